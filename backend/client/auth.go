@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"auth-app-backend/models"
+)
+
+// Login signs in and keeps the token for later calls. The password isn't
+// kept; see Client.Credentials for signing in again.
+func (c *Client) Login(ctx context.Context, email, password string) (*models.AuthResponse, error) {
+	var resp models.AuthResponse
+	creds := models.LoginRequest{Email: email, Password: password}
+	if err := c.doJSON(ctx, newCall(http.MethodPost, "/login", &resp), creds); err != nil {
+		return nil, err
+	}
+
+	c.SetToken(resp.Token)
+	return &resp, nil
+}
+
+// Signup creates an account and signs in as it
+func (c *Client) Signup(ctx context.Context, req models.SignupRequest) (*models.AuthResponse, error) {
+	var resp models.AuthResponse
+	if err := c.doJSON(ctx, newCall(http.MethodPost, "/signup", &resp), req); err != nil {
+		return nil, err
+	}
+
+	c.SetToken(resp.Token)
+	return &resp, nil
+}
+
+// Refresh trades the current token for a fresh one. Calls do this by
+// themselves shortly before the token expires.
+func (c *Client) Refresh(ctx context.Context) (*models.AuthResponse, error) {
+	token := c.Token()
+	if token == "" {
+		return nil, ErrNotAuthenticated
+	}
+	return c.refresh(ctx, token)
+}
+
+func (c *Client) refresh(ctx context.Context, token string) (*models.AuthResponse, error) {
+	var resp models.AuthResponse
+	cl := newCall(http.MethodPost, "/refresh", &resp)
+	cl.idempotent = true
+	if err := c.send(ctx, cl, token); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	// Keep a token another call got in the meantime
+	if c.token == token {
+		c.setTokenLocked(resp.Token)
+	}
+	c.mu.Unlock()
+	return &resp, nil
+}
+
+// Validate checks that the current token is accepted
+func (c *Client) Validate(ctx context.Context) error {
+	return c.do(ctx, newCall(http.MethodGet, "/validate", nil).authed())
+}
+
+// Logout signs out and forgets the token
+func (c *Client) Logout(ctx context.Context) error {
+	err := c.do(ctx, newCall(http.MethodPost, "/logout", nil).authed())
+	c.SetToken("")
+	return err
+}
@@ -0,0 +1,312 @@
+// Package client is a typed Go client for the Startony API. It speaks the
+// versioned API described by /api/v1/openapi.json and reuses the request
+// and response types of the models package.
+//
+//	c := client.New("http://localhost:8080/api/v1")
+//	if _, err := c.Login(ctx, email, password); err != nil { ... }
+//	projects, err := c.TrendingProjects(ctx, client.TrendingOptions{Limit: 10})
+//
+// Tokens are refreshed shortly before they expire, up to the server's
+// maximum session age; after that calls fail with ErrSessionExpired unless
+// a Credentials callback can sign in again. Idempotent calls are retried
+// on network errors and 429/502/503/504.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the API of a local development server
+const DefaultBaseURL = "http://localhost:8080/api/v1"
+
+// refreshWindow is how long before expiry a token is refreshed
+const refreshWindow = 5 * time.Minute
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+
+	// HTTPClient sends the requests
+	HTTPClient *http.Client
+	// MaxRetries is how many times an idempotent call is retried
+	MaxRetries int
+	// RetryWait is the wait before the first retry; it doubles each time
+	RetryWait time.Duration
+	// Credentials, when set, is asked for an email and password to sign in
+	// again once the token is rejected or can't be refreshed any more. The
+	// client doesn't keep them, so it can prompt a user or read a secret
+	// store each time.
+	Credentials func(ctx context.Context) (email, password string, err error)
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// New returns a client for the API at baseURL, such as DefaultBaseURL
+func New(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		RetryWait:  200 * time.Millisecond,
+	}
+}
+
+// SetToken makes the client use a token obtained elsewhere
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setTokenLocked(token)
+}
+
+// Token returns the token in use, if any
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+func (c *Client) setTokenLocked(token string) {
+	c.token = token
+	c.expiresAt = tokenExpiry(token)
+}
+
+// tokenExpiry reads the exp claim of a JWT. The signature isn't checked;
+// the server does that, the client only needs to know when to refresh.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+// call describes one API request
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	// body is sent as is with contentType; see doJSON for JSON bodies
+	body        []byte
+	contentType string
+	out         interface{}
+	auth        bool
+	// idempotent marks calls that are safe to retry; defaults by method
+	idempotent bool
+}
+
+func newCall(method, path string, out interface{}) *call {
+	return &call{
+		method:     method,
+		path:       path,
+		out:        out,
+		idempotent: method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete,
+	}
+}
+
+func (cl *call) authed() *call {
+	cl.auth = true
+	return cl
+}
+
+// optionalAuth sends the token with calls that work signed out but say
+// more when signed in
+func (c *Client) optionalAuth(cl *call) *call {
+	if c.Token() != "" {
+		cl.auth = true
+	}
+	return cl
+}
+
+// doJSON runs a call with in as its JSON body
+func (c *Client) doJSON(ctx context.Context, cl *call, in interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	cl.body, cl.contentType = body, "application/json"
+	return c.do(ctx, cl)
+}
+
+// do runs a call, refreshing the token first when needed, signing in
+// again once through Credentials if the token is rejected, and retrying
+// idempotent calls
+func (c *Client) do(ctx context.Context, cl *call) error {
+	if !cl.auth {
+		return c.send(ctx, cl, "")
+	}
+
+	token, err := c.validToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.send(ctx, cl, token)
+	if !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	// The token was rejected before it expired; sign in again if we can
+	token, reErr := c.relogin(ctx, token)
+	if reErr != nil {
+		return err
+	}
+	return c.send(ctx, cl, token)
+}
+
+// validToken returns a token that isn't about to expire
+func (c *Client) validToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	token, expiresAt := c.token, c.expiresAt
+	c.mu.Unlock()
+
+	if token == "" {
+		return "", ErrNotAuthenticated
+	}
+	if expiresAt.IsZero() || time.Until(expiresAt) > refreshWindow {
+		return token, nil
+	}
+
+	if time.Now().Before(expiresAt) {
+		_, err := c.refresh(ctx, token)
+		if err == nil {
+			return c.Token(), nil
+		}
+		// The token still works for a while; a later call tries again
+		if !errors.Is(err, ErrUnauthorized) {
+			return token, nil
+		}
+	}
+	return c.relogin(ctx, token)
+}
+
+// relogin signs in with the email and password from Credentials, unless
+// another call already replaced stale in the meantime
+func (c *Client) relogin(ctx context.Context, stale string) (string, error) {
+	if current := c.Token(); current != stale {
+		return current, nil
+	}
+	if c.Credentials == nil {
+		return "", ErrSessionExpired
+	}
+	email, password, err := c.Credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.Login(ctx, email, password); err != nil {
+		return "", err
+	}
+	return c.Token(), nil
+}
+
+// send performs a call with retries, using token if it's not empty
+func (c *Client) send(ctx context.Context, cl *call, token string) error {
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		after, err := c.sendOnce(ctx, cl, token)
+		if err == nil || !cl.idempotent || attempt >= c.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		delay := wait
+		if after > delay {
+			delay = after
+		}
+		wait *= 2
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) sendOnce(ctx context.Context, cl *call, token string) (time.Duration, error) {
+	u := c.baseURL + cl.path
+	if len(cl.query) > 0 {
+		u += "?" + cl.query.Encode()
+	}
+	var body io.Reader
+	if cl.body != nil {
+		body = bytes.NewReader(cl.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, cl.method, u, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if cl.contentType != "" {
+		req.Header.Set("Content-Type", cl.contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, &temporaryError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return retryAfter(resp), newAPIError(cl.method, cl.path, resp)
+	}
+	if cl.out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(cl.out); err != nil {
+		return 0, fmt.Errorf("%s %s: decoding response: %w", cl.method, cl.path, err)
+	}
+	return 0, nil
+}
+
+// retryAfter reads a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// pathf builds a path, escaping each argument as a path segment
+func pathf(format string, args ...interface{}) string {
+	for i, a := range args {
+		if s, ok := a.(string); ok {
+			args[i] = url.PathEscape(s)
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,347 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auth-app-backend/models"
+)
+
+// fakeToken builds an unsigned JWT expiring at exp; the client only reads
+// the exp claim
+func fakeToken(name string, exp time.Time) string {
+	enc := base64.RawURLEncoding
+	payload, _ := json.Marshal(map[string]interface{}{"exp": exp.Unix(), "sub": name})
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString(payload) + ".sig"
+}
+
+// tokenName reads back the name a fakeToken was made with
+func tokenName(r *http.Request) string {
+	parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Sub string `json:"sub"`
+	}
+	json.Unmarshal(payload, &claims)
+	return claims.Sub
+}
+
+func newTestClient(srv *httptest.Server) *Client {
+	c := New(srv.URL)
+	c.RetryWait = time.Millisecond
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestReloginOnceOnUnauthorized(t *testing.T) {
+	tests := []struct {
+		name string
+		// withCredentials sets a Credentials callback
+		withCredentials bool
+		// acceptNew makes the server accept tokens from the second login
+		acceptNew   bool
+		wantErr     error
+		wantLogins  int32
+		wantQueries int32
+		wantAsked   int32
+	}{
+		{"token rejected then accepted", true, true, nil, 2, 2, 1},
+		{"token rejected twice", true, false, ErrUnauthorized, 2, 2, 1},
+		{"no credentials callback", false, true, ErrUnauthorized, 1, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logins, queries, asked int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/login":
+					n := atomic.AddInt32(&logins, 1)
+					writeJSON(w, models.AuthResponse{Token: fakeToken(fmt.Sprint("login", n), time.Now().Add(time.Hour))})
+				case "/validate":
+					atomic.AddInt32(&queries, 1)
+					if tokenName(r) == "login1" || !tt.acceptNew {
+						http.Error(w, "Unauthorized", http.StatusUnauthorized)
+						return
+					}
+					writeJSON(w, map[string]string{"message": "Token is valid"})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			c := newTestClient(srv)
+			if tt.withCredentials {
+				c.Credentials = func(context.Context) (string, string, error) {
+					atomic.AddInt32(&asked, 1)
+					return "alice@example.com", "secret", nil
+				}
+			}
+			if _, err := c.Login(context.Background(), "alice@example.com", "secret"); err != nil {
+				t.Fatal(err)
+			}
+			err := c.Validate(context.Background())
+			if !errors.Is(err, tt.wantErr) && !(tt.wantErr == nil && err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if logins != tt.wantLogins || queries != tt.wantQueries || asked != tt.wantAsked {
+				t.Errorf("%d logins, %d calls and %d credential requests, want %d, %d and %d",
+					logins, queries, asked, tt.wantLogins, tt.wantQueries, tt.wantAsked)
+			}
+		})
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	tests := []struct {
+		name            string
+		withCredentials bool
+		wantErr         error
+		wantLogins      int32
+	}{
+		{"refresh refused", false, ErrSessionExpired, 0},
+		{"refresh refused, signed in again", true, nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logins, refreshes int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/refresh":
+					atomic.AddInt32(&refreshes, 1)
+					http.Error(w, "Session expired, log in again", http.StatusUnauthorized)
+				case "/login":
+					atomic.AddInt32(&logins, 1)
+					writeJSON(w, models.AuthResponse{Token: fakeToken("fresh", time.Now().Add(time.Hour))})
+				case "/validate":
+					if tokenName(r) != "fresh" {
+						http.Error(w, "Unauthorized", http.StatusUnauthorized)
+						return
+					}
+					writeJSON(w, map[string]string{"message": "Token is valid"})
+				}
+			}))
+			defer srv.Close()
+
+			c := newTestClient(srv)
+			if tt.withCredentials {
+				c.Credentials = func(context.Context) (string, string, error) {
+					return "alice@example.com", "secret", nil
+				}
+			}
+			// About to expire, so the client tries to refresh it first
+			c.SetToken(fakeToken("old", time.Now().Add(time.Minute)))
+
+			err := c.Validate(context.Background())
+			if !errors.Is(err, tt.wantErr) && !(tt.wantErr == nil && err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if refreshes != 1 || logins != tt.wantLogins {
+				t.Errorf("%d refreshes and %d logins, want 1 and %d", refreshes, logins, tt.wantLogins)
+			}
+		})
+	}
+}
+
+func TestRefreshFailureKeepsValidToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/refresh" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"message": "Token is valid"})
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	c.MaxRetries = 0
+	c.SetToken(fakeToken("old", time.Now().Add(time.Minute)))
+	if err := c.Validate(context.Background()); err != nil {
+		t.Errorf("err = %v, want the call made with the unexpired token", err)
+	}
+}
+
+func TestUnauthorizedWithoutCredentials(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	c.SetToken(fakeToken("elsewhere", time.Now().Add(time.Hour)))
+	if err := c.Validate(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if calls != 1 {
+		t.Errorf("%d calls, want 1", calls)
+	}
+}
+
+func TestPostIsNeverRetried(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.Header().Set("Retry-After", "0")
+				http.Error(w, "try later", status)
+			}))
+			defer srv.Close()
+
+			c := newTestClient(srv)
+			c.SetToken(fakeToken("alice", time.Now().Add(time.Hour)))
+			_, err := c.CreateProject(context.Background(), models.CreateProjectRequest{Name: "Widget"})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+				t.Fatalf("err = %v, want a %d APIError", err, status)
+			}
+			if calls != 1 {
+				t.Errorf("POST sent %d times, want once", calls)
+			}
+		})
+	}
+}
+
+func TestPostNotRetriedOnNetworkError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// Drop the connection without answering
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	c.SetToken(fakeToken("alice", time.Now().Add(time.Hour)))
+	if _, err := c.CreateProject(context.Background(), models.CreateProjectRequest{Name: "Widget"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("POST sent %d times, want once", calls)
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					w.Header().Set("Retry-After", "1")
+					http.Error(w, "slow down", status)
+					return
+				}
+				writeJSON(w, []models.Project{{Name: "Widget"}})
+			}))
+			defer srv.Close()
+
+			c := newTestClient(srv)
+			start := time.Now()
+			projects, err := c.Projects(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed < time.Second {
+				t.Errorf("retried after %v, before Retry-After", elapsed)
+			}
+			if calls != 2 || len(projects) != 1 {
+				t.Errorf("%d calls and %d projects, want 2 and 1", calls, len(projects))
+			}
+		})
+	}
+}
+
+func TestRetriesStopAtMaxRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	c.MaxRetries = 2
+	if _, err := c.Projects(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 3 {
+		t.Errorf("%d calls, want 3", calls)
+	}
+}
+
+func TestRetryWaitCanBeCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := newTestClient(srv).Projects(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's", err)
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	sentinels := []error{ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict, ErrRateLimited}
+	tests := []struct {
+		status      int
+		contentType string
+		body        string
+		want        error
+		wantMessage string
+	}{
+		{http.StatusBadRequest, "text/plain", "Invalid request body\n", ErrBadRequest, "Invalid request body"},
+		{http.StatusUnauthorized, "text/plain", "Unauthorized\n", ErrUnauthorized, "Unauthorized"},
+		{http.StatusForbidden, "application/json", `{"message": "Not a member"}`, ErrForbidden, "Not a member"},
+		{http.StatusNotFound, "application/json", `{"error": "User not found"}`, ErrNotFound, "User not found"},
+		{http.StatusConflict, "text/plain", "Canvas was changed", ErrConflict, "Canvas was changed"},
+		{http.StatusTooManyRequests, "text/plain", "", ErrRateLimited, ""},
+		{http.StatusInternalServerError, "text/plain", "Internal server error", nil, "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{"Content-Type": {tt.contentType}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			err := newAPIError("GET", "/thing", resp)
+			if err.StatusCode != tt.status || err.Message != tt.wantMessage {
+				t.Errorf("got %d %q, want %d %q", err.StatusCode, err.Message, tt.status, tt.wantMessage)
+			}
+			for _, s := range sentinels {
+				if got := errors.Is(err, s); got != (s == tt.want) {
+					t.Errorf("errors.Is(err, %v) = %v", s, got)
+				}
+			}
+			if !strings.Contains(err.Error(), "GET /thing") {
+				t.Errorf("Error() = %q lacks the call", err.Error())
+			}
+		})
+	}
+}
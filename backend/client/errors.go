@@ -0,0 +1,106 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors an *APIError matches with errors.Is, by status code
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// ErrNotAuthenticated is returned by calls that need a token before Login,
+// Signup or SetToken
+var ErrNotAuthenticated = errors.New("client: not authenticated")
+
+// ErrSessionExpired is returned once the token has expired and can't be
+// refreshed any more, without a Credentials callback to sign in again
+var ErrSessionExpired = errors.New("client: session expired, log in again")
+
+// APIError is a response with an error status
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message is the error the server gave, if any
+	Message string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+// Is lets errors.Is(err, ErrNotFound) and friends match by status
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError reads the error from a response. Most handlers answer with
+// plain text from http.Error; a few send JSON with a message field.
+func newAPIError(method, path string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	e := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode}
+
+	var payload struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		e.Message = payload.Message
+		if e.Message == "" {
+			e.Message = payload.Error
+		}
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// temporaryError wraps transport errors, which are worth retrying
+type temporaryError struct{ err error }
+
+func (e *temporaryError) Error() string { return e.err.Error() }
+func (e *temporaryError) Unwrap() error { return e.err }
+
+func retryable(err error) bool {
+	var tmp *temporaryError
+	if errors.As(err, &tmp) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
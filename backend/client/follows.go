@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"auth-app-backend/models"
+)
+
+// Follow makes the caller follow a user. Following again is a no-op.
+func (c *Client) Follow(ctx context.Context, username string) (*models.FollowResponse, error) {
+	var resp models.FollowResponse
+	if err := c.do(ctx, newCall(http.MethodPut, pathf("/users/%s/follow", username), &resp).authed()); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Unfollow makes the caller stop following a user
+func (c *Client) Unfollow(ctx context.Context, username string) (*models.FollowResponse, error) {
+	var resp models.FollowResponse
+	if err := c.do(ctx, newCall(http.MethodDelete, pathf("/users/%s/follow", username), &resp).authed()); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// IsFollowing reports whether the caller follows a user
+func (c *Client) IsFollowing(ctx context.Context, username string) (bool, error) {
+	var resp models.FollowStatusResponse
+	if err := c.do(ctx, newCall(http.MethodGet, pathf("/users/%s/follow/status", username), &resp).authed()); err != nil {
+		return false, err
+	}
+	return resp.Following, nil
+}
+
+// Followers lists a page of a user's followers. Pass the NextCursor of a
+// page to get the one after it.
+func (c *Client) Followers(ctx context.Context, username, cursor string) (*models.FollowListResponse, error) {
+	return c.followList(ctx, pathf("/users/%s/followers", username), cursor)
+}
+
+// Following lists a page of the users someone follows
+func (c *Client) Following(ctx context.Context, username, cursor string) (*models.FollowListResponse, error) {
+	return c.followList(ctx, pathf("/users/%s/following", username), cursor)
+}
+
+func (c *Client) followList(ctx context.Context, path, cursor string) (*models.FollowListResponse, error) {
+	var list models.FollowListResponse
+	cl := c.optionalAuth(newCall(http.MethodGet, path, &list))
+	if cursor != "" {
+		cl.query = url.Values{"cursor": {cursor}}
+	}
+	if err := c.do(ctx, cl); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// SuggestedUsers lists people the caller may want to follow
+func (c *Client) SuggestedUsers(ctx context.Context) ([]models.SuggestedUser, error) {
+	var users []models.SuggestedUser
+	if err := c.do(ctx, newCall(http.MethodGet, "/users/suggested", &users).authed()); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
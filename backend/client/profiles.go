@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"auth-app-backend/models"
+)
+
+// Profile returns a developer's public profile with their projects and
+// skills
+func (c *Client) Profile(ctx context.Context, username string) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	if err := c.do(ctx, newCall(http.MethodGet, pathf("/dev/%s", username), &profile)); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ProfileUpdate lists the profile fields to change; nil fields are kept
+type ProfileUpdate struct {
+	FirstName     *string
+	LastName      *string
+	Email         *string
+	Phone         *string
+	Bio           *string
+	GithubLink    *string
+	PortfolioLink *string
+	LinkedinLink  *string
+	CompanyName   *string
+
+	// ProfilePicture and Banner are image files to upload
+	ProfilePicture io.Reader
+	Banner         io.Reader
+}
+
+// UpdateProfile changes the caller's profile and returns it
+func (c *Client) UpdateProfile(ctx context.Context, update ProfileUpdate) (*models.User, error) {
+	// The form is built up front so retries can send it again
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"first_name", update.FirstName},
+		{"last_name", update.LastName},
+		{"email", update.Email},
+		{"phone", update.Phone},
+		{"bio", update.Bio},
+		{"github_link", update.GithubLink},
+		{"portfolio_link", update.PortfolioLink},
+		{"linkedin_link", update.LinkedinLink},
+		{"company_name", update.CompanyName},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		if err := form.WriteField(f.name, *f.value); err != nil {
+			return nil, err
+		}
+	}
+	files := []struct {
+		name string
+		data io.Reader
+	}{
+		{"profile_picture", update.ProfilePicture},
+		{"banner", update.Banner},
+	}
+	for _, f := range files {
+		if f.data == nil {
+			continue
+		}
+		part, err := form.CreateFormFile(f.name, f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(part, f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	cl := newCall(http.MethodPut, "/profile/update", &user).authed()
+	cl.body, cl.contentType = body.Bytes(), form.FormDataContentType()
+	if err := c.do(ctx, cl); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
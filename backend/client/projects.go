@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"auth-app-backend/models"
+)
+
+// Projects lists all projects
+func (c *Client) Projects(ctx context.Context) ([]models.Project, error) {
+	var projects []models.Project
+	if err := c.do(ctx, newCall(http.MethodGet, "/projects", &projects)); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// TrendingOptions filters TrendingProjects
+type TrendingOptions struct {
+	// Limit caps the number of projects; the server's default when zero
+	Limit int
+	// Tag only keeps projects with this tag
+	Tag string
+}
+
+// TrendingProjects lists the currently trending projects, best first
+func (c *Client) TrendingProjects(ctx context.Context, opts TrendingOptions) ([]models.TrendingProject, error) {
+	cl := newCall(http.MethodGet, "/projects/trending", nil)
+	cl.query = url.Values{}
+	if opts.Limit > 0 {
+		cl.query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Tag != "" {
+		cl.query.Set("tag", opts.Tag)
+	}
+
+	var projects []models.TrendingProject
+	cl.out = &projects
+	if err := c.do(ctx, cl); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// CreateProject creates a project owned by the caller. It's never retried,
+// so a failed call may still have created the project.
+func (c *Client) CreateProject(ctx context.Context, project models.CreateProjectRequest) (*models.Project, error) {
+	var created models.Project
+	if err := c.doJSON(ctx, newCall(http.MethodPost, "/projects/create", &created).authed(), project); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Project returns the detail page of a developer's project. When signed
+// in it says whether the caller saved or starred it.
+func (c *Client) Project(ctx context.Context, username, projectName string) (*models.Project, error) {
+	var project models.Project
+	if err := c.do(ctx, c.optionalAuth(newCall(http.MethodGet, pathf("/dev/%s/%s", username, projectName), &project))); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// UserProjects lists a developer's projects
+func (c *Client) UserProjects(ctx context.Context, username string) ([]models.Project, error) {
+	var projects []models.Project
+	if err := c.do(ctx, newCall(http.MethodGet, pathf("/dev/%s/projects", username), &projects)); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// SavedProjects lists the projects the caller saved, most recent first.
+// Only a summary of each developer is included.
+func (c *Client) SavedProjects(ctx context.Context) ([]models.Project, error) {
+	var projects []models.Project
+	if err := c.do(ctx, newCall(http.MethodGet, "/projects/saved", &projects).authed()); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// SaveProject saves a project for the caller. Saving it again is a no-op.
+func (c *Client) SaveProject(ctx context.Context, projectID int) error {
+	cl := newCall(http.MethodPost, pathf("/projects/%d/save", projectID), nil).authed()
+	cl.idempotent = true
+	return c.do(ctx, cl)
+}
+
+// UnsaveProject removes a project from the caller's saved projects
+func (c *Client) UnsaveProject(ctx context.Context, projectID int) error {
+	return c.do(ctx, newCall(http.MethodDelete, pathf("/projects/%d/save", projectID), nil).authed())
+}
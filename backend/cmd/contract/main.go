@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"auth-app-backend/openapi"
+)
+
+// paths collects repeated -path flags
+type paths []string
+
+func (p *paths) String() string     { return strings.Join(*p, ",") }
+func (p *paths) Set(v string) error { *p = append(*p, v); return nil }
+
+// Checks a running server against its own OpenAPI document: every GET
+// without path parameters is called, plus any -path given, and each
+// response is validated. Exits non-zero when a response diverges, so it
+// can gate a deploy. TestContract and TestContractPopulated cover the
+// operations without a database; this is the optional smoke check of a
+// real deployment.
+func main() {
+	base := flag.String("base", "http://localhost:8080/api/v1", "API base URL")
+	token := flag.String("token", "", "bearer token for authenticated operations")
+	var extra paths
+	flag.Var(&extra, "path", "extra path to GET, e.g. /dev/alice (repeatable)")
+	flag.Parse()
+
+	fmt.Println("Fetching", *base+"/openapi.json")
+	resp, err := http.Get(*base + "/openapi.json")
+	if err != nil {
+		log.Fatal("Error fetching the document: ", err)
+	}
+	var doc openapi.Document
+	err = json.NewDecoder(resp.Body).Decode(&doc)
+	resp.Body.Close()
+	if err != nil {
+		log.Fatal("Error decoding the document: ", err)
+	}
+
+	var targets []string
+	for path, item := range doc.Paths {
+		op := (*item)["get"]
+		if op == nil || strings.Contains(path, "{") {
+			continue
+		}
+		if len(op.Security) > 0 && *token == "" {
+			continue
+		}
+		targets = append(targets, path)
+	}
+	sort.Strings(targets)
+	targets = append(targets, extra...)
+
+	failed := 0
+	for _, path := range targets {
+		if err := check(&doc, *base, path, *token); err != nil {
+			fmt.Println("FAIL", err)
+			failed++
+			continue
+		}
+		fmt.Println("ok  ", path)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d responses diverge from the document\n", failed, len(targets))
+		os.Exit(1)
+	}
+	fmt.Printf("All %d responses match the document\n", len(targets))
+}
+
+func check(doc *openapi.Document, base, path, token string) error {
+	req, err := http.NewRequest("GET", base+path, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	route := path
+	if i := strings.IndexByte(route, '?'); i >= 0 {
+		route = route[:i]
+	}
+	return doc.Validate("GET", route, resp.StatusCode, resp.Header.Get("Content-Type"), body)
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-app-backend/database"
+	"auth-app-backend/openapi"
+	"auth-app-backend/ratelimit"
+	"auth-app-backend/storage"
+	"auth-app-backend/utils"
+)
+
+// stubDriver answers each statement with the rows of the first fixture
+// whose pattern matches it, and with no rows when none does, so handlers
+// run their real code paths without a database
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+// stubFixture holds the rows returned for statements matching pattern
+type stubFixture struct {
+	pattern *regexp.Regexp
+	rows    [][]driver.Value
+}
+
+// stubFixtures is set by a test before it calls the handlers
+var stubFixtures []stubFixture
+
+type stubConn struct{}
+
+func (stubConn) Prepare(query string) (driver.Stmt, error) { return stubStmt{query}, nil }
+func (stubConn) Close() error                              { return nil }
+func (stubConn) Begin() (driver.Tx, error)                 { return stubTx{}, nil }
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubStmt struct {
+	query string
+}
+
+func (stubStmt) Close() error  { return nil }
+func (stubStmt) NumInput() int { return -1 }
+
+func (s stubStmt) fixture() [][]driver.Value {
+	for _, f := range stubFixtures {
+		if f.pattern.MatchString(s.query) {
+			return f.rows
+		}
+	}
+	return nil
+}
+
+func (s stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(len(s.fixture())), nil
+}
+
+func (s stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &stubRows{rows: s.fixture()}, nil
+}
+
+type stubRows struct {
+	rows [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *stubRows) Close() error { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("stub", stubDriver{})
+}
+
+// samplePathValues fill the variables of route paths
+var samplePathValues = map[string]string{
+	"id":       "1",
+	"userId":   "2",
+	"version":  "1",
+	"code":     "demo",
+	"project":  "demo",
+	"username": "alice",
+	"skillId":  "1",
+	"slug":     "go",
+}
+
+var pathVariable = regexp.MustCompile(`\{([^}:]+)(?::([^}]*))?\}`)
+
+// samplePath turns a route template into a concrete path, taking the
+// first alternative of patterned variables
+func samplePath(t *testing.T, template string) string {
+	return pathVariable.ReplaceAllStringFunc(template, func(v string) string {
+		m := pathVariable.FindStringSubmatch(v)
+		if m[2] != "" {
+			return strings.Split(m[2], "|")[0]
+		}
+		value, ok := samplePathValues[m[1]]
+		if !ok {
+			t.Fatalf("no sample value for {%s} in %s", m[1], template)
+		}
+		return value
+	})
+}
+
+// contractRequest builds a request exercising route, with a bearer token
+// when the route needs one
+func contractRequest(t *testing.T, route openapi.Route, token string) *http.Request {
+	path := apiPrefix + samplePath(t, route.Path)
+
+	var body io.Reader
+	switch {
+	case route.Path == "/graphql" && route.Method == http.MethodGet:
+		path += "?query=" + url.QueryEscape("{ __typename }")
+	case route.Path == "/graphql":
+		body = strings.NewReader(`{"query": "{ __typename }"}`)
+	case route.Body != nil:
+		raw, err := json.Marshal(route.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req := httptest.NewRequest(route.Method, path, body)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if route.Auth {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// contractRouter serves the API with strict contract checking over the
+// stub database, returning a token for alice (user 1)
+func contractRouter(t *testing.T) (http.Handler, []openapi.Route, string) {
+	t.Helper()
+	db, err := sql.Open("stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = saved
+		db.Close()
+	})
+
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStore := storage.Default
+	storage.Default = store
+	t.Cleanup(func() { storage.Default = savedStore })
+
+	token, err := utils.GenerateToken(1, "alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Handlers log the errors the stub causes
+	savedLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { slog.SetDefault(savedLogger) })
+
+	routes := apiRoutes()
+	return newRouter(routes, &ratelimit.Limiter{Store: ratelimit.NewMemory()}, openapi.CheckStrict), routes, token
+}
+
+// checkContract calls route through router, failing on a contract
+// violation, on a request that didn't reach the route and, when
+// wantSuccess is set, on anything but a success
+func checkContract(t *testing.T, router http.Handler, route openapi.Route, token string, wantSuccess bool) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, contractRequest(t, route, token))
+
+	body := rec.Body.String()
+	if strings.HasPrefix(body, "API contract violation") {
+		t.Fatalf("%d %s", rec.Code, strings.TrimSpace(body))
+	}
+	if rec.Code == http.StatusNotFound && body == "404 page not found\n" ||
+		rec.Code == http.StatusMethodNotAllowed && body == "" {
+		t.Fatalf("the request didn't reach the route: %d", rec.Code)
+	}
+	if wantSuccess && rec.Code >= 300 {
+		t.Fatalf("status %d, want success: %s", rec.Code, strings.TrimSpace(body))
+	}
+	return body
+}
+
+// TestContract calls every operation of the API through the real router
+// with strict contract checking, failing on any response that diverges
+// from the OpenAPI document. The database is stubbed out with no rows, so
+// lookups find nothing: this covers the empty and error paths of every
+// handler, and TestContractPopulated covers the found ones.
+func TestContract(t *testing.T) {
+	stubFixtures = nil
+	router, routes, token := contractRouter(t)
+
+	// Operations that must succeed even with nothing in the database, so
+	// their success bodies are checked too
+	wantSuccess := map[string]bool{}
+	for _, name := range []string{
+		"GET /health", "POST /logout", "GET /validate", "GET /projects",
+		"GET /projects/saved", "GET /projects/trending", "GET /invitations", "GET /applications",
+		"POST /notifications/read", "GET /recommendations/projects", "GET /tags", "GET /skills",
+		"PUT /profile/skills", "GET /pipeline", "GET /feed", "GET /users/suggested",
+		"POST /graphql", "GET /graphql",
+	} {
+		wantSuccess[name] = true
+	}
+
+	for _, route := range routes {
+		name := route.Method + " " + route.Path
+		t.Run(name, func(t *testing.T) {
+			checkContract(t, router, route, token, wantSuccess[name])
+		})
+	}
+}
+
+// TestMetricsNotPublic keeps the metrics off the public router; they're
+// served on their own listener
+func TestMetricsNotPublic(t *testing.T) {
+	router := newRouter(apiRoutes(), &ratelimit.Limiter{Store: ratelimit.NewMemory()}, openapi.CheckOff)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /metrics on the API router got %d, want 404", rec.Code)
+	}
+}
+
+// TestContractPopulated checks the success bodies of operations that need
+// something in the database, answering their queries with fixture rows
+func TestContractPopulated(t *testing.T) {
+	stubFixtures = populatedFixtures
+	defer func() { stubFixtures = nil }()
+	router, routes, token := contractRouter(t)
+
+	ran := 0
+	for _, route := range routes {
+		name := route.Method + " " + route.Path
+		want, ok := populatedOperations[name]
+		if !ok {
+			continue
+		}
+		ran++
+		t.Run(name, func(t *testing.T) {
+			body := checkContract(t, router, route, token, true)
+			if !strings.Contains(body, want) {
+				t.Errorf("body lacks the fixture rows, want %s in %s", want, body)
+			}
+		})
+	}
+	if ran != len(populatedOperations) {
+		t.Errorf("ran %d of %d populated operations; one is no longer routed", ran, len(populatedOperations))
+	}
+}
+
+// fixtureTime stamps every fixture row
+var fixtureTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fixture answers the statements matching pattern with rows
+func fixture(pattern string, rows ...[]driver.Value) stubFixture {
+	return stubFixture{pattern: regexp.MustCompile(pattern), rows: rows}
+}
+
+// canvasRow is a version of the demo project's canvas, edited by alice
+func canvasRow(version int64) []driver.Value {
+	row := []driver.Value{int64(1), version}
+	// One list for each of the nine blocks
+	for range 9 {
+		row = append(row, "{\"Early adopters\"}")
+	}
+	return append(row, fixtureTime, false, int64(1), "alice", "Alice", "Smith", nil)
+}
+
+// dealRow is alice's deal on the demo project
+func dealRow() []driver.Value {
+	return []driver.Value{
+		int64(1), "in_talks", int64(25000), "Keen to talk", fixtureTime, fixtureTime,
+		int64(1), "Demo", "demo", "active",
+	}
+}
+
+// populatedFixtures answer the queries behind populatedOperations. Alice
+// (user 1) owns the demo project (1), has a deal on it and lists Go. The
+// first fixture matching a statement answers it, so narrower patterns go
+// before broader ones.
+var populatedFixtures = []stubFixture{
+	fixture(`FROM users u\s+LEFT JOIN followers f1`, []driver.Value{
+		int64(1), "alice", "alice@example.com", "Alice", "Smith", nil, "developer",
+		"https://github.com/alice", nil, nil, nil,
+		"/uploads/avatar.jpg", nil, "Builds things", fixtureTime, fixtureTime,
+		int64(3), int64(2),
+	}),
+	fixture(`FROM projects p\s+JOIN users u ON p.user_id = u.id\s+WHERE p.user_id = \$1`, []driver.Value{
+		int64(1), "Demo", "A demo project", "demo", "{saas}", "{Go,React}", "active", int64(4), fixtureTime,
+		"{/uploads/demo.jpg}", int64(1), "alice", "Alice", "Smith", nil, int64(2),
+	}),
+	fixture(`WHERE p.name = \$2 AND u.username = \$3`, []driver.Value{
+		int64(1), int64(1), "Demo", "A demo project", "demo", "{saas}", "{Go,React}", "active", int64(4), fixtureTime,
+		"{/uploads/demo.jpg}", "alice", "alice@example.com", int64(4), int64(2), true, false,
+	}),
+	fixture(`FROM business_model_canvases bc`, canvasRow(2), canvasRow(1)),
+	fixture(`SELECT COUNT\(\*\) FILTER \(WHERE state <> 'cancelled'\)`, []driver.Value{int64(3), int64(1)}),
+	fixture(`FROM project_members m\s+JOIN users u ON u.id = m.user_id`,
+		[]driver.Value{int64(1), "alice", "Alice", "Smith", nil, "owner", fixtureTime},
+		[]driver.Value{int64(2), "bob", nil, nil, nil, "developer", fixtureTime},
+	),
+	fixture(`SELECT TRUE, m.role\s+FROM projects p`, []driver.Value{true, "owner"}),
+	fixture(`SELECT EXISTS\(SELECT 1 FROM projects WHERE id = \$1\)`, []driver.Value{true}),
+	// Yesterday is within the default range even if the day turns mid-test
+	fixture(`FROM project_daily_stats`, []driver.Value{
+		time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1),
+		int64(7), int64(5), int64(2), int64(1), int64(1), int64(1),
+	}),
+	fixture(`SELECT COUNT\(DISTINCT viewer_key\)`, []driver.Value{int64(5)}),
+	fixture(`FROM investor_profiles ip\s+JOIN users u`, []driver.Value{
+		int64(10000), nil, "USD", "{saas}", "{seed}", "Developer tools", fixtureTime,
+		int64(1), "alice", "Alice", "Smith", nil, "Alice Ventures", nil,
+	}),
+	fixture(`SELECT d.stage, d.amount, d.project_id, p.name`, []driver.Value{"in_talks", int64(25000), int64(1), "Demo"}),
+	fixture(`FROM investment_interests d\s+JOIN projects p ON p.id = d.project_id\s+JOIN users u`, append(dealRow(),
+		int64(1), "alice", "Alice", "Smith", nil, "Alice Ventures", nil,
+		true, int64(10000), nil, "USD", "{saas}", "{seed}", "Developer tools",
+	)),
+	fixture(`FROM investment_interests d\s+JOIN projects p ON p.id = d.project_id`, append(dealRow(), int64(1))),
+	fixture(`SELECT investor_id FROM investment_interests`, []driver.Value{int64(1)}),
+	fixture(`SELECT id, body, created_at FROM deal_notes`, []driver.Value{int64(1), "Call back in May", fixtureTime}),
+	fixture(`FROM skills s\s+WHERE s.approved`, []driver.Value{int64(1), "Go", "language"}),
+	fixture(`SELECT id FROM users WHERE username`, []driver.Value{int64(2)}),
+	fixture(`SELECT u.id, s.name\s+FROM users u\s+JOIN user_skills`, []driver.Value{int64(2), "Go"}),
+	fixture(`^\s*SELECT COUNT\(\*\) FROM skill_endorsements WHERE`, []driver.Value{int64(4)}),
+	fixture(`FROM image_variants`, []driver.Value{
+		"/uploads/demo.jpg", "/uploads/demo-medium.jpg", "/uploads/demo-thumb.jpg", int64(1200), int64(800),
+	}),
+	fixture(`FROM user_skills us\s+JOIN skills s`, []driver.Value{
+		int64(1), "Go", "language", "expert", int64(3), true,
+	}),
+	fixture(`SELECT id, cover_image FROM projects`, []driver.Value{int64(1), "/uploads/demo.jpg"}),
+	fixture(`SELECT k.key, s.id, s.name, s.category`, []driver.Value{"go", int64(1), "Go", "language"}),
+	fixture(`SELECT p.programming_tags, p.created_at`, []driver.Value{"{Go,React}", fixtureTime}),
+}
+
+// populatedOperations map each operation to a part of its body that only
+// shows up once the fixture rows were read
+var populatedOperations = map[string]string{
+	"GET /dev/{username}":                             `"code":"demo"`,
+	"GET /dev/{username}/{project}":                   `"team":[{`,
+	"GET /projects/{id}/members":                      `"role":"developer"`,
+	"GET /projects/{id}/canvas/history":               `"version":1`,
+	"GET /projects/{id}/analytics":                    `"views":7`,
+	"GET /pipeline":                                   `"notes_count":1`,
+	"PUT /pipeline/{id}":                              `"stage":"in_talks"`,
+	"GET /projects/{id}/investors":                    `"investor_profile":{`,
+	"GET /investors/{username}":                       `"thesis":"Developer tools"`,
+	"GET /investors/me":                               `"thesis":"Developer tools"`,
+	"GET /pipeline/{id}/notes":                        `"body":"Call back in May"`,
+	"GET /skills":                                     `"name":"Go"`,
+	"GET /users/{username}/skills":                    `"endorsed_by_me":true`,
+	"PUT /profile/skills":                             `"level":"expert"`,
+	"POST /users/{username}/skills/{skillId}/endorse": `"endorsements":4`,
+}
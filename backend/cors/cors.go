@@ -0,0 +1,175 @@
+// Package cors answers cross-origin requests for the whole server. It wraps
+// the router, so preflights are handled before routing and no handler has
+// to set CORS headers itself.
+package cors
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config lists what cross-origin callers may do
+type Config struct {
+	// AllowedOrigins are exact origins such as http://localhost:3000, or
+	// patterns where * stands for any subdomain, as in
+	// https://*.startony.com. A lone * allows every origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read
+	ExposedHeaders []string
+	// AllowCredentials lets pages send cookies and read the response.
+	// The frontend authenticates with a bearer token, which needs none of
+	// it. It can't be combined with a lone *: New turns it off then.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight
+	MaxAge time.Duration
+}
+
+// DefaultConfig allows the local frontend to call the API with its bearer
+// token
+var DefaultConfig = Config{
+	AllowedOrigins: []string{"http://localhost:3000"},
+	AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+	AllowedHeaders: []string{"Content-Type", "Authorization"},
+	ExposedHeaders: []string{
+		"Content-Disposition", "Deprecation", "Link", "Retry-After", "X-Request-ID",
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+	},
+	MaxAge: 10 * time.Minute,
+}
+
+// ConfigFromEnv is DefaultConfig with the origins taken from the
+// comma-separated CORS_ALLOWED_ORIGINS, when set, and credentials allowed
+// when CORS_ALLOW_CREDENTIALS=true
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig
+	cfg.AllowCredentials = os.Getenv("CORS_ALLOW_CREDENTIALS") == "true"
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		cfg.AllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+			}
+		}
+	}
+	return cfg
+}
+
+// hostLabels is what * matches in an origin pattern: one or more DNS labels
+const hostLabels = `[a-z0-9-]+(\.[a-z0-9-]+)*`
+
+// Handler applies a Config
+type Handler struct {
+	next     http.Handler
+	cfg      Config
+	any      bool
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+	methods  string
+	headers  string
+	exposed  string
+	maxAge   string
+}
+
+// New wraps next with cfg. Credentials are turned off when every origin is
+// allowed, since any site could otherwise act with the caller's cookies.
+func New(cfg Config, next http.Handler) *Handler {
+	h := &Handler{
+		next:    next,
+		cfg:     cfg,
+		exact:   map[string]bool{},
+		methods: strings.Join(cfg.AllowedMethods, ", "),
+		headers: strings.Join(cfg.AllowedHeaders, ", "),
+		exposed: strings.Join(cfg.ExposedHeaders, ", "),
+	}
+	if cfg.MaxAge > 0 {
+		h.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimRight(origin, "/"))
+		switch {
+		case origin == "*":
+			h.any = true
+		case strings.Contains(origin, "*"):
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, hostLabels)
+			h.patterns = append(h.patterns, regexp.MustCompile("^"+pattern+"$"))
+		default:
+			h.exact[origin] = true
+		}
+	}
+	if h.any && cfg.AllowCredentials {
+		slog.Warn("CORS allows every origin, so credentials are turned off")
+		h.cfg.AllowCredentials = false
+	}
+	return h
+}
+
+func (h *Handler) allowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	if h.any || h.exact[origin] {
+		return true
+	}
+	for _, p := range h.patterns {
+		if p.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	// Responses differ by origin, so caches must keep them apart
+	header.Add("Vary", "Origin")
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	if origin == "" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	if !h.allowed(origin) {
+		if preflight {
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+		// Without CORS headers the browser keeps the response from the page
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if h.any {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if h.cfg.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if preflight {
+		header.Set("Access-Control-Allow-Methods", h.methods)
+		header.Set("Access-Control-Allow-Headers", h.headers)
+		if h.maxAge != "" {
+			header.Set("Access-Control-Max-Age", h.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if h.exposed != "" {
+		header.Set("Access-Control-Expose-Headers", h.exposed)
+	}
+	h.next.ServeHTTP(w, r)
+}
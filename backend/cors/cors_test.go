@@ -0,0 +1,74 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serve(cfg Config, method, origin string) http.Header {
+	h := New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(method, "/", nil)
+	r.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec.Header()
+}
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		origin      string
+		wantOrigin  string
+		wantCreds   string
+	}{
+		{"off by default", DefaultConfig.AllowedOrigins, DefaultConfig.AllowCredentials, "http://localhost:3000", "http://localhost:3000", ""},
+		{"exact origin with credentials", []string{"https://app.example.com"}, true, "https://app.example.com", "https://app.example.com", "true"},
+		{"pattern with credentials", []string{"https://*.example.com"}, true, "https://beta.example.com", "https://beta.example.com", "true"},
+		{"wildcard", []string{"*"}, false, "https://evil.example", "*", ""},
+		{"wildcard turns credentials off", []string{"*"}, true, "https://evil.example", "*", ""},
+		{"wildcard among others", []string{"https://app.example.com", "*"}, true, "https://app.example.com", "*", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig
+			cfg.AllowedOrigins = tt.origins
+			cfg.AllowCredentials = tt.credentials
+			for _, method := range []string{http.MethodGet, http.MethodOptions} {
+				h := serve(cfg, method, tt.origin)
+				if got := h.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+					t.Errorf("%s: Allow-Origin = %q, want %q", method, got, tt.wantOrigin)
+				}
+				if got := h.Get("Access-Control-Allow-Credentials"); got != tt.wantCreds {
+					t.Errorf("%s: Allow-Credentials = %q, want %q", method, got, tt.wantCreds)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	cfg := ConfigFromEnv()
+	if len(cfg.AllowedOrigins) != 2 || cfg.AllowedOrigins[1] != "https://b.example.com" || cfg.AllowCredentials {
+		t.Errorf("got origins %q and credentials %v", cfg.AllowedOrigins, cfg.AllowCredentials)
+	}
+
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	if !ConfigFromEnv().AllowCredentials {
+		t.Error("CORS_ALLOW_CREDENTIALS=true left credentials off")
+	}
+}
+
+func TestOriginNotAllowed(t *testing.T) {
+	h := serve(DefaultConfig, http.MethodGet, "https://evil.example")
+	if got := h.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Allow-Origin = %q for an unknown origin", got)
+	}
+}
@@ -0,0 +1,265 @@
+package export
+
+import (
+	"auth-app-backend/models"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Section names accepted in Template.Sections
+const (
+	SectionOverview  = "overview"
+	SectionStatus    = "status"
+	SectionTags      = "tags"
+	SectionDeveloper = "developer"
+	SectionImages    = "images"
+	SectionCanvas    = "canvas"
+)
+
+var knownSections = map[string]bool{
+	SectionOverview:  true,
+	SectionStatus:    true,
+	SectionTags:      true,
+	SectionDeveloper: true,
+	SectionImages:    true,
+	SectionCanvas:    true,
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Placeholders Title, Subtitle and Footer may contain. Each is replaced by
+// plain text; nothing else in those strings is interpreted.
+const (
+	PlaceholderProject   = "{{project}}"
+	PlaceholderCode      = "{{code}}"
+	PlaceholderDeveloper = "{{developer}}"
+	PlaceholderStatus    = "{{status}}"
+	PlaceholderDate      = "{{date}}"
+)
+
+var knownPlaceholders = map[string]bool{
+	PlaceholderProject:   true,
+	PlaceholderCode:      true,
+	PlaceholderDeveloper: true,
+	PlaceholderStatus:    true,
+	PlaceholderDate:      true,
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// maxTemplateText caps Title, Subtitle and Footer, in characters
+const maxTemplateText = 200
+
+// Template controls the layout of an export. Title, Subtitle and Footer are
+// text that may contain the placeholders above.
+type Template struct {
+	Title        string   `json:"title"`
+	Subtitle     string   `json:"subtitle"`
+	Footer       string   `json:"footer"`
+	AccentColor  string   `json:"accent_color"`
+	Sections     []string `json:"sections"`
+	CanvasLayout string   `json:"canvas_layout"`
+}
+
+// DefaultTemplate is used for projects that haven't customised their export
+func DefaultTemplate() Template {
+	return Template{
+		Title:        PlaceholderProject,
+		Subtitle:     "Business Model",
+		Footer:       PlaceholderProject + " · " + PlaceholderCode,
+		AccentColor:  "#4F46E5",
+		Sections:     []string{SectionOverview, SectionStatus, SectionTags, SectionDeveloper, SectionImages, SectionCanvas},
+		CanvasLayout: "grid",
+	}
+}
+
+// Validate checks that t can be rendered and that its text only uses
+// known placeholders
+func (t Template) Validate() error {
+	if err := t.validateLayout(); err != nil {
+		return err
+	}
+
+	for _, field := range []struct{ name, text string }{
+		{"title", t.Title}, {"subtitle", t.Subtitle}, {"footer", t.Footer},
+	} {
+		if utf8.RuneCountInString(field.text) > maxTemplateText {
+			return fmt.Errorf("%s must be at most %d characters", field.name, maxTemplateText)
+		}
+		for _, p := range placeholderPattern.FindAllString(field.text, -1) {
+			if !knownPlaceholders[p] {
+				return fmt.Errorf("unknown placeholder %s in %s", p, field.name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateLayout checks everything but the text, which renders as-is
+// whatever it contains
+func (t Template) validateLayout() error {
+	if !hexColor.MatchString(t.AccentColor) {
+		return fmt.Errorf("accent_color must look like #RRGGBB")
+	}
+	if t.CanvasLayout != "grid" && t.CanvasLayout != "list" {
+		return fmt.Errorf("canvas_layout must be grid or list")
+	}
+	if len(t.Sections) == 0 {
+		return fmt.Errorf("at least one section is required")
+	}
+
+	seen := map[string]bool{}
+	for _, s := range t.Sections {
+		if !knownSections[s] {
+			return fmt.Errorf("unknown section %q", s)
+		}
+		if seen[s] {
+			return fmt.Errorf("section %q listed twice", s)
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+// fill substitutes the placeholders in text with the document's values.
+// Text saved before it was validated is capped rather than rejected.
+func fill(text string, doc Document) string {
+	if utf8.RuneCountInString(text) > maxTemplateText {
+		text = string([]rune(text)[:maxTemplateText])
+	}
+
+	developer := ""
+	if doc.Project.Developer != nil {
+		developer = doc.Project.Developer.Username
+	}
+	date := doc.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	values := map[string]string{
+		PlaceholderProject:   doc.Project.Name,
+		PlaceholderCode:      doc.Project.Code,
+		PlaceholderDeveloper: developer,
+		PlaceholderStatus:    doc.Project.Status,
+		PlaceholderDate:      date.Format("January 2, 2006"),
+	}
+
+	// A single pass, so values containing placeholders aren't expanded
+	return placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		if v, ok := values[p]; ok {
+			return v
+		}
+		return p
+	})
+}
+
+// Image is a picture ready to embed, always JPEG encoded
+type Image struct {
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// NewImage prepares any supported image for embedding. Non-JPEG images are
+// flattened onto white and re-encoded since both formats embed JPEG as-is.
+func NewImage(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// Grayscale and CMYK JPEGs are re-encoded so every embedded image is RGB
+	if format == "jpeg" && cfg.ColorModel == color.YCbCrModel {
+		return &Image{Data: data, Width: cfg.Width, Height: cfg.Height}, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return &Image{Data: buf.Bytes(), Width: b.Dx(), Height: b.Dy()}, nil
+}
+
+// Document is everything an export renders
+type Document struct {
+	Project  models.Project
+	Canvas   *models.BusinessModelCanvas
+	Images   []*Image
+	Template Template
+	// Date fills {{date}}, today when zero
+	Date time.Time
+}
+
+// Format is an output file type
+type Format string
+
+const (
+	PDF  Format = "pdf"
+	PPTX Format = "pptx"
+)
+
+// ContentType is the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case PDF:
+		return "application/pdf"
+	case PPTX:
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	}
+	return "application/octet-stream"
+}
+
+// Render lays the document out and encodes it in the requested format
+func Render(doc Document, format Format) ([]byte, error) {
+	if err := doc.Template.validateLayout(); err != nil {
+		return nil, err
+	}
+
+	pages, err := layout(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case PDF:
+		return renderPDF(pages)
+	case PPTX:
+		return renderPPTX(pages, doc.Project.Name)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// FileName is a download name for the export of project p
+func FileName(p models.Project, format Format) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		}
+		return '_'
+	}, p.Name)
+	if name == "" {
+		name = "project"
+	}
+	return name + "_Business_Model." + string(format)
+}
@@ -0,0 +1,269 @@
+package export
+
+import (
+	"archive/zip"
+	"auth-app-backend/models"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleDocument(t *testing.T) Document {
+	t.Helper()
+
+	src := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 4; y++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 30), G: 120, B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	img, err := NewImage(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	company := "Acme & Co"
+	return Document{
+		Project: models.Project{
+			Name:            "Widget <Pro>",
+			Code:            "WID-1",
+			Description:     strings.Repeat("A long description that wraps over several lines. ", 40),
+			Status:          "in_progress",
+			GeneralTags:     []string{"saas"},
+			ProgrammingTags: []string{"go"},
+			Developer:       &models.User{Username: "dev", CompanyName: &company},
+		},
+		Canvas: &models.BusinessModelCanvas{
+			KeyPartners:       []string{"Suppliers"},
+			ValuePropositions: []string{"Faster widgets", "Cheaper (really)"},
+			RevenueStreams:    []string{"Subscriptions"},
+		},
+		Images:   []*Image{img, img},
+		Template: DefaultTemplate(),
+		Date:     time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFill(t *testing.T) {
+	doc := Document{
+		Project: models.Project{Name: "Widget", Code: "WID-1", Status: "done", Developer: &models.User{Username: "dev"}},
+		Date:    time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name, text, want string
+	}{
+		{"plain", "Business Model", "Business Model"},
+		{"all placeholders", "{{project}} {{code}} {{developer}} {{status}} {{date}}", "Widget WID-1 dev done March 5, 2024"},
+		{"unknown left literal", "{{range 1000000000}}x{{end}}", "{{range 1000000000}}x{{end}}"},
+		{"capped", strings.Repeat("a", maxTemplateText+50), strings.Repeat("a", maxTemplateText)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fill(tt.text, doc); got != tt.want {
+				t.Errorf("fill(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	// Values are not expanded again
+	doc.Project.Name = "{{code}}"
+	if got := fill("{{project}}", doc); got != "{{code}}" {
+		t.Errorf("fill expanded a placeholder inside a value: %q", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*Template)
+		wantErr bool
+	}{
+		{"default", func(*Template) {}, false},
+		{"unknown placeholder", func(t *Template) { t.Footer = "{{range 1000000000}}" }, true},
+		{"template action", func(t *Template) { t.Subtitle = "{{.Project.Developer.Email}}" }, true},
+		{"too long", func(t *Template) { t.Title = strings.Repeat("x", maxTemplateText+1) }, true},
+		{"bad color", func(t *Template) { t.AccentColor = "red" }, true},
+		{"bad canvas layout", func(t *Template) { t.CanvasLayout = "table" }, true},
+		{"no sections", func(t *Template) { t.Sections = nil }, true},
+		{"unknown section", func(t *Template) { t.Sections = []string{"pricing"} }, true},
+		{"duplicate section", func(t *Template) { t.Sections = []string{SectionTags, SectionTags} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := DefaultTemplate()
+			tt.edit(&tmpl)
+			if err := tmpl.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	for _, layoutName := range []string{"grid", "list"} {
+		t.Run(layoutName, func(t *testing.T) {
+			doc := sampleDocument(t)
+			doc.Template.CanvasLayout = layoutName
+			out, err := Render(doc, PDF)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPDF(t, out)
+		})
+	}
+}
+
+// checkPDF verifies the header, trailer and that every xref entry points at
+// the start of the object it indexes
+func checkPDF(t *testing.T, out []byte) {
+	t.Helper()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header")
+	}
+	if !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing %%%%EOF")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if xref <= 0 || xref >= len(out) || !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the xref table", xref)
+	}
+
+	var first, count int
+	rest := string(out[xref+len("xref\n"):])
+	if _, err := fmt.Sscanf(rest, "%d %d", &first, &count); err != nil || first != 0 {
+		t.Fatalf("bad xref subsection header: %v", err)
+	}
+	entries := strings.SplitN(rest, "\n", count+2)[1 : count+1]
+	if entries[0] != "0000000000 65535 f " {
+		t.Errorf("entry 0 = %q", entries[0])
+	}
+	for id := 1; id < count; id++ {
+		e := entries[id]
+		if len(e) != 19 || !strings.HasSuffix(e, " 00000 n ") {
+			t.Fatalf("entry %d malformed: %q", id, e)
+		}
+		off, err := strconv.Atoi(e[:10])
+		if err != nil {
+			t.Fatalf("entry %d offset: %v", id, err)
+		}
+		want := strconv.Itoa(id) + " 0 obj\n"
+		if off >= xref || !bytes.HasPrefix(out[off:], []byte(want)) {
+			t.Errorf("object %d: offset %d doesn't start %q", id, off, want)
+		}
+	}
+
+	if !bytes.Contains(out, []byte("trailer\n<< /Size "+strconv.Itoa(count)+" ")) {
+		t.Errorf("trailer /Size doesn't match the %d xref entries", count)
+	}
+	if !bytes.Contains(out, []byte("/Subtype /Image")) {
+		t.Errorf("image wasn't embedded")
+	}
+	if n := bytes.Count(out, []byte("/Subtype /Image")); n != 1 {
+		t.Errorf("shared image embedded %d times, want once", n)
+	}
+}
+
+func TestRenderPPTX(t *testing.T) {
+	out, err := Render(sampleDocument(t), PPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("pptx isn't a valid zip: %v", err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		if files[f.Name] != nil {
+			t.Errorf("duplicate part %s", f.Name)
+		}
+		files[f.Name] = f
+	}
+	for _, name := range []string{
+		"[Content_Types].xml",
+		"_rels/.rels",
+		"docProps/core.xml",
+		"ppt/presentation.xml",
+		"ppt/_rels/presentation.xml.rels",
+		"ppt/slides/slide1.xml",
+		"ppt/slides/_rels/slide1.xml.rels",
+		"ppt/slideMasters/slideMaster1.xml",
+		"ppt/slideLayouts/slideLayout1.xml",
+		"ppt/theme/theme1.xml",
+		"ppt/media/image1.jpeg",
+	} {
+		if files[name] == nil {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	if files["ppt/media/image2.jpeg"] != nil {
+		t.Errorf("shared image stored twice")
+	}
+
+	for name, f := range files {
+		if !strings.HasSuffix(name, ".xml") && !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		dec := xml.NewDecoder(rc)
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s isn't well-formed XML: %v", name, err)
+				break
+			}
+		}
+		rc.Close()
+	}
+
+	// Every slide the presentation points at exists
+	rels := readPart(t, files["ppt/_rels/presentation.xml.rels"])
+	for _, m := range regexp.MustCompile(`Target="(slides/slide\d+\.xml)"`).FindAllStringSubmatch(rels, -1) {
+		if files["ppt/"+m[1]] == nil {
+			t.Errorf("presentation references missing %s", m[1])
+		}
+	}
+}
+
+func readPart(t *testing.T, f *zip.File) string {
+	t.Helper()
+	if f == nil {
+		t.Fatal("missing part")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,377 @@
+package export
+
+import (
+	"auth-app-backend/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pages are 16:9 and measured in points from the top-left corner
+const (
+	pageWidth    = 960.0
+	pageHeight   = 540.0
+	margin       = 48.0
+	contentTop   = 40.0
+	contentLimit = pageHeight - 56
+	bodySize     = 12.0
+	headingSize  = 16.0
+)
+
+var (
+	black = rgb{0x11, 0x18, 0x27}
+	gray  = rgb{0x6B, 0x72, 0x80}
+	light = rgb{0xF3, 0xF4, 0xF6}
+	white = rgb{0xFF, 0xFF, 0xFF}
+)
+
+type rgb struct{ R, G, B uint8 }
+
+func parseHex(s string) rgb {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	return rgb{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+}
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// page is a renderer-neutral list of things to draw
+type page struct {
+	elements []interface{}
+}
+
+type rect struct {
+	x, y, w, h float64
+	fill       *rgb
+	stroke     *rgb
+}
+
+// text draws lines top-down starting at y, which is the top of the first line
+type text struct {
+	x, y    float64
+	lines   []string
+	size    float64
+	leading float64
+	bold    bool
+	color   rgb
+}
+
+type picture struct {
+	x, y, w, h float64
+	img        *Image
+}
+
+func leading(size float64) float64 {
+	return size * 1.35
+}
+
+// flow places content top to bottom, starting new pages as they fill up
+type flow struct {
+	pages  []*page
+	cur    *page
+	y      float64
+	accent rgb
+}
+
+func (f *flow) newPage() {
+	f.cur = &page{}
+	f.pages = append(f.pages, f.cur)
+	f.cur.elements = append(f.cur.elements, rect{x: 0, y: 0, w: pageWidth, h: 8, fill: &f.accent})
+	f.y = contentTop
+}
+
+// fresh reports whether nothing has been placed on the current page yet
+func (f *flow) fresh() bool {
+	return f.cur != nil && f.y == contentTop
+}
+
+func (f *flow) ensure(h float64) {
+	if f.cur == nil || f.y+h > contentLimit {
+		f.newPage()
+	}
+}
+
+func (f *flow) lines(lines []string, size float64, bold bool, color rgb) {
+	lead := leading(size)
+	for len(lines) > 0 {
+		f.ensure(lead)
+		fit := int((contentLimit - f.y) / lead)
+		if fit > len(lines) {
+			fit = len(lines)
+		}
+		f.cur.elements = append(f.cur.elements, text{
+			x: margin, y: f.y, lines: lines[:fit], size: size, leading: lead, bold: bold, color: color,
+		})
+		f.y += float64(fit) * lead
+		lines = lines[fit:]
+	}
+}
+
+func (f *flow) heading(s string) {
+	// Keep a heading on the same page as at least one line of its body
+	f.ensure(leading(headingSize) + leading(bodySize) + 12)
+	if !f.fresh() {
+		f.y += 12
+	}
+	f.lines(wrap(s, headingSize, pageWidth-2*margin, true), headingSize, true, f.accent)
+	f.y += 4
+}
+
+func (f *flow) paragraph(s string) {
+	f.lines(wrap(s, bodySize, pageWidth-2*margin, false), bodySize, false, black)
+}
+
+func (f *flow) bullets(items []string) {
+	for _, item := range items {
+		f.paragraph("• " + item)
+	}
+}
+
+// layout turns a document into pages following its template
+func layout(doc Document) ([]*page, error) {
+	title := fill(doc.Template.Title, doc)
+	subtitle := fill(doc.Template.Subtitle, doc)
+	footer := fill(doc.Template.Footer, doc)
+
+	f := &flow{accent: parseHex(doc.Template.AccentColor)}
+	f.newPage()
+	f.y = 64
+	f.lines(wrap(title, 32, pageWidth-2*margin, true), 32, true, black)
+	if subtitle != "" {
+		f.lines(wrap(subtitle, 18, pageWidth-2*margin, false), 18, false, gray)
+	}
+	f.y += 12
+
+	p := doc.Project
+	for _, section := range doc.Template.Sections {
+		switch section {
+		case SectionOverview:
+			f.heading("Overview")
+			if strings.TrimSpace(p.Description) == "" {
+				f.paragraph("No description provided.")
+			} else {
+				f.paragraph(p.Description)
+			}
+
+		case SectionStatus:
+			f.heading("Status")
+			if p.Status == "" {
+				f.paragraph("Not set")
+			} else {
+				f.paragraph(p.Status)
+			}
+
+		case SectionTags:
+			f.heading("Tags")
+			f.paragraph("General: " + joinOrNone(p.GeneralTags))
+			f.paragraph("Programming: " + joinOrNone(p.ProgrammingTags))
+
+		case SectionDeveloper:
+			f.heading("Developer")
+			f.bullets(developerLines(p.Developer))
+
+		case SectionImages:
+			if len(doc.Images) > 0 {
+				layoutImages(f, doc.Images)
+			}
+
+		case SectionCanvas:
+			if doc.Canvas == nil {
+				f.heading("Business Model Canvas")
+				f.paragraph("No business model canvas has been filled in yet.")
+			} else if doc.Template.CanvasLayout == "grid" {
+				layoutCanvasGrid(f, doc.Canvas)
+			} else {
+				layoutCanvasList(f, doc.Canvas)
+			}
+		}
+	}
+
+	for i, pg := range f.pages {
+		pg.elements = append(pg.elements,
+			text{x: margin, y: pageHeight - 32, lines: []string{footer}, size: 9, leading: leading(9), color: gray},
+		)
+		number := fmt.Sprintf("%d / %d", i+1, len(f.pages))
+		pg.elements = append(pg.elements, text{
+			x: pageWidth - margin - textWidth(number, 9, false), y: pageHeight - 32,
+			lines: []string{number}, size: 9, leading: leading(9), color: gray,
+		})
+	}
+
+	return f.pages, nil
+}
+
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return "None"
+	}
+	return strings.Join(values, ", ")
+}
+
+func developerLines(u *models.User) []string {
+	if u == nil {
+		return []string{"Unknown"}
+	}
+
+	name := strings.TrimSpace(deref(u.FirstName) + " " + deref(u.LastName))
+	lines := []string{}
+	if name != "" {
+		lines = append(lines, name+" (@"+u.Username+")")
+	} else {
+		lines = append(lines, "@"+u.Username)
+	}
+
+	for _, field := range []struct {
+		label string
+		value *string
+	}{
+		{"Company", u.CompanyName},
+		{"GitHub", u.GithubLink},
+		{"Portfolio", u.PortfolioLink},
+		{"LinkedIn", u.LinkedinLink},
+	} {
+		if v := deref(field.value); v != "" {
+			lines = append(lines, field.label+": "+v)
+		}
+	}
+	return lines
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// layoutImages places images in a 3x2 grid per page, scaled to fit
+func layoutImages(f *flow, images []*Image) {
+	const cols, rows, gap = 3, 2, 16.0
+	cellW := (pageWidth - 2*margin - (cols-1)*gap) / cols
+	top := contentTop + leading(headingSize) + 8
+	cellH := (contentLimit - top - (rows-1)*gap) / rows
+
+	for i, img := range images {
+		if i%(cols*rows) == 0 {
+			f.newPage()
+			f.heading("Gallery")
+		}
+
+		slot := i % (cols * rows)
+		x := margin + float64(slot%cols)*(cellW+gap)
+		y := top + float64(slot/cols)*(cellH+gap)
+
+		w, h := cellW, cellW*float64(img.Height)/float64(img.Width)
+		if h > cellH {
+			w, h = cellH*float64(img.Width)/float64(img.Height), cellH
+		}
+		f.cur.elements = append(f.cur.elements,
+			rect{x: x, y: y, w: cellW, h: cellH, fill: &light},
+			picture{x: x + (cellW-w)/2, y: y + (cellH-h)/2, w: w, h: h, img: img},
+		)
+	}
+
+	// Whatever comes next starts on its own page
+	f.y = contentLimit
+}
+
+type canvasBlock struct {
+	title string
+	items []string
+}
+
+func canvasBlocks(c *models.BusinessModelCanvas) map[string]canvasBlock {
+	return map[string]canvasBlock{
+		"kp": {"Key Partners", c.KeyPartners},
+		"ka": {"Key Activities", c.KeyActivities},
+		"kr": {"Key Resources", c.KeyResources},
+		"vp": {"Value Propositions", c.ValuePropositions},
+		"cr": {"Customer Relationships", c.CustomerRelationships},
+		"ch": {"Channels", c.Channels},
+		"cs": {"Customer Segments", c.CustomerSegments},
+		"co": {"Cost Structure", c.CostStructure},
+		"rs": {"Revenue Streams", c.RevenueStreams},
+	}
+}
+
+// canvasOrder is the reading order used by the list layout
+var canvasOrder = []string{"vp", "cs", "ch", "cr", "rs", "kr", "ka", "kp", "co"}
+
+func layoutCanvasList(f *flow, c *models.BusinessModelCanvas) {
+	f.newPage()
+	f.heading("Business Model Canvas")
+	blocks := canvasBlocks(c)
+	for _, key := range canvasOrder {
+		b := blocks[key]
+		f.ensure(leading(13) + leading(bodySize) + 8)
+		f.y += 8
+		f.lines([]string{b.title}, 13, true, black)
+		if len(b.items) == 0 {
+			f.lines([]string{"Not defined yet"}, bodySize, false, gray)
+		} else {
+			f.bullets(b.items)
+		}
+	}
+	f.y = contentLimit
+}
+
+// layoutCanvasGrid draws the classic one-page canvas: five columns on top
+// (partners, activities/resources, value, relationships/channels,
+// segments) over cost structure and revenue streams
+func layoutCanvasGrid(f *flow, c *models.BusinessModelCanvas) {
+	f.newPage()
+	f.heading("Business Model Canvas")
+
+	blocks := canvasBlocks(c)
+	top := f.y
+	width := pageWidth - 2*margin
+	height := contentLimit - top
+	colW := width / 5
+	upperH := height * 0.68
+	lowerH := height - upperH
+
+	place := func(key string, x, y, w, h float64) {
+		b := blocks[key]
+		stroke := gray
+		f.cur.elements = append(f.cur.elements, rect{x: x, y: y, w: w, h: h, fill: &white, stroke: &stroke})
+
+		const pad, titleSize, itemSize = 8.0, 10.0, 9.0
+		f.cur.elements = append(f.cur.elements, text{
+			x: x + pad, y: y + pad, lines: truncate(wrap(b.title, titleSize, w-2*pad, true), 1, titleSize, w-2*pad, true),
+			size: titleSize, leading: leading(titleSize), bold: true, color: f.accent,
+		})
+
+		body := []string{}
+		for _, item := range b.items {
+			body = append(body, wrap("• "+item, itemSize, w-2*pad, false)...)
+		}
+		color := black
+		if len(body) == 0 {
+			body, color = []string{"Not defined yet"}, gray
+		}
+
+		bodyTop := y + pad + leading(titleSize) + 4
+		maxLines := int((y + h - pad - bodyTop) / leading(itemSize))
+		if maxLines < 1 {
+			return
+		}
+		f.cur.elements = append(f.cur.elements, text{
+			x: x + pad, y: bodyTop, lines: truncate(body, maxLines, itemSize, w-2*pad, false),
+			size: itemSize, leading: leading(itemSize), color: color,
+		})
+	}
+
+	x := margin
+	place("kp", x, top, colW, upperH)
+	place("ka", x+colW, top, colW, upperH/2)
+	place("kr", x+colW, top+upperH/2, colW, upperH/2)
+	place("vp", x+2*colW, top, colW, upperH)
+	place("cr", x+3*colW, top, colW, upperH/2)
+	place("ch", x+3*colW, top+upperH/2, colW, upperH/2)
+	place("cs", x+4*colW, top, colW, upperH)
+	place("co", x, top+upperH, width/2, lowerH)
+	place("rs", x+width/2, top+upperH, width/2, lowerH)
+
+	f.y = contentLimit
+}
@@ -0,0 +1,115 @@
+package export
+
+import "strings"
+
+// Advance widths of printable ASCII (32-126) in 1/1000 em, from the Adobe
+// Helvetica AFM files. Arial shares these metrics, so the same line breaks
+// hold in PowerPoint.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// textWidth measures s in points at the given size
+func textWidth(s string, size float64, bold bool) float64 {
+	widths := &helveticaWidths
+	if bold {
+		widths = &helveticaBoldWidths
+	}
+
+	total := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// wrap breaks text into lines no wider than width. Existing newlines are
+// kept and words longer than a line are split.
+func wrap(text string, size, width float64, bold bool) []string {
+	lines := []string{}
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		line := ""
+		for _, word := range words {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if textWidth(candidate, size, bold) <= width {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Split words that don't fit on a line of their own
+			for textWidth(word, size, bold) > width {
+				cut := fitPrefix(word, size, width, bold)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitPrefix returns the byte length of the longest prefix of s that fits
+func fitPrefix(s string, size, width float64, bold bool) int {
+	cut := 0
+	for i := range s {
+		if i > 0 && textWidth(s[:i], size, bold) > width {
+			break
+		}
+		cut = i
+	}
+	if cut == 0 {
+		// Always make progress, even if one character is too wide
+		for i := range s {
+			if i > 0 {
+				return i
+			}
+		}
+		return len(s)
+	}
+	return cut
+}
+
+// truncate cuts lines down to max, marking the cut with an ellipsis
+func truncate(lines []string, max int, size, width float64, bold bool) []string {
+	if len(lines) <= max {
+		return lines
+	}
+	lines = append([]string{}, lines[:max]...)
+	last := []rune(lines[max-1])
+	for len(last) > 0 && textWidth(string(last)+"...", size, bold) > width {
+		last = last[:len(last)-1]
+	}
+	lines[max-1] = string(last) + "..."
+	return lines
+}
@@ -0,0 +1,204 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// pdfWriter assembles a PDF file object by object, remembering offsets for
+// the cross-reference table
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// reserve allocates an object number to be written later
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *pdfWriter) object(id int, body string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *pdfWriter) stream(id int, dict string, data []byte) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func renderPDF(pages []*page) ([]byte, error) {
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	catalog := w.reserve()
+	pagesID := w.reserve()
+	regular := w.reserve()
+	bold := w.reserve()
+
+	w.object(regular, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	w.object(bold, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	// Each distinct image is embedded once and shared between pages
+	images := map[*Image]int{}
+	for _, pg := range pages {
+		for _, el := range pg.elements {
+			if pic, ok := el.(picture); ok {
+				if _, done := images[pic.img]; !done {
+					id := w.reserve()
+					images[pic.img] = id
+					w.stream(id, fmt.Sprintf(
+						"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode",
+						pic.img.Width, pic.img.Height,
+					), pic.img.Data)
+				}
+			}
+		}
+	}
+
+	kids := []string{}
+	for _, pg := range pages {
+		content, xobjects, err := pdfContent(pg, images)
+		if err != nil {
+			return nil, err
+		}
+
+		contentID := w.reserve()
+		w.stream(contentID, "/Filter /FlateDecode", content)
+
+		resources := fmt.Sprintf("/Font << /F1 %d 0 R /F2 %d 0 R >>", regular, bold)
+		if len(xobjects) > 0 {
+			resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+		}
+
+		pageID := w.reserve()
+		w.object(pageID, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %g %g] /Resources << %s >> /Contents %d 0 R >>",
+			pagesID, pageWidth, pageHeight, resources, contentID,
+		))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+
+	w.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, catalog, xref)
+
+	return w.buf.Bytes(), nil
+}
+
+// pdfContent builds the compressed content stream of a page and the
+// XObject resource entries it uses
+func pdfContent(pg *page, images map[*Image]int) ([]byte, []string, error) {
+	var ops bytes.Buffer
+	used := map[int]bool{}
+	xobjects := []string{}
+
+	// PDF's origin is bottom-left, the layout's is top-left
+	flip := func(y float64) float64 { return pageHeight - y }
+
+	for _, el := range pg.elements {
+		switch e := el.(type) {
+		case rect:
+			op := ""
+			if e.fill != nil {
+				fmt.Fprintf(&ops, "%s rg\n", pdfColor(*e.fill))
+				op = "f"
+			}
+			if e.stroke != nil {
+				fmt.Fprintf(&ops, "%s RG 0.75 w\n", pdfColor(*e.stroke))
+				op = "S"
+				if e.fill != nil {
+					op = "B"
+				}
+			}
+			if op != "" {
+				fmt.Fprintf(&ops, "%.2f %.2f %.2f %.2f re %s\n", e.x, flip(e.y+e.h), e.w, e.h, op)
+			}
+
+		case text:
+			font := "F1"
+			if e.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&ops, "BT /%s %.2f Tf %s rg\n", font, e.size, pdfColor(e.color))
+			// Baseline of the first line sits roughly one ascent below the top
+			fmt.Fprintf(&ops, "%.2f %.2f Td %.2f TL\n", e.x, flip(e.y+e.size*0.8), e.leading)
+			for i, line := range e.lines {
+				if i > 0 {
+					ops.WriteString("T* ")
+				}
+				fmt.Fprintf(&ops, "(%s) Tj\n", pdfString(line))
+			}
+			ops.WriteString("ET\n")
+
+		case picture:
+			id := images[e.img]
+			if !used[id] {
+				used[id] = true
+				xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", id, id))
+			}
+			fmt.Fprintf(&ops, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", e.w, e.h, e.x, flip(e.y+e.h), id)
+		}
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(ops.Bytes()); err != nil {
+		return nil, nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return compressed.Bytes(), xobjects, nil
+}
+
+func pdfColor(c rgb) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// winAnsiExtras maps the characters WinAnsiEncoding places in 0x80-0x9F
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// pdfString encodes s as the body of a literal string for the standard
+// fonts. Characters outside WinAnsiEncoding become '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		var c byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			c = byte(r)
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			c = byte(r)
+		default:
+			if v, ok := winAnsiExtras[r]; ok {
+				c = v
+			} else {
+				c = '?'
+			}
+		}
+		if c >= 0x80 {
+			fmt.Fprintf(&b, "\\%03o", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,329 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PresentationML measures in EMUs; there are 12700 to a point
+const emuPerPoint = 12700
+
+const (
+	nsA   = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	nsR   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsP   = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	relNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+func emu(pt float64) int64 {
+	return int64(pt * emuPerPoint)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// renderPPTX writes the pages as slides of a minimal but complete
+// presentation: one master, one blank layout, one theme
+func renderPPTX(pages []*page, title string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct{ name, body string }{}
+	add := func(name, body string) {
+		files = append(files, struct{ name, body string }{name, body})
+	}
+
+	// Number the images; each is stored once in ppt/media
+	media := map[*Image]int{}
+	mediaOrder := []*Image{}
+	for _, pg := range pages {
+		for _, el := range pg.elements {
+			if pic, ok := el.(picture); ok {
+				if _, done := media[pic.img]; !done {
+					mediaOrder = append(mediaOrder, pic.img)
+					media[pic.img] = len(mediaOrder)
+				}
+			}
+		}
+	}
+
+	overrides := []string{
+		`<Override PartName="/ppt/presentation.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/>`,
+		`<Override PartName="/ppt/slideMasters/slideMaster1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideMaster+xml"/>`,
+		`<Override PartName="/ppt/slideLayouts/slideLayout1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideLayout+xml"/>`,
+		`<Override PartName="/ppt/theme/theme1.xml" ContentType="application/vnd.openxmlformats-officedocument.theme+xml"/>`,
+		`<Override PartName="/ppt/presProps.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presProps+xml"/>`,
+		`<Override PartName="/ppt/viewProps.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.viewProps+xml"/>`,
+		`<Override PartName="/ppt/tableStyles.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.tableStyles+xml"/>`,
+		`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>`,
+		`<Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/>`,
+	}
+	slideIDs := []string{}
+	presRels := []string{
+		`<Relationship Id="rId1" Type="` + relNS + `/slideMaster" Target="slideMasters/slideMaster1.xml"/>`,
+		`<Relationship Id="rId2" Type="` + relNS + `/theme" Target="theme/theme1.xml"/>`,
+		`<Relationship Id="rIdProps" Type="` + relNS + `/presProps" Target="presProps.xml"/>`,
+		`<Relationship Id="rIdView" Type="` + relNS + `/viewProps" Target="viewProps.xml"/>`,
+		`<Relationship Id="rIdTables" Type="` + relNS + `/tableStyles" Target="tableStyles.xml"/>`,
+	}
+
+	for i, pg := range pages {
+		n := i + 1
+		body, rels := pptxSlide(pg, media)
+		add(fmt.Sprintf("ppt/slides/slide%d.xml", n), body)
+		add(fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", n), rels)
+
+		overrides = append(overrides, fmt.Sprintf(
+			`<Override PartName="/ppt/slides/slide%d.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slide+xml"/>`, n))
+		slideIDs = append(slideIDs, fmt.Sprintf(`<p:sldId id="%d" r:id="rId%d"/>`, 255+n, n+2))
+		presRels = append(presRels, fmt.Sprintf(
+			`<Relationship Id="rId%d" Type="%s/slide" Target="slides/slide%d.xml"/>`, n+2, relNS, n))
+	}
+
+	add("[Content_Types].xml", xmlHeader+
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`+
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`+
+		`<Default Extension="xml" ContentType="application/xml"/>`+
+		`<Default Extension="jpeg" ContentType="image/jpeg"/>`+
+		strings.Join(overrides, "")+
+		`</Types>`)
+
+	add("_rels/.rels", xmlHeader+
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		`<Relationship Id="rId1" Type="`+relNS+`/officeDocument" Target="ppt/presentation.xml"/>`+
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>`+
+		`<Relationship Id="rId3" Type="`+relNS+`/extended-properties" Target="docProps/app.xml"/>`+
+		`</Relationships>`)
+
+	add("docProps/core.xml", xmlHeader+
+		`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" `+
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" `+
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`+
+		`<dc:title>`+escape(title)+`</dc:title><dc:creator>Startony</dc:creator>`+
+		`<dcterms:created xsi:type="dcterms:W3CDTF">`+time.Now().UTC().Format(time.RFC3339)+`</dcterms:created>`+
+		`</cp:coreProperties>`)
+
+	add("docProps/app.xml", xmlHeader+
+		`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">`+
+		`<Application>Startony</Application>`+fmt.Sprintf(`<Slides>%d</Slides>`, len(pages))+
+		`</Properties>`)
+
+	add("ppt/presentation.xml", xmlHeader+
+		`<p:presentation xmlns:a="`+nsA+`" xmlns:r="`+nsR+`" xmlns:p="`+nsP+`">`+
+		`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>`+
+		`<p:sldIdLst>`+strings.Join(slideIDs, "")+`</p:sldIdLst>`+
+		fmt.Sprintf(`<p:sldSz cx="%d" cy="%d"/>`, emu(pageWidth), emu(pageHeight))+
+		`<p:notesSz cx="6858000" cy="9144000"/>`+
+		`</p:presentation>`)
+
+	add("ppt/_rels/presentation.xml.rels", xmlHeader+
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		strings.Join(presRels, "")+
+		`</Relationships>`)
+
+	add("ppt/presProps.xml", xmlHeader+
+		`<p:presentationPr xmlns:a="`+nsA+`" xmlns:r="`+nsR+`" xmlns:p="`+nsP+`"/>`)
+	add("ppt/viewProps.xml", xmlHeader+
+		`<p:viewPr xmlns:a="`+nsA+`" xmlns:r="`+nsR+`" xmlns:p="`+nsP+`"/>`)
+	add("ppt/tableStyles.xml", xmlHeader+
+		`<a:tblStyleLst xmlns:a="`+nsA+`" def="{5C22544A-7EE6-4342-B048-85BDC9FD1C3A}"/>`)
+
+	add("ppt/slideMasters/slideMaster1.xml", xmlHeader+
+		`<p:sldMaster xmlns:a="`+nsA+`" xmlns:r="`+nsR+`" xmlns:p="`+nsP+`">`+
+		`<p:cSld><p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg>`+emptyTree+`</p:cSld>`+
+		`<p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" `+
+		`accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>`+
+		`<p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/></p:sldLayoutIdLst>`+
+		`</p:sldMaster>`)
+
+	add("ppt/slideMasters/_rels/slideMaster1.xml.rels", xmlHeader+
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		`<Relationship Id="rId1" Type="`+relNS+`/slideLayout" Target="../slideLayouts/slideLayout1.xml"/>`+
+		`<Relationship Id="rId2" Type="`+relNS+`/theme" Target="../theme/theme1.xml"/>`+
+		`</Relationships>`)
+
+	add("ppt/slideLayouts/slideLayout1.xml", xmlHeader+
+		`<p:sldLayout xmlns:a="`+nsA+`" xmlns:r="`+nsR+`" xmlns:p="`+nsP+`" type="blank" preserve="1">`+
+		`<p:cSld name="Blank">`+emptyTree+`</p:cSld>`+
+		`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>`+
+		`</p:sldLayout>`)
+
+	add("ppt/slideLayouts/_rels/slideLayout1.xml.rels", xmlHeader+
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		`<Relationship Id="rId1" Type="`+relNS+`/slideMaster" Target="../slideMasters/slideMaster1.xml"/>`+
+		`</Relationships>`)
+
+	add("ppt/theme/theme1.xml", themeXML)
+
+	// Readers expect the content types part first in the archive
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].name == "[Content_Types].xml" && files[j].name != "[Content_Types].xml"
+	})
+
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write([]byte(f.body)); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, img := range mediaOrder {
+		fw, err := zw.Create(fmt.Sprintf("ppt/media/image%d.jpeg", i+1))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(img.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const emptyTree = `<p:spTree><p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+	`<p:grpSpPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/><a:chOff x="0" y="0"/><a:chExt cx="0" cy="0"/></a:xfrm></p:grpSpPr>` +
+	`</p:spTree>`
+
+// pptxSlide returns the XML of one slide and of its relationships
+func pptxSlide(pg *page, media map[*Image]int) (string, string) {
+	var shapes strings.Builder
+	rels := []string{
+		`<Relationship Id="rId1" Type="` + relNS + `/slideLayout" Target="../slideLayouts/slideLayout1.xml"/>`,
+	}
+	imageRels := map[int]string{}
+	id := 2
+
+	xfrm := func(x, y, w, h float64) string {
+		return fmt.Sprintf(`<a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm>`, emu(x), emu(y), emu(w), emu(h))
+	}
+
+	for _, el := range pg.elements {
+		switch e := el.(type) {
+		case rect:
+			fill := `<a:noFill/>`
+			if e.fill != nil {
+				fill = `<a:solidFill><a:srgbClr val="` + e.fill.hex() + `"/></a:solidFill>`
+			}
+			line := `<a:ln><a:noFill/></a:ln>`
+			if e.stroke != nil {
+				line = `<a:ln w="9525"><a:solidFill><a:srgbClr val="` + e.stroke.hex() + `"/></a:solidFill></a:ln>`
+			}
+			fmt.Fprintf(&shapes,
+				`<p:sp><p:nvSpPr><p:cNvPr id="%d" name="Shape %d"/><p:cNvSpPr/><p:nvPr/></p:nvSpPr>`+
+					`<p:spPr>%s<a:prstGeom prst="rect"><a:avLst/></a:prstGeom>%s%s</p:spPr></p:sp>`,
+				id, id, xfrm(e.x, e.y, e.w, e.h), fill, line)
+
+		case text:
+			width := 0.0
+			for _, l := range e.lines {
+				width = max(width, textWidth(l, e.size, e.bold))
+			}
+			bold := ""
+			if e.bold {
+				bold = ` b="1"`
+			}
+
+			var paras strings.Builder
+			for _, l := range e.lines {
+				fmt.Fprintf(&paras,
+					`<a:p><a:pPr><a:lnSpc><a:spcPts val="%d"/></a:lnSpc></a:pPr>`+
+						`<a:r><a:rPr lang="en-US" sz="%d"%s dirty="0"><a:solidFill><a:srgbClr val="%s"/></a:solidFill>`+
+						`<a:latin typeface="Arial"/></a:rPr><a:t>%s</a:t></a:r></a:p>`,
+					int(e.leading*100), int(e.size*100), bold, e.color.hex(), escape(l))
+			}
+
+			// Lines are already broken to fit, so PowerPoint must not rewrap
+			fmt.Fprintf(&shapes,
+				`<p:sp><p:nvSpPr><p:cNvPr id="%d" name="Text %d"/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>`+
+					`<p:spPr>%s<a:prstGeom prst="rect"><a:avLst/></a:prstGeom><a:noFill/></p:spPr>`+
+					`<p:txBody><a:bodyPr wrap="none" lIns="0" tIns="0" rIns="0" bIns="0" rtlCol="0"><a:noAutofit/></a:bodyPr>`+
+					`<a:lstStyle/>%s</p:txBody></p:sp>`,
+				id, id, xfrm(e.x, e.y, width+2, float64(len(e.lines))*e.leading), paras.String())
+
+		case picture:
+			n := media[e.img]
+			relID, ok := imageRels[n]
+			if !ok {
+				relID = fmt.Sprintf("rId%d", len(rels)+1)
+				imageRels[n] = relID
+				rels = append(rels, fmt.Sprintf(
+					`<Relationship Id="%s" Type="%s/image" Target="../media/image%d.jpeg"/>`, relID, relNS, n))
+			}
+			fmt.Fprintf(&shapes,
+				`<p:pic><p:nvPicPr><p:cNvPr id="%d" name="Picture %d"/><p:cNvPicPr><a:picLocks noChangeAspect="1"/></p:cNvPicPr><p:nvPr/></p:nvPicPr>`+
+					`<p:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></p:blipFill>`+
+					`<p:spPr>%s<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr></p:pic>`,
+				id, id, relID, xfrm(e.x, e.y, e.w, e.h))
+		}
+		id++
+	}
+
+	slide := xmlHeader +
+		`<p:sld xmlns:a="` + nsA + `" xmlns:r="` + nsR + `" xmlns:p="` + nsP + `">` +
+		`<p:cSld>` + strings.Replace(emptyTree, `</p:spTree>`, shapes.String()+`</p:spTree>`, 1) + `</p:cSld>` +
+		`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sld>`
+
+	relsXML := xmlHeader +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		strings.Join(rels, "") + `</Relationships>`
+
+	return slide, relsXML
+}
+
+// themeXML is the smallest theme PowerPoint accepts: the Office colour
+// scheme, Arial for everything and plain formatting styles
+const themeXML = xmlHeader +
+	`<a:theme xmlns:a="` + nsA + `" name="Startony">` +
+	`<a:themeElements>` +
+	`<a:clrScheme name="Startony">` +
+	`<a:dk1><a:srgbClr val="000000"/></a:dk1><a:lt1><a:srgbClr val="FFFFFF"/></a:lt1>` +
+	`<a:dk2><a:srgbClr val="1F2937"/></a:dk2><a:lt2><a:srgbClr val="F3F4F6"/></a:lt2>` +
+	`<a:accent1><a:srgbClr val="4F46E5"/></a:accent1><a:accent2><a:srgbClr val="0EA5E9"/></a:accent2>` +
+	`<a:accent3><a:srgbClr val="10B981"/></a:accent3><a:accent4><a:srgbClr val="F59E0B"/></a:accent4>` +
+	`<a:accent5><a:srgbClr val="EF4444"/></a:accent5><a:accent6><a:srgbClr val="8B5CF6"/></a:accent6>` +
+	`<a:hlink><a:srgbClr val="2563EB"/></a:hlink><a:folHlink><a:srgbClr val="7C3AED"/></a:folHlink>` +
+	`</a:clrScheme>` +
+	`<a:fontScheme name="Startony">` +
+	`<a:majorFont><a:latin typeface="Arial"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Arial"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont>` +
+	`</a:fontScheme>` +
+	`<a:fmtScheme name="Startony">` +
+	`<a:fillStyleLst>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`</a:fillStyleLst>` +
+	`<a:lnStyleLst>` +
+	`<a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>` +
+	`<a:ln w="12700"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>` +
+	`<a:ln w="19050"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>` +
+	`</a:lnStyleLst>` +
+	`<a:effectStyleLst>` +
+	`<a:effectStyle><a:effectLst/></a:effectStyle>` +
+	`<a:effectStyle><a:effectLst/></a:effectStyle>` +
+	`<a:effectStyle><a:effectLst/></a:effectStyle>` +
+	`</a:effectStyleLst>` +
+	`<a:bgFillStyleLst>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`</a:bgFillStyleLst>` +
+	`</a:fmtScheme>` +
+	`</a:themeElements>` +
+	`</a:theme>`
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"auth-app-backend/utils"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// viewDedupeWindow is how long repeat views by the same viewer count
+	// once
+	viewDedupeWindow = 30 * time.Minute
+
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 366
+	analyticsDateLayout  = "2006-01-02"
+)
+
+// viewerKey identifies a viewer for de-duplication: signed-in users by
+// ID, anonymous ones by a hash of their address and user agent so no raw
+// IPs are stored
+func viewerKey(r *http.Request, userID int) string {
+	if userID != 0 {
+		return "u:" + strconv.Itoa(userID)
+	}
+	sum := sha256.Sum256([]byte(utils.ClientIP(r, utils.TrustProxy()) + "|" + r.UserAgent()))
+	return "a:" + hex.EncodeToString(sum[:16])
+}
+
+// recordProjectView counts a view of a project unless the same viewer saw
+// it within viewDedupeWindow. Members looking at their own project aren't
+// counted.
+func recordProjectView(r *http.Request, projectID, userID int) error {
+	if userID != 0 {
+		role, err := projectRole(r.Context(), projectID, userID)
+		if err != nil {
+			return err
+		}
+		if role != "" {
+			return nil
+		}
+	}
+
+	var viewer sql.NullInt64
+	if userID != 0 {
+		viewer = sql.NullInt64{Int64: int64(userID), Valid: true}
+	}
+	_, err := database.DB.ExecContext(r.Context(), `
+        INSERT INTO project_views (project_id, user_id, viewer_key)
+        SELECT $1, $2, $3
+        WHERE NOT EXISTS (
+            SELECT 1 FROM project_views
+            WHERE project_id = $1 AND viewer_key = $3 AND created_at > NOW() - $4 * INTERVAL '1 second'
+        )
+    `, projectID, viewer, viewerKey(r, userID), viewDedupeWindow.Seconds())
+	return err
+}
+
+// GetProjectAnalytics returns daily stats of project {id} between ?from=
+// and ?to= (YYYY-MM-DD, inclusive; the last 30 days by default), one entry
+// per day including quiet ones. Owner only. Stats are aggregated in the
+// background, so today's numbers can lag by a few minutes.
+func GetProjectAnalytics(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleOwner)
+	if !ok {
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, from := today, today.AddDate(0, 0, 1-defaultAnalyticsDays)
+	var err error
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(analyticsDateLayout, v); err != nil {
+			http.Error(w, "Invalid to date", http.StatusBadRequest)
+			return
+		}
+		from = to.AddDate(0, 0, 1-defaultAnalyticsDays)
+	}
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(analyticsDateLayout, v); err != nil {
+			http.Error(w, "Invalid from date", http.StatusBadRequest)
+			return
+		}
+	}
+	if from.After(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) >= maxAnalyticsDays*24*time.Hour {
+		http.Error(w, "Range is limited to 366 days", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := database.DB.QueryContext(r.Context(), `
+        SELECT day, views, unique_viewers, likes, saves, stars, follows_gained
+        FROM project_daily_stats
+        WHERE project_id = $1 AND day BETWEEN $2::DATE AND $3::DATE
+    `, projectID, from.Format(analyticsDateLayout), to.Format(analyticsDateLayout))
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	byDay := map[string]models.DailyStats{}
+	for rows.Next() {
+		var s models.DailyStats
+		var day time.Time
+		if err := rows.Scan(&day, &s.Views, &s.UniqueViewers, &s.Likes, &s.Saves, &s.Stars, &s.FollowsGained); err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		s.Date = day.Format(analyticsDateLayout)
+		byDay[s.Date] = s
+	}
+	rows.Close()
+
+	response := models.ProjectAnalytics{
+		From:   from.Format(analyticsDateLayout),
+		To:     to.Format(analyticsDateLayout),
+		Series: []models.DailyStats{},
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		s, ok := byDay[d.Format(analyticsDateLayout)]
+		if !ok {
+			s.Date = d.Format(analyticsDateLayout)
+		}
+		response.Series = append(response.Series, s)
+		response.Totals.Views += s.Views
+		response.Totals.Likes += s.Likes
+		response.Totals.Saves += s.Saves
+		response.Totals.Stars += s.Stars
+		response.Totals.FollowsGained += s.FollowsGained
+	}
+
+	// Someone visiting on several days is still one viewer over the range
+	err = database.DB.QueryRowContext(r.Context(), `
+        SELECT COUNT(DISTINCT viewer_key) FROM project_views
+        WHERE project_id = $1 AND created_at >= $2::DATE AND created_at < $3::DATE + 1
+    `, projectID, response.From, response.To).Scan(&response.Totals.UniqueViewers)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,527 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Application states
+const (
+	applicationPending     = "pending"
+	applicationShortlisted = "shortlisted"
+	applicationAccepted    = "accepted"
+	applicationRejected    = "rejected"
+	applicationWithdrawn   = "withdrawn"
+)
+
+// applicationTransitions lists where the project side can move an
+// application. Applicants can only withdraw while it is still open.
+var applicationTransitions = map[string][]string{
+	applicationPending:     {applicationShortlisted, applicationAccepted, applicationRejected},
+	applicationShortlisted: {applicationPending, applicationAccepted, applicationRejected},
+}
+
+const (
+	maxApplicationMessageLength = 2000
+	maxPortfolioProjects        = 5
+)
+
+// applicationColumns is shared by every query that builds a models.Application
+const applicationColumns = `
+	a.id, a.message, a.status, a.decision_note, a.created_at, a.updated_at, a.decided_at,
+	p.id, p.name, p.code,
+	u.id, u.username, u.first_name, u.last_name, u.profile_picture, u.github_link, u.portfolio_link, u.linkedin_link`
+
+const applicationJoins = `
+	FROM project_applications a
+	JOIN projects p ON p.id = a.project_id
+	JOIN users u ON u.id = a.applicant_id`
+
+func scanApplication(rows interface{ Scan(...interface{}) error }) (models.Application, error) {
+	var a models.Application
+	var project models.Project
+	var applicant models.User
+	var decidedAt sql.NullTime
+
+	err := rows.Scan(
+		&a.ID, &a.Message, &a.Status, &a.DecisionNote, &a.CreatedAt, &a.UpdatedAt, &decidedAt,
+		&project.ID, &project.Name, &project.Code,
+		&applicant.ID, &applicant.Username, &applicant.FirstName, &applicant.LastName, &applicant.ProfilePicture,
+		&applicant.GithubLink, &applicant.PortfolioLink, &applicant.LinkedinLink,
+	)
+	if err != nil {
+		return a, err
+	}
+
+	a.Project = &project
+	a.Applicant = &applicant
+	if decidedAt.Valid {
+		a.DecidedAt = &decidedAt.Time
+	}
+	a.PortfolioProjects = []models.Project{}
+	return a, nil
+}
+
+// queryApplications runs a paginated application query, newest first,
+// and attaches each application's portfolio projects
+func queryApplications(ctx context.Context, where string, args []interface{}, cursor *Cursor, limit int) (models.ApplicationListResponse, error) {
+	n := len(args)
+	query := `SELECT ` + applicationColumns + applicationJoins + ` WHERE ` + where
+	args = append(args, limit+1)
+	if cursor != nil {
+		query += fmt.Sprintf(` AND (a.created_at, a.id) < ($%d, $%d)`, n+2, n+3)
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY a.created_at DESC, a.id DESC LIMIT $%d`, n+1)
+
+	var response models.ApplicationListResponse
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	applications := []models.Application{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return response, err
+		}
+		applications = append(applications, a)
+	}
+	rows.Close()
+
+	response.Applications = applications
+	if len(applications) > limit {
+		last := applications[limit-1]
+		response.Applications = applications[:limit]
+		response.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return response, attachPortfolios(ctx, response.Applications)
+}
+
+// attachPortfolios loads the portfolio projects of the given applications
+// in one query
+func attachPortfolios(ctx context.Context, applications []models.Application) error {
+	if len(applications) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(applications))
+	index := map[int]int{}
+	for i, a := range applications {
+		ids[i] = int64(a.ID)
+		index[a.ID] = i
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+        SELECT ap.application_id, p.id, p.name, p.code, p.description, p.images, p.status
+        FROM application_portfolio ap
+        JOIN projects p ON p.id = ap.project_id
+        WHERE ap.application_id = ANY($1)
+        ORDER BY ap.position
+    `, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var applicationID int
+		var p models.Project
+		if err := rows.Scan(&applicationID, &p.ID, &p.Name, &p.Code, &p.Description, pq.Array(&p.Images), &p.Status); err != nil {
+			return err
+		}
+		if p.Images == nil {
+			p.Images = []string{}
+		}
+		a := &applications[index[applicationID]]
+		a.PortfolioProjects = append(a.PortfolioProjects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Every application's images in one lookup
+	var portfolio []*models.Project
+	for i := range applications {
+		portfolio = append(portfolio, projectPointers(applications[i].PortfolioProjects)...)
+	}
+	fillProjectImages(ctx, portfolio...)
+	return nil
+}
+
+// ApplyToProject submits the caller's application to join project {id}.
+// Only developers can apply, once at a time per project.
+func ApplyToProject(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	projectID, ok := projectExists(w, r)
+	if !ok {
+		return
+	}
+
+	var userType sql.NullString
+	if err := database.DB.QueryRowContext(r.Context(), "SELECT user_type FROM users WHERE id = $1", userID).Scan(&userType); err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if userType.String != "developer" {
+		http.Error(w, "Only developers can apply to projects", http.StatusForbidden)
+		return
+	}
+
+	role, err := projectRole(r.Context(), projectID, userID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if role != "" {
+		http.Error(w, "You are already a member of this project", http.StatusConflict)
+		return
+	}
+
+	var req models.ApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+	if len([]rune(req.Message)) > maxApplicationMessageLength {
+		http.Error(w, "Message is too long", http.StatusBadRequest)
+		return
+	}
+
+	portfolio := []int64{}
+	seen := map[int]bool{}
+	for _, id := range req.PortfolioProjectIDs {
+		if !seen[id] {
+			seen[id] = true
+			portfolio = append(portfolio, int64(id))
+		}
+	}
+	if len(portfolio) > maxPortfolioProjects {
+		http.Error(w, fmt.Sprintf("At most %d portfolio projects", maxPortfolioProjects), http.StatusBadRequest)
+		return
+	}
+
+	// Portfolio projects must be ones the applicant worked on
+	if len(portfolio) > 0 {
+		var count int
+		err := database.DB.QueryRowContext(r.Context(), `
+            SELECT COUNT(*) FROM project_members
+            WHERE user_id = $1 AND project_id = ANY($2) AND role <> 'observer'
+        `, userID, pq.Array(portfolio)).Scan(&count)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		if count != len(portfolio) {
+			http.Error(w, "Portfolio projects must be projects you work on", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// The partial unique index allows one open application per project
+	var applicationID int
+	var projectName string
+	err = tx.QueryRowContext(r.Context(), `
+        INSERT INTO project_applications (project_id, applicant_id, message)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (project_id, applicant_id) WHERE status IN ('pending', 'shortlisted') DO NOTHING
+        RETURNING id, (SELECT name FROM projects WHERE id = $1)
+    `, projectID, userID, req.Message).Scan(&applicationID, &projectName)
+	if err == sql.ErrNoRows {
+		http.Error(w, "You already have an open application for this project", http.StatusConflict)
+		return
+	} else if err != nil {
+		serverError(w, r, "Failed to submit application", err)
+		return
+	}
+
+	for i, id := range portfolio {
+		_, err := tx.ExecContext(r.Context(),
+			"INSERT INTO application_portfolio (application_id, project_id, position) VALUES ($1, $2, $3)",
+			applicationID, id, i,
+		)
+		if err != nil {
+			serverError(w, r, "Failed to submit application", err)
+			return
+		}
+	}
+
+	managers, err := projectManagers(r.Context(), projectID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	err = notify(r.Context(), tx, managers, models.Notification{
+		Type:          notifyApplicationReceived,
+		Actor:         &models.User{ID: userID},
+		Project:       &models.Project{ID: projectID},
+		ApplicationID: &applicationID,
+		Message:       "New application to " + projectName,
+	})
+	if err != nil {
+		serverError(w, r, "Failed to submit application", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to submit application", err)
+		return
+	}
+	writeApplication(w, r, applicationID, http.StatusCreated)
+}
+
+// GetProjectApplications lists the applications to project {id}, filtered
+// by ?status= if given. Maintainers and the owner only.
+func GetProjectApplications(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+	listApplications(w, r, "a.project_id = $1", projectID)
+}
+
+// GetMyApplications lists the caller's applications, filtered by ?status=
+// if given
+func GetMyApplications(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	listApplications(w, r, "a.applicant_id = $1", userID)
+}
+
+func listApplications(w http.ResponseWriter, r *http.Request, where string, id int) {
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	args := []interface{}{id}
+	if status := r.URL.Query().Get("status"); status != "" {
+		switch status {
+		case applicationPending, applicationShortlisted, applicationAccepted, applicationRejected, applicationWithdrawn:
+		default:
+			http.Error(w, "Invalid status", http.StatusBadRequest)
+			return
+		}
+		where += " AND a.status = $2"
+		args = append(args, status)
+	}
+
+	response, err := queryApplications(r.Context(), where, args, cursor, limit)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// applicationAccess loads application {id} and works out whether the
+// caller applied or manages the project, writing the error response itself
+// when they are neither
+func applicationAccess(w http.ResponseWriter, r *http.Request) (applicationID, projectID int, applicant, manager, ok bool) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	applicationID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid application ID", http.StatusBadRequest)
+		return
+	}
+
+	var applicantID int
+	err = database.DB.QueryRowContext(r.Context(),
+		"SELECT project_id, applicant_id FROM project_applications WHERE id = $1", applicationID,
+	).Scan(&projectID, &applicantID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	role, err := projectRole(r.Context(), projectID, userID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	applicant = applicantID == userID
+	manager = hasRole(role, roleMaintainer)
+	if !applicant && !manager {
+		// Don't reveal applications to outsiders
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	return applicationID, projectID, applicant, manager, true
+}
+
+// GetApplication returns application {id} to its applicant or the
+// project's maintainers
+func GetApplication(w http.ResponseWriter, r *http.Request) {
+	applicationID, _, _, _, ok := applicationAccess(w, r)
+	if !ok {
+		return
+	}
+	writeApplication(w, r, applicationID, http.StatusOK)
+}
+
+// UpdateApplicationStatus moves application {id}. Maintainers shortlist,
+// accept or reject it; the applicant can withdraw it. Accepted applicants
+// join the team as developers. The other side is notified either way.
+func UpdateApplicationStatus(w http.ResponseWriter, r *http.Request) {
+	applicationID, projectID, applicant, manager, ok := applicationAccess(w, r)
+	if !ok {
+		return
+	}
+	userID := getUserIDFromToken(r)
+
+	var req models.ApplicationDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if len([]rune(req.Note)) > maxApplicationMessageLength {
+		http.Error(w, "Note is too long", http.StatusBadRequest)
+		return
+	}
+	if req.Status == applicationWithdrawn && !applicant || req.Status != applicationWithdrawn && !manager {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var current, projectName string
+	var applicantID int
+	err = tx.QueryRowContext(r.Context(), `
+        SELECT a.status, a.applicant_id, p.name
+        FROM project_applications a
+        JOIN projects p ON p.id = a.project_id
+        WHERE a.id = $1
+        FOR UPDATE OF a
+    `, applicationID).Scan(&current, &applicantID, &projectName)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	open := current == applicationPending || current == applicationShortlisted
+	allowed := req.Status == applicationWithdrawn && open
+	for _, s := range applicationTransitions[current] {
+		allowed = allowed || s == req.Status
+	}
+	if !allowed {
+		http.Error(w, "Cannot move an application from "+current+" to "+req.Status, http.StatusConflict)
+		return
+	}
+
+	_, err = tx.ExecContext(r.Context(), `
+        UPDATE project_applications
+        SET status = $2, decision_note = $3, updated_at = CURRENT_TIMESTAMP,
+            decided_at = CASE WHEN $2 IN ('accepted', 'rejected', 'withdrawn') THEN CURRENT_TIMESTAMP END
+        WHERE id = $1
+    `, applicationID, req.Status, req.Note)
+	if err != nil {
+		serverError(w, r, "Failed to update application", err)
+		return
+	}
+
+	if req.Status == applicationAccepted {
+		_, err = tx.ExecContext(r.Context(), `
+            INSERT INTO project_members (project_id, user_id, role)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (project_id, user_id) DO NOTHING
+        `, projectID, applicantID, roleDeveloper)
+		if err != nil {
+			serverError(w, r, "Failed to update application", err)
+			return
+		}
+	}
+
+	n := models.Notification{
+		Type:          notifyApplicationUpdated,
+		Actor:         &models.User{ID: userID},
+		Project:       &models.Project{ID: projectID},
+		ApplicationID: &applicationID,
+		Message:       "Your application to " + projectName + " was " + req.Status,
+	}
+	recipients := []int{applicantID}
+	if req.Status == applicationWithdrawn {
+		n.Type = notifyApplicationWithdrawn
+		n.Message = "An application to " + projectName + " was withdrawn"
+		recipients, err = projectManagers(r.Context(), projectID)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+	}
+	if err := notify(r.Context(), tx, recipients, n); err != nil {
+		serverError(w, r, "Failed to update application", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to update application", err)
+		return
+	}
+	writeApplication(w, r, applicationID, http.StatusOK)
+}
+
+func writeApplication(w http.ResponseWriter, r *http.Request, applicationID, status int) {
+	response, err := queryApplications(r.Context(), "a.id = $1", []interface{}{applicationID}, nil, 1)
+	if err == nil && len(response.Applications) == 0 {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response.Applications[0])
+}
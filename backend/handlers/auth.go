@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"auth-app-backend/database"
+	"auth-app-backend/metrics"
 	"auth-app-backend/models"
 	"auth-app-backend/utils"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"golang.org/x/crypto/bcrypt"
@@ -26,7 +28,7 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
@@ -39,7 +41,7 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		RETURNING id, username, email, first_name, last_name, phone, user_type, 
 			github_link, portfolio_link, linkedin_link, company_name, created_at, updated_at`
 
-	err = database.DB.QueryRow(query,
+	err = database.DB.QueryRowContext(r.Context(), query,
 		req.Username, req.Email, string(hashedPassword),
 		nullStringFromString(req.FirstName),
 		nullStringFromString(req.LastName),
@@ -59,13 +61,14 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error creating user (email or username might be taken)", http.StatusConflict)
 		return
 	}
+	metrics.Signups.Inc()
 
 	w.Header().Set("Content-Type", "application/json")
 
 	// Generate JWT token
 	token, err := utils.GenerateToken(user.ID, user.Username, user.Email)
 	if err != nil {
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		serverError(w, r, "Error generating token", err)
 		return
 	}
 
@@ -104,32 +107,37 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		github_link, portfolio_link, linkedin_link, company_name, profile_picture, banner, bio, 
 		created_at, updated_at FROM users WHERE email = $1`
 
-	err := database.DB.QueryRow(query, req.Email).Scan(
+	err := database.DB.QueryRowContext(r.Context(), query, req.Email).Scan(
 		&user.ID, &user.Username, &user.Email, &hash, &user.FirstName, &user.LastName, &user.Phone,
 		&user.UserType, &user.GithubLink, &user.PortfolioLink, &user.LinkedinLink, &user.CompanyName,
 		&user.ProfilePicture, &user.Banner, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
+		metrics.LoginFailures.WithLabelValues("unknown_email").Inc()
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	} else if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password))
 	if err != nil {
+		metrics.LoginFailures.WithLabelValues("wrong_password").Inc()
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	metrics.Logins.Inc()
+
+	fillUserImages(r.Context(), &user)
 
 	// Generate JWT token and return user info
 	w.Header().Set("Content-Type", "application/json")
 
 	token, err := utils.GenerateToken(user.ID, user.Username, user.Email)
 	if err != nil {
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		serverError(w, r, "Error generating token", err)
 		return
 	}
 
@@ -140,6 +148,48 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// RefreshToken trades a still valid token for a fresh one, so clients can
+// stay signed in without keeping the password around, for up to
+// utils.MaxSessionAge after logging in
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.ClaimsFromRequest(r)
+	if err != nil || claims.UserID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := claims.UserID
+
+	var user models.User
+	err = database.DB.QueryRowContext(r.Context(), `SELECT id, username, email, first_name, last_name, phone, user_type,
+		github_link, portfolio_link, linkedin_link, company_name, profile_picture, banner, bio,
+		created_at, updated_at FROM users WHERE id = $1`, userID).Scan(
+		&user.ID, &user.Username, &user.Email, &user.FirstName, &user.LastName, &user.Phone,
+		&user.UserType, &user.GithubLink, &user.PortfolioLink, &user.LinkedinLink, &user.CompanyName,
+		&user.ProfilePicture, &user.Banner, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	fillUserImages(r.Context(), &user)
+
+	// The new token belongs to the same session, which can't be stretched
+	// forever by refreshing
+	token, err := utils.RefreshToken(claims, user.Username, user.Email)
+	if errors.Is(err, utils.ErrSessionExpired) {
+		http.Error(w, "Session expired, log in again", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		serverError(w, r, "Error generating token", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.AuthResponse{Token: token, User: user})
+}
 
 func Logout(w http.ResponseWriter, r *http.Request) {
     // In a stateless JWT setup, logout is handled on the client side
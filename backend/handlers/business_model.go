@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/export"
+	"auth-app-backend/models"
+	"auth-app-backend/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// ExportBusinessModel renders project {code} as a PDF or PPTX download
+// using the project's export template
+func ExportBusinessModel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	format := export.Format(vars["format"])
+	if format != export.PDF && format != export.PPTX {
+		http.Error(w, "Unsupported format", http.StatusBadRequest)
+		return
+	}
+
+	var project models.Project
+	var dev models.User
+	var genTags, progTags, images []string
+	var coverImage sql.NullString
+	err := database.DB.QueryRowContext(r.Context(), `
+        SELECT p.id, p.user_id, p.name, p.description, p.code, p.general_tags, p.programming_tags, p.status, p.likes, p.created_at, p.images, p.cover_image,
+               u.username, u.email, u.first_name, u.last_name, u.company_name, u.github_link, u.portfolio_link, u.linkedin_link
+        FROM projects p
+        JOIN users u ON u.id = p.user_id
+        WHERE p.code = $1
+    `, vars["code"]).Scan(
+		&project.ID, &project.UserID, &project.Name, &project.Description, &project.Code,
+		pq.Array(&genTags), pq.Array(&progTags), &project.Status, &project.Likes, &project.CreatedAt, pq.Array(&images), &coverImage,
+		&dev.Username, &dev.Email, &dev.FirstName, &dev.LastName, &dev.CompanyName, &dev.GithubLink, &dev.PortfolioLink, &dev.LinkedinLink,
+	)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	project.GeneralTags = genTags
+	project.ProgrammingTags = progTags
+	project.Images = images
+	project.CoverImage = coverImage.String
+	project.Developer = &dev
+	fillProjectImages(r.Context(), &project)
+
+	tmpl, err := loadExportTemplate(r.Context(), project.ID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	canvas, err := loadCurrentCanvas(r.Context(), database.DB, project.ID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	doc := export.Document{
+		Project:  project,
+		Images:   loadExportImages(r.Context(), project.ImageVariants),
+		Template: tmpl,
+	}
+	if canvas != nil {
+		doc.Canvas = &canvas.BusinessModelCanvas
+	}
+
+	out, err := export.Render(doc, format)
+	if err != nil {
+		serverError(w, r, "Failed to render export", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+export.FileName(project, format)+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	w.Write(out)
+}
+
+// loadExportImages fetches a project's images from storage, preferring the
+// medium rendition. Images that aren't in storage (legacy data URIs,
+// external links) or fail to decode are left out rather than failing the
+// whole export.
+func loadExportImages(ctx context.Context, variants []models.ImageVariants) []*export.Image {
+	images := []*export.Image{}
+	for _, v := range variants {
+		url := v.Medium
+		if url == "" {
+			url = v.Original
+		}
+		key := strings.TrimPrefix(url, storage.URLPrefix)
+		if key == url || !storage.ValidKey(key) {
+			continue
+		}
+
+		body, _, err := storage.Default.Get(ctx, key)
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			continue
+		}
+
+		img, err := export.NewImage(data)
+		if err != nil {
+			continue
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// loadExportTemplate returns the saved template of a project, or the
+// default one if it hasn't customised its export
+func loadExportTemplate(ctx context.Context, projectID int) (export.Template, error) {
+	var raw []byte
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT template FROM business_model_templates WHERE project_id = $1", projectID,
+	).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return export.DefaultTemplate(), nil
+	} else if err != nil {
+		return export.Template{}, err
+	}
+
+	tmpl := export.DefaultTemplate()
+	if err := json.Unmarshal(raw, &tmpl); err != nil {
+		return export.Template{}, err
+	}
+	return tmpl, nil
+}
+
+// HandleExportTemplate reads (GET) or replaces (PUT, maintainers and up)
+// the export template of project {code}
+func HandleExportTemplate(w http.ResponseWriter, r *http.Request) {
+	var projectID int
+	err := database.DB.QueryRowContext(r.Context(),
+		"SELECT id FROM projects WHERE code = $1", mux.Vars(r)["code"],
+	).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tmpl, err := loadExportTemplate(r.Context(), projectID)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tmpl)
+
+	case http.MethodPut:
+		userID := getUserIDFromToken(r)
+		if userID == 0 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !checkProjectRole(w, r, projectID, userID, roleMaintainer) {
+			return
+		}
+
+		// Fields left out of the body keep their default values
+		tmpl := export.DefaultTemplate()
+		if err := json.NewDecoder(r.Body).Decode(&tmpl); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := tmpl.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		raw, err := json.Marshal(tmpl)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		_, err = database.DB.ExecContext(r.Context(), `
+            INSERT INTO business_model_templates (project_id, template, updated_at)
+            VALUES ($1, $2, CURRENT_TIMESTAMP)
+            ON CONFLICT (project_id) DO UPDATE SET template = EXCLUDED.template, updated_at = EXCLUDED.updated_at
+        `, projectID, raw)
+		if err != nil {
+			serverError(w, r, "Failed to save template", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tmpl)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const (
+	maxCanvasItems      = 20
+	maxCanvasItemLength = 500
+)
+
+// canvasBlockNames are the canvas columns, in the order canvasLists returns
+// the matching fields
+var canvasBlockNames = []string{
+	"key_partners", "key_activities", "key_resources", "value_propositions",
+	"customer_relationships", "channels", "customer_segments", "cost_structure", "revenue_streams",
+}
+
+func canvasLists(c *models.BusinessModelCanvas) []*[]string {
+	return []*[]string{
+		&c.KeyPartners, &c.KeyActivities, &c.KeyResources, &c.ValuePropositions,
+		&c.CustomerRelationships, &c.Channels, &c.CustomerSegments, &c.CostStructure, &c.RevenueStreams,
+	}
+}
+
+// canvasColumns is shared by every query that builds a models.ProjectCanvas
+var canvasColumns = `
+	bc.project_id, bc.version, bc.` + strings.Join(canvasBlockNames, ", bc.") + `, bc.created_at, bc.deleted,
+	u.id, u.username, u.first_name, u.last_name, u.profile_picture`
+
+func scanCanvas(rows interface{ Scan(...interface{}) error }) (models.ProjectCanvas, error) {
+	var c models.ProjectCanvas
+	var editorID sql.NullInt64
+	var editorName sql.NullString
+	var editor models.User
+
+	dest := []interface{}{&c.ProjectID, &c.Version}
+	for _, list := range canvasLists(&c.BusinessModelCanvas) {
+		dest = append(dest, pq.Array(list))
+	}
+	dest = append(dest, &c.CreatedAt, &c.Deleted,
+		&editorID, &editorName, &editor.FirstName, &editor.LastName, &editor.ProfilePicture)
+
+	if err := rows.Scan(dest...); err != nil {
+		return c, err
+	}
+
+	for _, list := range canvasLists(&c.BusinessModelCanvas) {
+		if *list == nil {
+			*list = []string{}
+		}
+	}
+	// The editor may have deleted their account since
+	if editorID.Valid {
+		editor.ID = int(editorID.Int64)
+		editor.Username = editorName.String
+		c.EditedBy = &editor
+	}
+	c.Changes = []string{}
+	return c, nil
+}
+
+// queryCanvasVersions returns up to limit versions of a project's canvas,
+// newest first, starting below version before (0 for the latest). Changes
+// are filled from the next older version, which is fetched for that purpose
+// and reported through more. Deleted versions are included.
+func queryCanvasVersions(ctx context.Context, q queryer, projectID, before, limit int) (versions []models.ProjectCanvas, more bool, err error) {
+	if before <= 0 {
+		before = math.MaxInt32
+	}
+
+	rows, err := q.QueryContext(ctx, `
+        SELECT `+canvasColumns+`
+        FROM business_model_canvases bc
+        LEFT JOIN users u ON u.id = bc.edited_by
+        WHERE bc.project_id = $1 AND bc.version < $2
+        ORDER BY bc.version DESC
+        LIMIT $3
+    `, projectID, before, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	versions = []models.ProjectCanvas{}
+	for rows.Next() {
+		c, err := scanCanvas(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		versions = append(versions, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	for i := range versions {
+		if i+1 < len(versions) {
+			versions[i].Changes = canvasChanges(&versions[i+1].BusinessModelCanvas, &versions[i].BusinessModelCanvas)
+		} else if versions[i].Version == 1 {
+			// The first version changes every block it fills in
+			versions[i].Changes = canvasChanges(&models.BusinessModelCanvas{}, &versions[i].BusinessModelCanvas)
+		}
+	}
+
+	if len(versions) > limit {
+		return versions[:limit], true, nil
+	}
+	return versions, false, nil
+}
+
+// loadCurrentCanvas returns the latest canvas of a project, or nil if it
+// has none or it was deleted
+func loadCurrentCanvas(ctx context.Context, q queryer, projectID int) (*models.ProjectCanvas, error) {
+	latest, err := loadLatestCanvas(ctx, q, projectID)
+	if err != nil || latest == nil || latest.Deleted {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// loadLatestCanvas returns the newest version of a project's canvas, even
+// when it is the one that deleted it
+func loadLatestCanvas(ctx context.Context, q queryer, projectID int) (*models.ProjectCanvas, error) {
+	versions, _, err := queryCanvasVersions(ctx, q, projectID, 0, 1)
+	if err != nil || len(versions) == 0 {
+		return nil, err
+	}
+	return &versions[0], nil
+}
+
+// insertCanvasVersion adds version of a project's canvas
+func insertCanvasVersion(ctx context.Context, tx execer, projectID, version, editorID int, canvas models.BusinessModelCanvas, deleted bool) error {
+	args := []interface{}{projectID, version, editorID, deleted}
+	placeholders := []string{}
+	for i, list := range canvasLists(&canvas) {
+		args = append(args, pq.Array(*list))
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+5))
+	}
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO business_model_canvases (project_id, version, edited_by, deleted, `+strings.Join(canvasBlockNames, ", ")+`)
+        VALUES ($1, $2, $3, $4, `+strings.Join(placeholders, ", ")+`)
+    `, args...)
+	return err
+}
+
+// canvasChanges names the blocks that differ between two canvases
+func canvasChanges(prev, next *models.BusinessModelCanvas) []string {
+	changes := []string{}
+	a, b := canvasLists(prev), canvasLists(next)
+	for i, name := range canvasBlockNames {
+		if !equalStrings(*a[i], *b[i]) {
+			changes = append(changes, name)
+		}
+	}
+	return changes
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeCanvas trims every item, drops blank ones and enforces the size
+// limits
+func normalizeCanvas(c *models.BusinessModelCanvas) error {
+	for i, list := range canvasLists(c) {
+		items := []string{}
+		for _, item := range *list {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if len([]rune(item)) > maxCanvasItemLength {
+				return fmt.Errorf("%s items must be at most %d characters", canvasBlockNames[i], maxCanvasItemLength)
+			}
+			items = append(items, item)
+		}
+		if len(items) > maxCanvasItems {
+			return fmt.Errorf("%s can have at most %d items", canvasBlockNames[i], maxCanvasItems)
+		}
+		*list = items
+	}
+	return nil
+}
+
+// projectExists writes a 404 when project {id} doesn't exist
+func projectExists(w http.ResponseWriter, r *http.Request) (int, bool) {
+	projectID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	var exists bool
+	err = database.DB.QueryRowContext(r.Context(), "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&exists)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return 0, false
+	}
+	if !exists {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return 0, false
+	}
+	return projectID, true
+}
+
+// HandleProjectCanvas reads (GET), replaces (PUT) or removes (DELETE) the
+// business model canvas of project {id}. Only maintainers and the
+// owner can change it.
+func HandleProjectCanvas(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		projectID, ok := projectExists(w, r)
+		if !ok {
+			return
+		}
+		canvas, err := loadCurrentCanvas(r.Context(), database.DB, projectID)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		if canvas == nil {
+			http.Error(w, "Canvas not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(canvas)
+
+	case http.MethodPut:
+		projectID, ok := requireProjectRole(w, r, roleMaintainer)
+		if !ok {
+			return
+		}
+		var req models.CanvasRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := normalizeCanvas(&req.BusinessModelCanvas); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		saveCanvas(w, r, projectID, req.BusinessModelCanvas, req.BaseVersion)
+
+	case http.MethodDelete:
+		projectID, ok := requireProjectRole(w, r, roleMaintainer)
+		if !ok {
+			return
+		}
+		deleteCanvas(w, r, projectID)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// lockCanvas starts a transaction holding the project's row lock so
+// concurrent edits get consecutive versions, and returns the newest
+// version of its canvas
+func lockCanvas(w http.ResponseWriter, r *http.Request, projectID int) (*sql.Tx, *models.ProjectCanvas, bool) {
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return nil, nil, false
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "SELECT id FROM projects WHERE id = $1 FOR UPDATE", projectID); err != nil {
+		tx.Rollback()
+		serverError(w, r, "Internal server error", err)
+		return nil, nil, false
+	}
+
+	latest, err := loadLatestCanvas(r.Context(), tx, projectID)
+	if err != nil {
+		tx.Rollback()
+		serverError(w, r, "Internal server error", err)
+		return nil, nil, false
+	}
+	return tx, latest, true
+}
+
+// deleteCanvas clears the project's canvas by adding an empty, deleted
+// version, so the history stays and older versions can be restored
+func deleteCanvas(w http.ResponseWriter, r *http.Request, projectID int) {
+	tx, latest, ok := lockCanvas(w, r, projectID)
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	if latest == nil || latest.Deleted {
+		http.Error(w, "Canvas not found", http.StatusNotFound)
+		return
+	}
+
+	if err := insertCanvasVersion(r.Context(), tx, projectID, latest.Version+1, getUserIDFromToken(r), models.BusinessModelCanvas{}, true); err != nil {
+		serverError(w, r, "Failed to delete canvas", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to delete canvas", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// saveCanvas stores canvas as the next version of the project's canvas.
+// Saving content identical to the current version doesn't create a new one.
+func saveCanvas(w http.ResponseWriter, r *http.Request, projectID int, canvas models.BusinessModelCanvas, baseVersion *int) {
+	tx, latest, ok := lockCanvas(w, r, projectID)
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	version := 0
+	var current *models.ProjectCanvas
+	if latest != nil {
+		version = latest.Version
+		if !latest.Deleted {
+			current = latest
+		}
+	}
+	// A deleted canvas can also be saved over as if it never existed
+	if baseVersion != nil && *baseVersion != version && !(current == nil && *baseVersion == 0) {
+		http.Error(w, "Canvas was changed by someone else, reload and try again", http.StatusConflict)
+		return
+	}
+
+	if current != nil && len(canvasChanges(&current.BusinessModelCanvas, &canvas)) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(current)
+		return
+	}
+
+	if err := insertCanvasVersion(r.Context(), tx, projectID, version+1, getUserIDFromToken(r), canvas, false); err != nil {
+		serverError(w, r, "Failed to save canvas", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to save canvas", err)
+		return
+	}
+
+	saved, err := loadCurrentCanvas(r.Context(), database.DB, projectID)
+	if err == nil && saved == nil {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetCanvasHistory lists the versions of project {id}'s canvas, newest
+// first. Pass the last version seen as ?before= to get the next page.
+func GetCanvasHistory(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectExists(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := parseLimit(r, defaultPageSize, maxPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before := 0
+	if v := r.URL.Query().Get("before"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid before", http.StatusBadRequest)
+			return
+		}
+		before = n
+	}
+
+	versions, more, err := queryCanvasVersions(r.Context(), database.DB, projectID, before, limit)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	resp := models.CanvasHistoryResponse{Versions: versions}
+	if more {
+		next := versions[len(versions)-1].Version
+		resp.NextBefore = &next
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetCanvasVersion returns one version of project {id}'s canvas
+func GetCanvasVersion(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectExists(w, r)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil || version < 1 {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	// Fetching from just above the version also brings the one before it
+	// for the change list
+	versions, _, err := queryCanvasVersions(r.Context(), database.DB, projectID, version+1, 1)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if len(versions) == 0 || versions[0].Version != version {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions[0])
+}
+
+// RestoreCanvasVersion saves an old version of project {id}'s canvas as
+// the newest one, keeping the history intact
+func RestoreCanvasVersion(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil || version < 1 {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	versions, _, err := queryCanvasVersions(r.Context(), database.DB, projectID, version+1, 1)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if len(versions) == 0 || versions[0].Version != version {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	if versions[0].Deleted {
+		http.Error(w, "Version deleted the canvas, restore an earlier one", http.StatusBadRequest)
+		return
+	}
+	saveCanvas(w, r, projectID, versions[0].BusinessModelCanvas, nil)
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const maxCommentLength = 5000
+
+// mentionPattern matches @username; usernames are limited to word characters
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w{1,50})`)
+
+// commentsCountColumn selects the number of visible comments on project p
+const commentsCountColumn = `(SELECT COUNT(*) FROM project_comments pc WHERE pc.project_id = p.id AND pc.deleted_at IS NULL)`
+
+// commentColumns is shared by every query that builds a models.Comment
+const commentColumns = `
+	c.id, c.project_id, c.parent_id, c.body, c.created_at, c.updated_at, c.deleted_at IS NOT NULL,
+	u.id, u.username, u.first_name, u.last_name, u.profile_picture,
+	COALESCE((SELECT ARRAY_AGG(mu.username ORDER BY mu.username)
+	          FROM comment_mentions m JOIN users mu ON mu.id = m.user_id
+	          WHERE m.comment_id = c.id), ARRAY[]::VARCHAR[]),
+	(SELECT COUNT(*) FROM project_comments rc WHERE rc.parent_id = c.id AND rc.deleted_at IS NULL)`
+
+func scanComment(rows interface{ Scan(...interface{}) error }) (models.Comment, error) {
+	var c models.Comment
+	var author models.User
+	var parentID sql.NullInt64
+	var mentions []string
+
+	err := rows.Scan(
+		&c.ID, &c.ProjectID, &parentID, &c.Body, &c.CreatedAt, &c.UpdatedAt, &c.Deleted,
+		&author.ID, &author.Username, &author.FirstName, &author.LastName, &author.ProfilePicture,
+		pq.Array(&mentions), &c.ReplyCount,
+	)
+	if err != nil {
+		return c, err
+	}
+
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		c.ParentID = &id
+	}
+	c.Edited = c.UpdatedAt.After(c.CreatedAt)
+
+	// Keep the slot in the thread but hide what was said and by whom
+	if c.Deleted {
+		c.Body = ""
+		mentions = nil
+	} else {
+		c.Author = &author
+	}
+
+	if mentions == nil {
+		mentions = []string{}
+	}
+	c.Mentions = mentions
+	return c, nil
+}
+
+// parseMentions returns the distinct usernames mentioned in body
+func parseMentions(body string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := m[1]
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// saveMentions replaces the mentions of a comment with the users named in
+// body. Names that don't resolve to a user are ignored.
+func saveMentions(ctx context.Context, tx *sql.Tx, commentID int, body string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM comment_mentions WHERE comment_id = $1", commentID); err != nil {
+		return err
+	}
+
+	names := parseMentions(body)
+	if len(names) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO comment_mentions (comment_id, user_id)
+		SELECT $1, id FROM users WHERE LOWER(username) = ANY($2)
+		ON CONFLICT DO NOTHING`,
+		commentID, pq.Array(lowerAll(names)),
+	)
+	return err
+}
+
+func lowerAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
+
+// GetProjectComments lists the top-level comments of a project with their
+// replies. ?sort=newest (default) or ?sort=oldest; paginated by cursor.
+func GetProjectComments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	projectID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	order, cmp := "DESC", "<"
+	switch r.URL.Query().Get("sort") {
+	case "", "newest":
+	case "oldest":
+		order, cmp = "ASC", ">"
+	default:
+		http.Error(w, "Invalid sort", http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err = database.DB.QueryRowContext(r.Context(), "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&exists)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if !exists {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	query := `SELECT ` + commentColumns + `
+		FROM project_comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.project_id = $1 AND c.parent_id IS NULL`
+	args := []interface{}{projectID, limit + 1}
+	if cursor != nil {
+		query += ` AND (c.created_at, c.id) ` + cmp + ` ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY c.created_at ` + order + `, c.id ` + order + ` LIMIT $2`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		comments = append(comments, c)
+	}
+	rows.Close()
+
+	response := models.CommentListResponse{Comments: comments}
+	if len(comments) > limit {
+		last := comments[limit-1]
+		response.Comments = comments[:limit]
+		response.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	if err := attachReplies(r.Context(), response.Comments); err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// attachReplies loads the replies of the given top-level comments in one
+// query, oldest first so conversations read top to bottom
+func attachReplies(ctx context.Context, comments []models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(comments))
+	index := map[int]int{}
+	for i, c := range comments {
+		ids[i] = int64(c.ID)
+		index[c.ID] = i
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `SELECT `+commentColumns+`
+		FROM project_comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.parent_id = ANY($1)
+		ORDER BY c.created_at ASC, c.id ASC`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reply, err := scanComment(rows)
+		if err != nil {
+			return err
+		}
+		i := index[*reply.ParentID]
+		comments[i].Replies = append(comments[i].Replies, reply)
+	}
+	return rows.Err()
+}
+
+// CreateComment adds a comment to a project. Setting parent_id replies to a
+// comment; replying to a reply attaches to the top of that thread.
+func CreateComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	projectID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		http.Error(w, "Comment body is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Body) > maxCommentLength {
+		http.Error(w, "Comment is too long", http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err = database.DB.QueryRowContext(r.Context(), "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&exists)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if !exists {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	var parentID sql.NullInt64
+	if req.ParentID != nil {
+		// Deleted comments can't be replied to, nor can replies whose
+		// thread was deleted
+		var parentProject int
+		var grandparent sql.NullInt64
+		var deleted bool
+		err := database.DB.QueryRowContext(r.Context(), `
+			SELECT c.project_id, c.parent_id, c.deleted_at IS NOT NULL OR t.deleted_at IS NOT NULL
+			FROM project_comments c
+			LEFT JOIN project_comments t ON t.id = c.parent_id
+			WHERE c.id = $1`, *req.ParentID,
+		).Scan(&parentProject, &grandparent, &deleted)
+		if err == sql.ErrNoRows || (err == nil && (parentProject != projectID || deleted)) {
+			http.Error(w, "Parent comment not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+
+		parentID = sql.NullInt64{Int64: int64(*req.ParentID), Valid: true}
+		if grandparent.Valid {
+			parentID = grandparent
+		}
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var commentID int
+	err = tx.QueryRowContext(r.Context(), `
+		INSERT INTO project_comments (project_id, user_id, parent_id, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		projectID, userID, parentID, req.Body,
+	).Scan(&commentID)
+	if err != nil {
+		serverError(w, r, "Error creating comment", err)
+		return
+	}
+
+	if err := saveMentions(r.Context(), tx, commentID, req.Body); err != nil {
+		serverError(w, r, "Error creating comment", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Error creating comment", err)
+		return
+	}
+
+	writeComment(w, r, commentID, http.StatusCreated)
+}
+
+// HandleCommentActions handles PUT (edit) and DELETE on /comments/{id}.
+// Only the author may change a comment.
+func HandleCommentActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	var authorID int
+	var deleted bool
+	err = database.DB.QueryRowContext(r.Context(),
+		"SELECT user_id, deleted_at IS NOT NULL FROM project_comments WHERE id = $1", commentID,
+	).Scan(&authorID, &deleted)
+	if err == sql.ErrNoRows || (err == nil && deleted) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	if authorID != userID {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		editComment(w, r, commentID)
+	} else {
+		deleteComment(w, r, commentID)
+	}
+}
+
+func editComment(w http.ResponseWriter, r *http.Request, commentID int) {
+	var req models.CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		http.Error(w, "Comment body is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Body) > maxCommentLength {
+		http.Error(w, "Comment is too long", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(r.Context(),
+		"UPDATE project_comments SET body = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL",
+		req.Body, commentID,
+	)
+	if err != nil {
+		serverError(w, r, "Failed to update comment", err)
+		return
+	}
+
+	if err := saveMentions(r.Context(), tx, commentID, req.Body); err != nil {
+		serverError(w, r, "Failed to update comment", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to update comment", err)
+		return
+	}
+
+	writeComment(w, r, commentID, http.StatusOK)
+}
+
+// deleteComment soft-deletes so replies stay attached to their thread
+func deleteComment(w http.ResponseWriter, r *http.Request, commentID int) {
+	_, err := database.DB.ExecContext(r.Context(),
+		"UPDATE project_comments SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", commentID,
+	)
+	if err != nil {
+		serverError(w, r, "Failed to delete comment", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Comment deleted successfully"})
+}
+
+func writeComment(w http.ResponseWriter, r *http.Request, commentID, status int) {
+	row := database.DB.QueryRowContext(r.Context(), `SELECT `+commentColumns+`
+		FROM project_comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1`, commentID)
+
+	comment, err := scanComment(row)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(comment)
+}
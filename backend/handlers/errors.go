@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serverError answers with a 500 carrying msg, logging the cause that the
+// client doesn't get to see
+func serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logErrorAt(r.Context(), msg, err, 1)
+	http.Error(w, msg, http.StatusInternalServerError)
+}
+
+// logError logs err with the request and span of ctx, for failures the
+// client isn't told about, and marks the span as failed
+func logError(ctx context.Context, msg string, err error) {
+	logErrorAt(ctx, msg, err, 1)
+}
+
+// logErrorAt logs like logError, attributing the line to the caller skip
+// frames up so helpers don't show up as the source
+func logErrorAt(ctx context.Context, msg string, err error, skip int) {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:])
+	record := slog.NewRecord(time.Now(), slog.LevelError, msg, pcs[0])
+	record.AddAttrs(slog.Any("err", err))
+
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	record.AddAttrs(slog.Any(slog.SourceKey, &slog.Source{
+		Function: frame.Function,
+		File:     frame.File,
+		Line:     frame.Line,
+	}))
+	if handler := slog.Default().Handler(); handler.Enabled(ctx, record.Level) {
+		handler.Handle(ctx, record)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, msg)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// feedSources maps an "include" value to the table holding that activity
+var feedSources = map[string]string{
+	"likes": "project_likes",
+	"stars": "project_stars",
+}
+
+// feedCursor is a position in the feed. Events are ordered by time, then
+// kind, project and actor, which together tell any two events apart even
+// when several share a timestamp or a project.
+type feedCursor struct {
+	EventAt   time.Time
+	Kind      string
+	ProjectID int
+	ActorID   int
+}
+
+func encodeFeedCursor(item models.FeedItem) string {
+	raw := strings.Join([]string{
+		strconv.FormatInt(item.CreatedAt.UnixNano(), 10),
+		item.Type,
+		strconv.Itoa(item.Project.ID),
+		strconv.Itoa(item.Actor.ID),
+	}, ":")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFeedCursor(s string) (*feedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	projectID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	actorID, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &feedCursor{
+		EventAt:   time.Unix(0, nanos).UTC(),
+		Kind:      parts[1],
+		ProjectID: projectID,
+		ActorID:   actorID,
+	}, nil
+}
+
+// GetFeed returns recent activity from the users the caller follows.
+// Projects are always included; likes and stars can be added with
+// ?include=likes,stars. Results are paginated with ?cursor= and ?limit=.
+func GetFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit, err := parseLimit(r, defaultPageSize, maxPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	args := []interface{}{userID, limit + 1}
+	cursorFilter := func(timeCol, kind, projectCol, actorCol string) string { return "" }
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		cursor, err := decodeFeedCursor(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		args = append(args, cursor.EventAt, cursor.Kind, cursor.ProjectID, cursor.ActorID)
+		cursorFilter = func(timeCol, kind, projectCol, actorCol string) string {
+			return fmt.Sprintf(" AND (%s, '%s', %s, %s) < ($3, $4::TEXT, $5, $6)", timeCol, kind, projectCol, actorCol)
+		}
+	}
+
+	// Each branch is limited on its own so Postgres can walk the
+	// (user_id, created_at) indexes instead of sorting every row
+	branches := []string{fmt.Sprintf(`
+		(SELECT 'project' AS kind, p.user_id AS actor_id, p.id AS project_id, p.created_at AS event_at
+		FROM projects p
+		WHERE p.user_id IN (SELECT following_id FROM followers WHERE follower_id = $1)%s
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2)`, cursorFilter("p.created_at", "project", "p.id", "p.user_id"))}
+
+	seen := map[string]bool{}
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		include = strings.TrimSpace(include)
+		table, ok := feedSources[include]
+		if !ok || seen[include] {
+			continue
+		}
+		seen[include] = true
+		kind := strings.TrimSuffix(include, "s")
+		branches = append(branches, fmt.Sprintf(`
+		(SELECT '%s' AS kind, a.user_id AS actor_id, a.project_id, a.created_at AS event_at
+		FROM %s a
+		WHERE a.user_id IN (SELECT following_id FROM followers WHERE follower_id = $1)%s
+		ORDER BY a.created_at DESC, a.project_id DESC, a.user_id DESC
+		LIMIT $2)`, kind, table, cursorFilter("a.created_at", kind, "a.project_id", "a.user_id")))
+	}
+
+	query := `
+		SELECT e.kind, e.event_at,
+		       a.id, a.username, a.first_name, a.last_name, a.profile_picture,
+		       p.id, p.user_id, p.name, p.description, p.code, p.general_tags, p.programming_tags,
+		       p.likes, p.status, p.created_at, p.images,
+		       u.username, u.email, ` + commentsCountColumn + `,
+		       EXISTS(SELECT 1 FROM project_saves s WHERE s.project_id = p.id AND s.user_id = $1),
+		       EXISTS(SELECT 1 FROM project_stars st WHERE st.project_id = p.id AND st.user_id = $1)
+		FROM (` + strings.Join(branches, " UNION ALL ") + `) e
+		JOIN projects p ON p.id = e.project_id
+		JOIN users a ON a.id = e.actor_id
+		JOIN users u ON u.id = p.user_id
+		ORDER BY e.event_at DESC, e.kind DESC, e.project_id DESC, e.actor_id DESC
+		LIMIT $2`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	items := []models.FeedItem{}
+	for rows.Next() {
+		var item models.FeedItem
+		var dev models.User
+		var genTags, progTags, images []string
+
+		err := rows.Scan(
+			&item.Type, &item.CreatedAt,
+			&item.Actor.ID, &item.Actor.Username, &item.Actor.FirstName, &item.Actor.LastName, &item.Actor.ProfilePicture,
+			&item.Project.ID, &item.Project.UserID, &item.Project.Name, &item.Project.Description, &item.Project.Code,
+			pq.Array(&genTags), pq.Array(&progTags),
+			&item.Project.Likes, &item.Project.Status, &item.Project.CreatedAt, pq.Array(&images),
+			&dev.Username, &dev.Email, &item.Project.CommentsCount,
+			&item.Project.SavedByUser, &item.Project.StarredByUser,
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+
+		if genTags == nil {
+			genTags = []string{}
+		}
+		if progTags == nil {
+			progTags = []string{}
+		}
+		if images == nil {
+			images = []string{}
+		}
+		item.Project.GeneralTags = genTags
+		item.Project.ProgrammingTags = progTags
+		item.Project.Images = images
+		item.Project.Developer = &dev
+		items = append(items, item)
+	}
+
+	projects := make([]*models.Project, len(items))
+	actors := make([]*models.User, len(items))
+	for i := range items {
+		projects[i] = &items[i].Project
+		actors[i] = &items[i].Actor
+	}
+	fillProjectImages(r.Context(), projects...)
+	fillUserImages(r.Context(), actors...)
+
+	response := models.FeedResponse{Items: items}
+	if len(items) > limit {
+		last := items[limit-1]
+		response.Items = items[:limit]
+		response.NextCursor = encodeFeedCursor(last)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
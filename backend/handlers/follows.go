@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const maxSuggestions = 20
+
+// GetFollowers lists the users following {username}
+func GetFollowers(w http.ResponseWriter, r *http.Request) {
+	listFollows(w, r, "follower_id", "following_id")
+}
+
+// GetFollowing lists the users {username} follows
+func GetFollowing(w http.ResponseWriter, r *http.Request) {
+	listFollows(w, r, "following_id", "follower_id")
+}
+
+// listFollows pages through the followers table. userCol is the column
+// holding the listed users, ownerCol the column matching {username}.
+func listFollows(w http.ResponseWriter, r *http.Request, userCol, ownerCol string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+	if username == "" {
+		http.Error(w, "Username is required", http.StatusBadRequest)
+		return
+	}
+
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var ownerID int
+	err = database.DB.QueryRowContext(r.Context(), "SELECT id FROM users WHERE username = $1", username).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	// Anonymous callers get every relationship flag as false
+	callerID := getUserIDFromToken(r)
+
+	query := `
+		SELECT f.id, f.created_at,
+		       u.id, u.username, u.first_name, u.last_name, u.profile_picture, u.user_type, u.bio,
+		       EXISTS(SELECT 1 FROM followers x WHERE x.follower_id = $2 AND x.following_id = u.id),
+		       EXISTS(SELECT 1 FROM followers y WHERE y.follower_id = u.id AND y.following_id = $2)
+		FROM followers f
+		JOIN users u ON u.id = f.` + userCol + `
+		WHERE f.` + ownerCol + ` = $1`
+	args := []interface{}{ownerID, callerID, limit + 1}
+	if cursor != nil {
+		query += ` AND (f.created_at, f.id) < ($4, $5)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY f.created_at DESC, f.id DESC LIMIT $3`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.FollowEntry{}
+	var lastID int
+	var lastAt time.Time
+	for rows.Next() {
+		var e models.FollowEntry
+		var followID int
+		err := rows.Scan(
+			&followID, &e.FollowedAt,
+			&e.User.ID, &e.User.Username, &e.User.FirstName, &e.User.LastName, &e.User.ProfilePicture,
+			&e.User.UserType, &e.User.Bio,
+			&e.IsFollowing, &e.FollowsYou,
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		e.Mutual = e.IsFollowing && e.FollowsYou
+
+		if len(entries) < limit {
+			lastID, lastAt = followID, e.FollowedAt
+		}
+		entries = append(entries, e)
+	}
+
+	response := models.FollowListResponse{Users: entries}
+	if len(entries) > limit {
+		response.Users = entries[:limit]
+		response.NextCursor = encodeCursor(lastAt, lastID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSuggestedUsers recommends people to follow based on the follow graph:
+// users followed by the people the caller follows, ranked by how many of
+// them do. Popular users fill the list when the graph is too sparse.
+func GetSuggestedUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := `
+		WITH my_following AS (
+			SELECT following_id FROM followers WHERE follower_id = $1
+		),
+		candidates AS (
+			SELECT f.following_id AS user_id,
+			       COUNT(DISTINCT f.follower_id) AS mutual_count,
+			       (ARRAY_AGG(DISTINCT mu.username))[1:3] AS followed_by
+			FROM followers f
+			JOIN users mu ON mu.id = f.follower_id
+			WHERE f.follower_id IN (SELECT following_id FROM my_following)
+			  AND f.following_id <> $1
+			  AND f.following_id NOT IN (SELECT following_id FROM my_following)
+			GROUP BY f.following_id
+		),
+		popular AS (
+			SELECT u.id AS user_id, 0 AS mutual_count, ARRAY[]::TEXT[] AS followed_by,
+			       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) AS followers
+			FROM users u
+			WHERE u.id <> $1
+			  AND u.id NOT IN (SELECT following_id FROM my_following)
+			  AND u.id NOT IN (SELECT user_id FROM candidates)
+			ORDER BY followers DESC, u.id
+			LIMIT $2
+		)
+		SELECT u.id, u.username, u.first_name, u.last_name, u.profile_picture, u.user_type, u.bio,
+		       s.mutual_count, s.followed_by
+		FROM (
+			SELECT user_id, mutual_count, followed_by, 1 AS tier FROM candidates
+			UNION ALL
+			SELECT user_id, mutual_count, followed_by, 2 AS tier FROM popular
+		) s
+		JOIN users u ON u.id = s.user_id
+		ORDER BY s.tier, s.mutual_count DESC, u.id
+		LIMIT $2`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, userID, maxSuggestions)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	suggestions := []models.SuggestedUser{}
+	for rows.Next() {
+		var s models.SuggestedUser
+		var followedBy []string
+		err := rows.Scan(
+			&s.User.ID, &s.User.Username, &s.User.FirstName, &s.User.LastName, &s.User.ProfilePicture,
+			&s.User.UserType, &s.User.Bio,
+			&s.MutualCount, pq.Array(&followedBy),
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		if followedBy == nil {
+			followedBy = []string{}
+		}
+		s.FollowedBy = followedBy
+		suggestions = append(suggestions, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"auth-app-backend/models"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	gqlotel "github.com/graph-gophers/graphql-go/trace/otel"
+)
+
+// Limits keeping a single query from loading half the database. Depth and
+// the cost worked out from the query are checked before running; the cost
+// of every user and project resolved is counted again as it runs.
+const (
+	gqlMaxDepth       = 7
+	gqlMaxCost        = 1000
+	gqlMaxQueryLength = 10000
+	gqlDefaultFirst   = 20
+	gqlMaxFirst       = 50
+)
+
+const gqlSchemaSDL = `
+schema {
+	query: Query
+}
+
+scalar Time
+
+type Query {
+	# The signed in user, null without a token
+	me: User
+	user(username: String!): User
+	project(id: ID!): Project
+	trending(first: Int = 10, tag: String): [Project!]!
+}
+
+type User {
+	id: ID!
+	username: String!
+	# Only visible to the user themselves
+	email: String
+	firstName: String
+	lastName: String
+	userType: String
+	bio: String
+	profilePicture: String
+	banner: String
+	githubLink: String
+	portfolioLink: String
+	linkedinLink: String
+	companyName: String
+	createdAt: Time!
+	followersCount: Int!
+	followingCount: Int!
+	followers(first: Int = 20): [User!]!
+	following(first: Int = 20): [User!]!
+	# Whether the signed in user follows this user
+	viewerFollows: Boolean!
+	# Whether this user follows the signed in user
+	followsViewer: Boolean!
+	projects(first: Int = 20): [Project!]!
+	# Only visible to the user themselves
+	saved(first: Int = 20): [Project!]
+	starred(first: Int = 20): [Project!]!
+}
+
+type Project {
+	id: ID!
+	name: String!
+	description: String!
+	code: String!
+	status: String!
+	generalTags: [String!]!
+	programmingTags: [String!]!
+	images: [String!]!
+	coverImage: String
+	createdAt: Time!
+	developer: User!
+	likesCount: Int!
+	commentsCount: Int!
+	savesCount: Int!
+	starsCount: Int!
+	viewerSaved: Boolean!
+	viewerStarred: Boolean!
+	stargazers(first: Int = 20): [User!]!
+}
+`
+
+var gqlSchema = graphql.MustParseSchema(gqlSchemaSDL, &gqlQuery{},
+	graphql.MaxDepth(gqlMaxDepth),
+	graphql.MaxQueryLength(gqlMaxQueryLength),
+	// Sibling list items must resolve together to share a batch
+	graphql.MaxParallelism(2*gqlMaxFirst),
+	graphql.Tracer(gqlotel.DefaultTracer()),
+)
+
+var errQueryTooExpensive = errors.New("query is too expensive, ask for fewer items")
+
+// gqlRequest is the state of one GraphQL request: the caller, what it has
+// cost so far and its loaders
+type gqlRequest struct {
+	viewerID int
+	cost     atomic.Int64
+	loaders  *gqlLoaders
+}
+
+type gqlRequestKey struct{}
+
+func gqlFromContext(ctx context.Context) *gqlRequest {
+	return ctx.Value(gqlRequestKey{}).(*gqlRequest)
+}
+
+// charge adds n resolved objects to the request's cost
+func (q *gqlRequest) charge(n int) error {
+	if q.cost.Add(int64(n)) > gqlMaxCost {
+		return errQueryTooExpensive
+	}
+	return nil
+}
+
+// GraphQL serves queries over users, projects, follows, saves and stars,
+// as a JSON body on POST or ?query= on GET. The caller is taken from the
+// bearer token like every other route.
+func GraphQL(w http.ResponseWriter, r *http.Request) {
+	var req models.GraphQLRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				http.Error(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	if cost, ok := gqlQueryCost(req.Query, req.OperationName, req.Variables); ok && cost > gqlMaxCost {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&graphql.Response{
+			Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("%s", errQueryTooExpensive)},
+		})
+		return
+	}
+
+	gq := &gqlRequest{viewerID: getUserIDFromToken(r)}
+	gq.loaders = newGQLLoaders(gq.viewerID)
+	ctx := context.WithValue(r.Context(), gqlRequestKey{}, gq)
+
+	resp := gqlSchema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// clampFirst applies the default and maximum page size to a first argument
+func clampFirst(first int32) int {
+	switch {
+	case first <= 0:
+		return gqlDefaultFirst
+	case first > gqlMaxFirst:
+		return gqlMaxFirst
+	}
+	return int(first)
+}
@@ -0,0 +1,514 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/ast"
+)
+
+// gqlQueryCost works out the most users and projects a query can resolve
+// before it runs: every object field counts once per parent item and a list
+// counts as many items as its first argument allows, multiplied down through
+// nested lists. ok is false when the query can't be read or names no single
+// operation; Exec reports those. Objects resolved at runtime are still
+// charged, as a backstop for anything this misses.
+func gqlQueryCost(query, operationName string, variables map[string]interface{}) (cost int64, ok bool) {
+	if len(query) > gqlMaxQueryLength {
+		return 0, false
+	}
+	doc, err := parseGQLDocument(query)
+	if err != nil {
+		return 0, false
+	}
+	op := doc.operation(operationName)
+	if op == nil || op.kind != "query" {
+		return 0, false
+	}
+	root, _ := gqlSchema.AST().RootOperationTypes["query"].(*ast.ObjectTypeDefinition)
+	if root == nil {
+		return 0, false
+	}
+
+	c := &gqlCostWalk{
+		doc:      doc,
+		vars:     variables,
+		defaults: op.defaults,
+		visiting: map[string]bool{},
+	}
+	c.selections(root, op.selections, 1)
+	return c.cost, true
+}
+
+type gqlCostWalk struct {
+	doc      *gqlDocument
+	vars     map[string]interface{}
+	defaults map[string]int64
+	visiting map[string]bool
+	cost     int64
+}
+
+func (c *gqlCostWalk) selections(typ *ast.ObjectTypeDefinition, sels []gqlSelection, items int64) {
+	for _, sel := range sels {
+		if c.cost > gqlMaxCost {
+			return
+		}
+		switch {
+		case sel.spread != "":
+			frag, ok := c.doc.fragments[sel.spread]
+			if !ok || c.visiting[sel.spread] {
+				continue
+			}
+			c.visiting[sel.spread] = true
+			c.selections(gqlObjectType(frag.on, typ), frag.selections, items)
+			c.visiting[sel.spread] = false
+		case sel.field == "":
+			c.selections(gqlObjectType(sel.on, typ), sel.children, items)
+		default:
+			def := typ.Fields.Get(sel.field)
+			if def == nil {
+				continue
+			}
+			child, list := gqlUnwrap(def.Type)
+			if child == nil {
+				continue
+			}
+			count := items
+			if list {
+				count *= c.first(def, sel.args)
+			}
+			// Past the maximum the exact figure no longer matters, and
+			// capping it keeps deep nesting from overflowing
+			count = min(count, gqlMaxCost+1)
+			c.cost += count
+			c.selections(child, sel.children, count)
+		}
+	}
+}
+
+// first is the page size a list field will be loaded with, following
+// clampFirst
+func (c *gqlCostWalk) first(def *ast.FieldDefinition, args map[string]gqlArg) int64 {
+	arg, given := args["first"]
+	first := arg.value
+	if given && arg.variable != "" {
+		v, set := c.vars[arg.variable]
+		switch n := v.(type) {
+		case float64:
+			first = int64(min(max(n, 0), gqlMaxFirst))
+		default:
+			first = 0
+		}
+		if !set {
+			first, given = c.defaults[arg.variable]
+		}
+	}
+	if !given {
+		if in := def.Arguments.Get("first"); in != nil && in.Default != nil {
+			if d, ok := in.Default.Deserialize(nil).(int32); ok {
+				first = int64(d)
+			}
+		}
+	}
+	return int64(clampFirst(int32(min(max(first, 0), gqlMaxFirst))))
+}
+
+// gqlObjectType looks up the type a fragment applies to, falling back to
+// the enclosing type when it has no condition
+func gqlObjectType(name string, enclosing *ast.ObjectTypeDefinition) *ast.ObjectTypeDefinition {
+	if name == "" {
+		return enclosing
+	}
+	if t, ok := gqlSchema.AST().Types[name].(*ast.ObjectTypeDefinition); ok {
+		return t
+	}
+	return enclosing
+}
+
+// gqlUnwrap returns the object type a field resolves to, nil for scalars,
+// and whether it is a list of them
+func gqlUnwrap(t ast.Type) (obj *ast.ObjectTypeDefinition, list bool) {
+	for {
+		switch u := t.(type) {
+		case *ast.NonNull:
+			t = u.OfType
+		case *ast.List:
+			list = true
+			t = u.OfType
+		case *ast.ObjectTypeDefinition:
+			return u, list
+		default:
+			return nil, false
+		}
+	}
+}
+
+// gqlDocument is the part of a query document the cost depends on
+type gqlDocument struct {
+	operations []gqlOperation
+	fragments  map[string]gqlFragment
+}
+
+type gqlOperation struct {
+	kind       string
+	name       string
+	defaults   map[string]int64
+	selections []gqlSelection
+}
+
+type gqlFragment struct {
+	on         string
+	selections []gqlSelection
+}
+
+// gqlSelection is a field, a named fragment spread or an inline fragment
+type gqlSelection struct {
+	field    string
+	args     map[string]gqlArg
+	spread   string
+	on       string
+	children []gqlSelection
+}
+
+// gqlArg is an argument's integer value or the variable giving it; other
+// values read as 0
+type gqlArg struct {
+	value    int64
+	variable string
+}
+
+// operation picks the operation Exec would run
+func (d *gqlDocument) operation(name string) *gqlOperation {
+	if name == "" {
+		if len(d.operations) != 1 {
+			return nil
+		}
+		return &d.operations[0]
+	}
+	for i := range d.operations {
+		if d.operations[i].name == name {
+			return &d.operations[i]
+		}
+	}
+	return nil
+}
+
+const (
+	gqlTokEOF = iota
+	gqlTokName
+	gqlTokInt
+	gqlTokFloat
+	gqlTokString
+	gqlTokPunct
+)
+
+type gqlToken struct {
+	kind int
+	text string
+}
+
+// lexGQL splits a query into tokens, dropping whitespace, commas and
+// comments
+func lexGQL(src string) ([]gqlToken, error) {
+	var toks []gqlToken
+	isName := func(c byte, first bool) bool {
+		return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+	}
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == 0xEF && strings.HasPrefix(src[i:], "\uFEFF"):
+			i += 3
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case c == '.':
+			if len(src) < i+3 || src[i:i+3] != "..." {
+				return nil, fmt.Errorf("unexpected %q", c)
+			}
+			toks = append(toks, gqlToken{gqlTokPunct, "..."})
+			i += 3
+		case c == '!' || c == '$' || c == '&' || c == '(' || c == ')' || c == ':' ||
+			c == '=' || c == '@' || c == '[' || c == ']' || c == '{' || c == '|' || c == '}':
+			toks = append(toks, gqlToken{gqlTokPunct, string(c)})
+			i++
+		case isName(c, true):
+			j := i + 1
+			for j < len(src) && isName(src[j], false) {
+				j++
+			}
+			toks = append(toks, gqlToken{gqlTokName, src[i:j]})
+			i = j
+		case c == '-' || isDigit(c):
+			j, kind := i+1, gqlTokInt
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.' || src[j] == 'e' || src[j] == 'E' ||
+				(src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				if !isDigit(src[j]) {
+					kind = gqlTokFloat
+				}
+				j++
+			}
+			toks = append(toks, gqlToken{kind, src[i:j]})
+			i = j
+		case c == '"' && len(src) >= i+3 && src[i:i+3] == `"""`:
+			j := i + 3
+			for ; ; j++ {
+				if j+3 > len(src) {
+					return nil, fmt.Errorf("unterminated block string")
+				}
+				if src[j] == '\\' && j+4 <= len(src) && src[j+1:j+4] == `"""` {
+					j += 3
+					continue
+				}
+				if src[j:j+3] == `"""` {
+					break
+				}
+			}
+			toks = append(toks, gqlToken{gqlTokString, src[i : j+3]})
+			i = j + 3
+		case c == '"':
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+				if j < len(src) && (src[j] == '\n' || src[j] == '\r') {
+					return nil, fmt.Errorf("unterminated string")
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			toks = append(toks, gqlToken{gqlTokString, src[i : j+1]})
+			i = j + 1
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return append(toks, gqlToken{kind: gqlTokEOF}), nil
+}
+
+type gqlParser struct {
+	toks []gqlToken
+	pos  int
+}
+
+// gqlSyntaxError unwinds the parser back to parseGQLDocument
+type gqlSyntaxError struct{ err error }
+
+func parseGQLDocument(src string) (doc *gqlDocument, err error) {
+	toks, err := lexGQL(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(gqlSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, se.err
+		}
+	}()
+
+	p := &gqlParser{toks: toks}
+	doc = &gqlDocument{fragments: map[string]gqlFragment{}}
+	for p.peek().kind != gqlTokEOF {
+		switch {
+		case p.peekPunct("{"):
+			doc.operations = append(doc.operations, gqlOperation{kind: "query", selections: p.selectionSet()})
+		case p.peekName("fragment"):
+			p.next()
+			name := p.name()
+			p.expectName("on")
+			frag := gqlFragment{on: p.name()}
+			p.directives()
+			frag.selections = p.selectionSet()
+			doc.fragments[name] = frag
+		default:
+			op := gqlOperation{kind: p.name(), defaults: map[string]int64{}}
+			if p.peek().kind == gqlTokName {
+				op.name = p.name()
+			}
+			if p.skipPunct("(") {
+				for !p.skipPunct(")") {
+					p.expectPunct("$")
+					v := p.name()
+					p.expectPunct(":")
+					p.typeRef()
+					if p.skipPunct("=") {
+						op.defaults[v] = p.value().value
+					}
+					p.directives()
+				}
+			}
+			p.directives()
+			op.selections = p.selectionSet()
+			doc.operations = append(doc.operations, op)
+		}
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) fail(format string, a ...interface{}) {
+	panic(gqlSyntaxError{fmt.Errorf(format, a...)})
+}
+
+func (p *gqlParser) peek() gqlToken { return p.toks[p.pos] }
+
+func (p *gqlParser) next() gqlToken {
+	t := p.toks[p.pos]
+	if t.kind != gqlTokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *gqlParser) peekPunct(s string) bool {
+	t := p.peek()
+	return t.kind == gqlTokPunct && t.text == s
+}
+
+func (p *gqlParser) peekName(s string) bool {
+	t := p.peek()
+	return t.kind == gqlTokName && t.text == s
+}
+
+func (p *gqlParser) skipPunct(s string) bool {
+	if p.peekPunct(s) {
+		p.next()
+		return true
+	}
+	if p.peek().kind == gqlTokEOF {
+		p.fail("unexpected end of query")
+	}
+	return false
+}
+
+func (p *gqlParser) expectPunct(s string) {
+	if !p.skipPunct(s) {
+		p.fail("expected %q, got %q", s, p.peek().text)
+	}
+}
+
+func (p *gqlParser) expectName(s string) {
+	if !p.peekName(s) {
+		p.fail("expected %q, got %q", s, p.peek().text)
+	}
+	p.next()
+}
+
+func (p *gqlParser) name() string {
+	t := p.next()
+	if t.kind != gqlTokName {
+		p.fail("expected a name, got %q", t.text)
+	}
+	return t.text
+}
+
+func (p *gqlParser) selectionSet() []gqlSelection {
+	var sels []gqlSelection
+	p.expectPunct("{")
+	for !p.skipPunct("}") {
+		sels = append(sels, p.selection())
+	}
+	return sels
+}
+
+func (p *gqlParser) selection() gqlSelection {
+	var sel gqlSelection
+	if p.skipPunct("...") {
+		switch {
+		case p.peekName("on"):
+			p.next()
+			sel.on = p.name()
+		case p.peek().kind == gqlTokName:
+			sel.spread = p.name()
+			p.directives()
+			return sel
+		}
+		p.directives()
+		sel.children = p.selectionSet()
+		return sel
+	}
+
+	sel.field = p.name()
+	if p.skipPunct(":") {
+		sel.field = p.name()
+	}
+	if p.skipPunct("(") {
+		sel.args = map[string]gqlArg{}
+		for !p.skipPunct(")") {
+			name := p.name()
+			p.expectPunct(":")
+			sel.args[name] = p.value()
+		}
+	}
+	p.directives()
+	if p.peekPunct("{") {
+		sel.children = p.selectionSet()
+	}
+	return sel
+}
+
+func (p *gqlParser) directives() {
+	for p.skipPunct("@") {
+		p.name()
+		if p.skipPunct("(") {
+			for !p.skipPunct(")") {
+				p.name()
+				p.expectPunct(":")
+				p.value()
+			}
+		}
+	}
+}
+
+func (p *gqlParser) typeRef() {
+	if p.skipPunct("[") {
+		p.typeRef()
+		p.expectPunct("]")
+	} else {
+		p.name()
+	}
+	p.skipPunct("!")
+}
+
+// value reads any input value, keeping integers and variables
+func (p *gqlParser) value() gqlArg {
+	switch {
+	case p.skipPunct("$"):
+		return gqlArg{variable: p.name()}
+	case p.skipPunct("["):
+		for !p.skipPunct("]") {
+			p.value()
+		}
+	case p.skipPunct("{"):
+		for !p.skipPunct("}") {
+			p.name()
+			p.expectPunct(":")
+			p.value()
+		}
+	default:
+		t := p.next()
+		switch t.kind {
+		case gqlTokInt:
+			n, err := strconv.ParseInt(t.text, 10, 64)
+			if err != nil {
+				// Too large for any page size; clamped all the same
+				n = gqlMaxFirst
+			}
+			return gqlArg{value: n}
+		case gqlTokFloat, gqlTokString, gqlTokName:
+		default:
+			p.fail("expected a value, got %q", t.text)
+		}
+	}
+	return gqlArg{}
+}
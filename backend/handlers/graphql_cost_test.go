@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGQLQueryCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		vars      map[string]interface{}
+		want      int64
+	}{
+		{"scalars only", `{ __typename }`, "", nil, 0},
+		{"single object", `{ me { username bio } }`, "", nil, 1},
+		{"list uses the schema default", `{ trending { name } }`, "", nil, 10},
+		{"nested default", `{ me { followers { username } } }`, "", nil, 21},
+		{"object under a list", `{ trending { developer { username } } }`, "", nil, 20},
+		{"lists multiply", `{ trending(first: 5) { stargazers(first: 4) { username } } }`, "", nil, 25},
+		{"first is capped", `{ trending(first: 500) { name } }`, "", nil, gqlMaxFirst},
+		{"non-positive first gets the default", `{ trending(first: 0) { name } me { starred(first: -3) { name } } }`, "", nil, gqlDefaultFirst + 1 + gqlDefaultFirst},
+		{"aliases add up", `{ a: trending(first: 2) { name } b: trending(first: 3) { name } }`, "", nil, 5},
+		{"variable", `query Q($n: Int) { trending(first: $n) { name } }`, "", map[string]interface{}{"n": float64(3)}, 3},
+		{"missing variable", `query Q($n: Int) { trending(first: $n) { name } }`, "", nil, 10},
+		{"variable default", `query Q($n: Int = 7) { trending(first: $n) { name } }`, "", nil, 7},
+		{"null variable", `query Q($n: Int) { trending(first: $n) { name } }`, "", map[string]interface{}{"n": nil}, gqlDefaultFirst},
+		{
+			"fragments",
+			`query { me { ...F } }
+			fragment F on User { followers(first: 10) { ...G } }
+			fragment G on User { projects(first: 2) { name } }`,
+			"", nil, 1 + 10 + 20,
+		},
+		{"inline fragment", `{ me { ... on User { starred(first: 3) { name } } } }`, "", nil, 4},
+		{"recursive fragment stops", `{ me { ...A } } fragment A on User { followers(first: 1) { ...A } }`, "", nil, 2},
+		{
+			"named operation",
+			`query A { me { username } } query B { trending(first: 4) { name } }`,
+			"B", nil, 4,
+		},
+		{
+			"strings, comments and directives are skipped",
+			`# "first: 50" in a comment
+			{ trending(first: 2, tag: """block "first" string""") @include(if: true) { name } user(username: "a\"b") { id } }`,
+			"", nil, 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := gqlQueryCost(tt.query, tt.operation, tt.vars)
+			if !ok {
+				t.Fatal("query was not costed")
+			}
+			if got != tt.want {
+				t.Errorf("cost = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGQLQueryCostOverMaximum(t *testing.T) {
+	queries := []string{
+		`{ trending(first: 50) { stargazers(first: 50) { username } } }`,
+		`{ me { followers(first: 50) { following(first: 50) { followers(first: 50) { following(first: 50) { projects(first: 50) { name } } } } } } }`,
+		`query { me { ...F } } fragment F on User { followers(first: 50) { ...G } } fragment G on User { following(first: 50) { username } }`,
+	}
+	for _, q := range queries {
+		cost, ok := gqlQueryCost(q, "", nil)
+		if !ok || cost <= gqlMaxCost {
+			t.Errorf("gqlQueryCost(%q) = %d, %v, want over %d", q, cost, ok, gqlMaxCost)
+		}
+	}
+}
+
+func TestGQLQueryCostLeavesErrorsToExec(t *testing.T) {
+	tests := []struct {
+		name, query, operation string
+	}{
+		{"unterminated selection", `{ me {`, ""},
+		{"bad character", `{ me ^ }`, ""},
+		{"unterminated string", `{ user(username: "alice) { id } }`, ""},
+		{"mutation", `mutation { me { id } }`, ""},
+		{"ambiguous operation", `query A { me { id } } query B { me { id } }`, ""},
+		{"unknown operation", `query A { me { id } }`, "B"},
+		{"too long", "{ me { " + strings.Repeat("id ", gqlMaxQueryLength) + "} }", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := gqlQueryCost(tt.query, tt.operation, nil); ok {
+				t.Error("query was costed")
+			}
+		})
+	}
+}
+
+func TestGraphQLRejectsExpensiveQuery(t *testing.T) {
+	body := `{"query": "{ trending(first: 50) { stargazers(first: 50) { username } } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	// Rejected before any resolver runs, so no database is needed
+	GraphQL(rec, req)
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != errQueryTooExpensive.Error() {
+		t.Errorf("errors = %+v, want %q", resp.Errors, errQueryTooExpensive)
+	}
+	if len(resp.Data) != 0 {
+		t.Errorf("data = %s, want none", resp.Data)
+	}
+}
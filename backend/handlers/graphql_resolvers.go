@@ -0,0 +1,648 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/lib/pq"
+)
+
+// errGQLInternal hides database errors from GraphQL clients; the cause is
+// logged instead
+var errGQLInternal = errors.New("internal server error")
+
+// listKey asks for the first items of a list belonging to id
+type listKey struct {
+	id    int
+	first int
+}
+
+type projectCounts struct {
+	likes, comments, saves, stars int32
+}
+
+type followCounts struct {
+	followers, following int32
+}
+
+type viewerProjectState struct {
+	saved, starred bool
+}
+
+type viewerUserState struct {
+	follows, followedBy bool
+}
+
+// gqlLoaders batch every lookup a query can repeat per user or project
+type gqlLoaders struct {
+	users         *batchLoader[int, *models.User]
+	usersByName   *batchLoader[string, *models.User]
+	projects      *batchLoader[int, *models.Project]
+	userProjects  *batchLoader[listKey, []*models.Project]
+	userSaved     *batchLoader[listKey, []*models.Project]
+	userStarred   *batchLoader[listKey, []*models.Project]
+	followers     *batchLoader[listKey, []*models.User]
+	following     *batchLoader[listKey, []*models.User]
+	stargazers    *batchLoader[listKey, []*models.User]
+	followCounts  *batchLoader[int, followCounts]
+	projectCounts *batchLoader[int, projectCounts]
+	viewerUsers   *batchLoader[int, viewerUserState]
+	viewerProject *batchLoader[int, viewerProjectState]
+}
+
+// gqlUserColumns and gqlProjectColumns are scanned by scanGQLUser and
+// scanGQLProject
+const gqlUserColumns = `u.id, u.username, u.email, u.first_name, u.last_name, u.user_type,
+	u.github_link, u.portfolio_link, u.linkedin_link, u.company_name,
+	u.profile_picture, u.banner, u.bio, u.created_at`
+
+const gqlProjectColumns = `p.id, p.user_id, p.name, COALESCE(p.description, ''), COALESCE(p.code, ''),
+	COALESCE(p.general_tags, '{}'), COALESCE(p.programming_tags, '{}'), p.status, p.created_at,
+	COALESCE(p.images, '{}')`
+
+func scanGQLUser(rows *sql.Rows, dest ...interface{}) (*models.User, error) {
+	var u models.User
+	err := rows.Scan(append(dest,
+		&u.ID, &u.Username, &u.Email, &u.FirstName, &u.LastName, &u.UserType,
+		&u.GithubLink, &u.PortfolioLink, &u.LinkedinLink, &u.CompanyName,
+		&u.ProfilePicture, &u.Banner, &u.Bio, &u.CreatedAt)...)
+	return &u, err
+}
+
+func scanGQLProject(rows *sql.Rows, dest ...interface{}) (*models.Project, error) {
+	var p models.Project
+	err := rows.Scan(append(dest,
+		&p.ID, &p.UserID, &p.Name, &p.Description, &p.Code,
+		pq.Array(&p.GeneralTags), pq.Array(&p.ProgrammingTags), &p.Status, &p.CreatedAt,
+		pq.Array(&p.Images))...)
+	return &p, err
+}
+
+func newGQLLoaders(viewerID int) *gqlLoaders {
+	return &gqlLoaders{
+		users: newLoader(func(ctx context.Context, ids []int) (map[int]*models.User, error) {
+			return fetchGQLUsers(ctx, `u.id = ANY($1)`, pq.Array(ids), func(u *models.User) int { return u.ID })
+		}),
+		usersByName: newLoader(func(ctx context.Context, names []string) (map[string]*models.User, error) {
+			return fetchGQLUsers(ctx, `u.username = ANY($1)`, pq.Array(names), func(u *models.User) string { return u.Username })
+		}),
+		projects: newLoader(fetchGQLProjects),
+
+		userProjects: newLoader(func(ctx context.Context, keys []listKey) (map[listKey][]*models.Project, error) {
+			return fetchGQLProjectLists(ctx, keys, `
+				SELECT `+gqlProjectColumns+` FROM projects p
+				WHERE p.user_id = k.id
+				ORDER BY p.created_at DESC, p.id DESC`)
+		}),
+		userSaved: newLoader(func(ctx context.Context, keys []listKey) (map[listKey][]*models.Project, error) {
+			return fetchGQLProjectLists(ctx, keys, `
+				SELECT `+gqlProjectColumns+` FROM project_saves s JOIN projects p ON p.id = s.project_id
+				WHERE s.user_id = k.id
+				ORDER BY s.created_at DESC, p.id DESC`)
+		}),
+		userStarred: newLoader(func(ctx context.Context, keys []listKey) (map[listKey][]*models.Project, error) {
+			return fetchGQLProjectLists(ctx, keys, `
+				SELECT `+gqlProjectColumns+` FROM project_stars st JOIN projects p ON p.id = st.project_id
+				WHERE st.user_id = k.id
+				ORDER BY st.created_at DESC, p.id DESC`)
+		}),
+
+		followers: newLoader(func(ctx context.Context, keys []listKey) (map[listKey][]*models.User, error) {
+			return fetchGQLUserLists(ctx, keys, `
+				SELECT `+gqlUserColumns+` FROM followers f JOIN users u ON u.id = f.follower_id
+				WHERE f.following_id = k.id
+				ORDER BY f.created_at DESC, u.id DESC`)
+		}),
+		following: newLoader(func(ctx context.Context, keys []listKey) (map[listKey][]*models.User, error) {
+			return fetchGQLUserLists(ctx, keys, `
+				SELECT `+gqlUserColumns+` FROM followers f JOIN users u ON u.id = f.following_id
+				WHERE f.follower_id = k.id
+				ORDER BY f.created_at DESC, u.id DESC`)
+		}),
+		stargazers: newLoader(func(ctx context.Context, keys []listKey) (map[listKey][]*models.User, error) {
+			return fetchGQLUserLists(ctx, keys, `
+				SELECT `+gqlUserColumns+` FROM project_stars st JOIN users u ON u.id = st.user_id
+				WHERE st.project_id = k.id
+				ORDER BY st.created_at DESC, u.id DESC`)
+		}),
+
+		followCounts:  newLoader(fetchGQLFollowCounts),
+		projectCounts: newLoader(fetchGQLProjectCounts),
+		viewerUsers: newLoader(func(ctx context.Context, ids []int) (map[int]viewerUserState, error) {
+			return fetchGQLViewerUsers(ctx, viewerID, ids)
+		}),
+		viewerProject: newLoader(func(ctx context.Context, ids []int) (map[int]viewerProjectState, error) {
+			return fetchGQLViewerProjects(ctx, viewerID, ids)
+		}),
+	}
+}
+
+func gqlError(ctx context.Context, err error) error {
+	logErrorAt(ctx, "GraphQL resolver failed", err, 1)
+	return errGQLInternal
+}
+
+func fetchGQLUsers[K comparable](ctx context.Context, where string, arg interface{}, key func(*models.User) K) (map[K]*models.User, error) {
+	rows, err := database.DB.QueryContext(ctx, `SELECT `+gqlUserColumns+` FROM users u WHERE `+where, arg)
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	found := map[K]*models.User{}
+	var users []*models.User
+	for rows.Next() {
+		u, err := scanGQLUser(rows)
+		if err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[key(u)] = u
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	fillUserImages(ctx, users...)
+	return found, nil
+}
+
+func fetchGQLProjects(ctx context.Context, ids []int) (map[int]*models.Project, error) {
+	rows, err := database.DB.QueryContext(ctx, `SELECT `+gqlProjectColumns+` FROM projects p WHERE p.id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	found := map[int]*models.Project{}
+	var projects []*models.Project
+	for rows.Next() {
+		p, err := scanGQLProject(rows)
+		if err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[p.ID] = p
+		projects = append(projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	fillProjectImages(ctx, projects...)
+	return found, nil
+}
+
+// listKeyArrays splits keys into the id and limit arrays the list queries
+// unnest, so every list of a batch comes from one query
+func listKeyArrays(keys []listKey) (interface{}, interface{}) {
+	ids := make([]int64, len(keys))
+	limits := make([]int64, len(keys))
+	for i, k := range keys {
+		ids[i], limits[i] = int64(k.id), int64(k.first)
+	}
+	return pq.Array(ids), pq.Array(limits)
+}
+
+// fetchGQLProjectLists runs inner, a query over projects p filtered by
+// k.id, for every key at once
+func fetchGQLProjectLists(ctx context.Context, keys []listKey, inner string) (map[listKey][]*models.Project, error) {
+	ids, limits := listKeyArrays(keys)
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT k.id, k.lim, l.*
+		FROM unnest($1::INTEGER[], $2::INTEGER[]) AS k(id, lim)
+		CROSS JOIN LATERAL (`+inner+` LIMIT k.lim) l`, ids, limits)
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	found := map[listKey][]*models.Project{}
+	var all []*models.Project
+	for rows.Next() {
+		var k listKey
+		p, err := scanGQLProject(rows, &k.id, &k.first)
+		if err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[k] = append(found[k], p)
+		all = append(all, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	fillProjectImages(ctx, all...)
+	return found, nil
+}
+
+// fetchGQLUserLists is fetchGQLProjectLists for lists of users u
+func fetchGQLUserLists(ctx context.Context, keys []listKey, inner string) (map[listKey][]*models.User, error) {
+	ids, limits := listKeyArrays(keys)
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT k.id, k.lim, l.*
+		FROM unnest($1::INTEGER[], $2::INTEGER[]) AS k(id, lim)
+		CROSS JOIN LATERAL (`+inner+` LIMIT k.lim) l`, ids, limits)
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	found := map[listKey][]*models.User{}
+	var all []*models.User
+	for rows.Next() {
+		var k listKey
+		u, err := scanGQLUser(rows, &k.id, &k.first)
+		if err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[k] = append(found[k], u)
+		all = append(all, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	fillUserImages(ctx, all...)
+	return found, nil
+}
+
+func fetchGQLFollowCounts(ctx context.Context, ids []int) (map[int]followCounts, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT k.id,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = k.id),
+		       (SELECT COUNT(*) FROM followers f WHERE f.follower_id = k.id)
+		FROM unnest($1::INTEGER[]) AS k(id)`, pq.Array(ids))
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	found := map[int]followCounts{}
+	for rows.Next() {
+		var id int
+		var c followCounts
+		if err := rows.Scan(&id, &c.followers, &c.following); err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[id] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	return found, nil
+}
+
+func fetchGQLProjectCounts(ctx context.Context, ids []int) (map[int]projectCounts, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT p.id,
+		       (SELECT COUNT(*) FROM project_likes l WHERE l.project_id = p.id),
+		       `+commentsCountColumn+`,
+		       (SELECT COUNT(*) FROM project_saves s WHERE s.project_id = p.id),
+		       (SELECT COUNT(*) FROM project_stars st WHERE st.project_id = p.id)
+		FROM projects p
+		WHERE p.id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	found := map[int]projectCounts{}
+	for rows.Next() {
+		var id int
+		var c projectCounts
+		if err := rows.Scan(&id, &c.likes, &c.comments, &c.saves, &c.stars); err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[id] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	return found, nil
+}
+
+func fetchGQLViewerUsers(ctx context.Context, viewerID int, ids []int) (map[int]viewerUserState, error) {
+	found := map[int]viewerUserState{}
+	if viewerID == 0 {
+		return found, nil
+	}
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT k.id,
+		       EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = $2 AND f.following_id = k.id),
+		       EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = k.id AND f.following_id = $2)
+		FROM unnest($1::INTEGER[]) AS k(id)`, pq.Array(ids), viewerID)
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var s viewerUserState
+		if err := rows.Scan(&id, &s.follows, &s.followedBy); err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[id] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	return found, nil
+}
+
+func fetchGQLViewerProjects(ctx context.Context, viewerID int, ids []int) (map[int]viewerProjectState, error) {
+	found := map[int]viewerProjectState{}
+	if viewerID == 0 {
+		return found, nil
+	}
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT k.id,
+		       EXISTS(SELECT 1 FROM project_saves s WHERE s.project_id = k.id AND s.user_id = $2),
+		       EXISTS(SELECT 1 FROM project_stars st WHERE st.project_id = k.id AND st.user_id = $2)
+		FROM unnest($1::INTEGER[]) AS k(id)`, pq.Array(ids), viewerID)
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var s viewerProjectState
+		if err := rows.Scan(&id, &s.saved, &s.starred); err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		found[id] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	return found, nil
+}
+
+// Resolvers
+
+type gqlQuery struct{}
+
+func (gqlQuery) Me(ctx context.Context) (*gqlUser, error) {
+	gq := gqlFromContext(ctx)
+	if gq.viewerID == 0 {
+		return nil, nil
+	}
+	u, err := gq.loaders.users.load(ctx, gq.viewerID)
+	return resolveUser(ctx, u, err)
+}
+
+func (gqlQuery) User(ctx context.Context, args struct{ Username string }) (*gqlUser, error) {
+	u, err := gqlFromContext(ctx).loaders.usersByName.load(ctx, args.Username)
+	return resolveUser(ctx, u, err)
+}
+
+func (gqlQuery) Project(ctx context.Context, args struct{ ID graphql.ID }) (*gqlProject, error) {
+	id, err := strconv.Atoi(string(args.ID))
+	if err != nil {
+		return nil, nil
+	}
+	p, err := gqlFromContext(ctx).loaders.projects.load(ctx, id)
+	return resolveProject(ctx, p, err)
+}
+
+func (gqlQuery) Trending(ctx context.Context, args struct {
+	First int32
+	Tag   *string
+}) ([]*gqlProject, error) {
+	query := `
+		SELECT ` + gqlProjectColumns + `
+		FROM project_trending t
+		JOIN projects p ON p.id = t.project_id`
+	queryArgs := []interface{}{clampFirst(args.First)}
+	if args.Tag != nil && *args.Tag != "" {
+		name, err := canonicalTagName(ctx, *args.Tag)
+		if err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		query += `
+		WHERE p.general_tags @> ARRAY[$2::TEXT] OR p.programming_tags @> ARRAY[$2::TEXT]`
+		queryArgs = append(queryArgs, name)
+	}
+	query += `
+		ORDER BY t.score DESC, p.id DESC
+		LIMIT $1`
+
+	rows, err := database.DB.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	defer rows.Close()
+
+	var projects []*models.Project
+	for rows.Next() {
+		p, err := scanGQLProject(rows)
+		if err != nil {
+			return nil, gqlError(ctx, err)
+		}
+		projects = append(projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, gqlError(ctx, err)
+	}
+	fillProjectImages(ctx, projects...)
+	return resolveProjects(ctx, projects, nil)
+}
+
+func resolveUser(ctx context.Context, u *models.User, err error) (*gqlUser, error) {
+	if err != nil || u == nil {
+		return nil, err
+	}
+	if err := gqlFromContext(ctx).charge(1); err != nil {
+		return nil, err
+	}
+	return &gqlUser{u}, nil
+}
+
+func resolveUsers(ctx context.Context, users []*models.User, err error) ([]*gqlUser, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := gqlFromContext(ctx).charge(len(users)); err != nil {
+		return nil, err
+	}
+	resolved := make([]*gqlUser, len(users))
+	for i, u := range users {
+		resolved[i] = &gqlUser{u}
+	}
+	return resolved, nil
+}
+
+func resolveProject(ctx context.Context, p *models.Project, err error) (*gqlProject, error) {
+	if err != nil || p == nil {
+		return nil, err
+	}
+	if err := gqlFromContext(ctx).charge(1); err != nil {
+		return nil, err
+	}
+	return &gqlProject{p}, nil
+}
+
+func resolveProjects(ctx context.Context, projects []*models.Project, err error) ([]*gqlProject, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := gqlFromContext(ctx).charge(len(projects)); err != nil {
+		return nil, err
+	}
+	resolved := make([]*gqlProject, len(projects))
+	for i, p := range projects {
+		resolved[i] = &gqlProject{p}
+	}
+	return resolved, nil
+}
+
+type firstArgs struct {
+	First int32
+}
+
+type gqlUser struct {
+	u *models.User
+}
+
+func (r *gqlUser) ID() graphql.ID          { return graphql.ID(strconv.Itoa(r.u.ID)) }
+func (r *gqlUser) Username() string        { return r.u.Username }
+func (r *gqlUser) FirstName() *string      { return r.u.FirstName }
+func (r *gqlUser) LastName() *string       { return r.u.LastName }
+func (r *gqlUser) UserType() *string       { return r.u.UserType }
+func (r *gqlUser) Bio() *string            { return r.u.Bio }
+func (r *gqlUser) ProfilePicture() *string { return r.u.ProfilePicture }
+func (r *gqlUser) Banner() *string         { return r.u.Banner }
+func (r *gqlUser) GithubLink() *string     { return r.u.GithubLink }
+func (r *gqlUser) PortfolioLink() *string  { return r.u.PortfolioLink }
+func (r *gqlUser) LinkedinLink() *string   { return r.u.LinkedinLink }
+func (r *gqlUser) CompanyName() *string    { return r.u.CompanyName }
+func (r *gqlUser) CreatedAt() graphql.Time { return graphql.Time{Time: r.u.CreatedAt} }
+func (r *gqlUser) isViewer(ctx context.Context) bool {
+	return gqlFromContext(ctx).viewerID == r.u.ID
+}
+
+func (r *gqlUser) Email(ctx context.Context) *string {
+	if !r.isViewer(ctx) {
+		return nil
+	}
+	return &r.u.Email
+}
+
+func (r *gqlUser) FollowersCount(ctx context.Context) (int32, error) {
+	c, err := gqlFromContext(ctx).loaders.followCounts.load(ctx, r.u.ID)
+	return c.followers, err
+}
+
+func (r *gqlUser) FollowingCount(ctx context.Context) (int32, error) {
+	c, err := gqlFromContext(ctx).loaders.followCounts.load(ctx, r.u.ID)
+	return c.following, err
+}
+
+func (r *gqlUser) Followers(ctx context.Context, args firstArgs) ([]*gqlUser, error) {
+	users, err := gqlFromContext(ctx).loaders.followers.load(ctx, listKey{r.u.ID, clampFirst(args.First)})
+	return resolveUsers(ctx, users, err)
+}
+
+func (r *gqlUser) Following(ctx context.Context, args firstArgs) ([]*gqlUser, error) {
+	users, err := gqlFromContext(ctx).loaders.following.load(ctx, listKey{r.u.ID, clampFirst(args.First)})
+	return resolveUsers(ctx, users, err)
+}
+
+func (r *gqlUser) ViewerFollows(ctx context.Context) (bool, error) {
+	s, err := gqlFromContext(ctx).loaders.viewerUsers.load(ctx, r.u.ID)
+	return s.follows, err
+}
+
+func (r *gqlUser) FollowsViewer(ctx context.Context) (bool, error) {
+	s, err := gqlFromContext(ctx).loaders.viewerUsers.load(ctx, r.u.ID)
+	return s.followedBy, err
+}
+
+func (r *gqlUser) Projects(ctx context.Context, args firstArgs) ([]*gqlProject, error) {
+	projects, err := gqlFromContext(ctx).loaders.userProjects.load(ctx, listKey{r.u.ID, clampFirst(args.First)})
+	return resolveProjects(ctx, projects, err)
+}
+
+func (r *gqlUser) Saved(ctx context.Context, args firstArgs) (*[]*gqlProject, error) {
+	if !r.isViewer(ctx) {
+		return nil, nil
+	}
+	projects, err := gqlFromContext(ctx).loaders.userSaved.load(ctx, listKey{r.u.ID, clampFirst(args.First)})
+	saved, err := resolveProjects(ctx, projects, err)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (r *gqlUser) Starred(ctx context.Context, args firstArgs) ([]*gqlProject, error) {
+	projects, err := gqlFromContext(ctx).loaders.userStarred.load(ctx, listKey{r.u.ID, clampFirst(args.First)})
+	return resolveProjects(ctx, projects, err)
+}
+
+type gqlProject struct {
+	p *models.Project
+}
+
+func (r *gqlProject) ID() graphql.ID            { return graphql.ID(strconv.Itoa(r.p.ID)) }
+func (r *gqlProject) Name() string              { return r.p.Name }
+func (r *gqlProject) Description() string       { return r.p.Description }
+func (r *gqlProject) Code() string              { return r.p.Code }
+func (r *gqlProject) Status() string            { return r.p.Status }
+func (r *gqlProject) GeneralTags() []string     { return r.p.GeneralTags }
+func (r *gqlProject) ProgrammingTags() []string { return r.p.ProgrammingTags }
+func (r *gqlProject) Images() []string          { return r.p.Images }
+func (r *gqlProject) CreatedAt() graphql.Time   { return graphql.Time{Time: r.p.CreatedAt} }
+
+func (r *gqlProject) CoverImage() *string {
+	if r.p.CoverImage == "" {
+		return nil
+	}
+	return &r.p.CoverImage
+}
+
+func (r *gqlProject) Developer(ctx context.Context) (*gqlUser, error) {
+	u, err := gqlFromContext(ctx).loaders.users.load(ctx, r.p.UserID)
+	if err == nil && u == nil {
+		err = errGQLInternal
+	}
+	return resolveUser(ctx, u, err)
+}
+
+func (r *gqlProject) LikesCount(ctx context.Context) (int32, error) {
+	c, err := gqlFromContext(ctx).loaders.projectCounts.load(ctx, r.p.ID)
+	return c.likes, err
+}
+
+func (r *gqlProject) CommentsCount(ctx context.Context) (int32, error) {
+	c, err := gqlFromContext(ctx).loaders.projectCounts.load(ctx, r.p.ID)
+	return c.comments, err
+}
+
+func (r *gqlProject) SavesCount(ctx context.Context) (int32, error) {
+	c, err := gqlFromContext(ctx).loaders.projectCounts.load(ctx, r.p.ID)
+	return c.saves, err
+}
+
+func (r *gqlProject) StarsCount(ctx context.Context) (int32, error) {
+	c, err := gqlFromContext(ctx).loaders.projectCounts.load(ctx, r.p.ID)
+	return c.stars, err
+}
+
+func (r *gqlProject) ViewerSaved(ctx context.Context) (bool, error) {
+	s, err := gqlFromContext(ctx).loaders.viewerProject.load(ctx, r.p.ID)
+	return s.saved, err
+}
+
+func (r *gqlProject) ViewerStarred(ctx context.Context) (bool, error) {
+	s, err := gqlFromContext(ctx).loaders.viewerProject.load(ctx, r.p.ID)
+	return s.starred, err
+}
+
+func (r *gqlProject) Stargazers(ctx context.Context, args firstArgs) ([]*gqlUser, error) {
+	users, err := gqlFromContext(ctx).loaders.stargazers.load(ctx, listKey{r.p.ID, clampFirst(args.First)})
+	return resolveUsers(ctx, users, err)
+}
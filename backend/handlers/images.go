@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/imaging"
+	"auth-app-backend/models"
+	"auth-app-backend/storage"
+	"context"
+	"io"
+
+	"github.com/lib/pq"
+)
+
+// storeUpload reads an uploaded file and passes it to storeImage. Reading
+// stops once the size limit is passed instead of buffering the whole body.
+func storeUpload(ctx context.Context, r io.Reader) (models.ImageVariants, error) {
+	data, err := io.ReadAll(io.LimitReader(r, imaging.MaxFileSize+1))
+	if err != nil {
+		return models.ImageVariants{}, err
+	}
+	if len(data) > imaging.MaxFileSize {
+		return models.ImageVariants{}, imaging.ErrTooLarge
+	}
+	return storeImage(ctx, data)
+}
+
+// storeImage runs an upload through the image pipeline, stores every variant
+// and records them against the original's URL. Validation failures wrap
+// imaging.ErrInvalid so callers can report them to the client.
+func storeImage(ctx context.Context, data []byte) (models.ImageVariants, error) {
+	processed, err := imaging.Process(data)
+	if err != nil {
+		return models.ImageVariants{}, err
+	}
+
+	urls := make([]string, 3)
+	for i, v := range []imaging.Variant{processed.Original, processed.Medium, processed.Thumbnail} {
+		urls[i], err = storage.Save(ctx, v.Data)
+		if err != nil {
+			return models.ImageVariants{}, err
+		}
+	}
+
+	variants := models.ImageVariants{
+		Original:  urls[0],
+		Medium:    urls[1],
+		Thumbnail: urls[2],
+		Width:     processed.Original.Width,
+		Height:    processed.Original.Height,
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		INSERT INTO image_variants (url, medium_url, thumbnail_url, width, height)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (url) DO NOTHING`,
+		variants.Original, variants.Medium, variants.Thumbnail, variants.Width, variants.Height,
+	)
+	if err != nil {
+		return models.ImageVariants{}, err
+	}
+
+	return variants, nil
+}
+
+// loadImageVariants looks up the variants recorded for the given URLs
+func loadImageVariants(ctx context.Context, urls []string) map[string]models.ImageVariants {
+	found := map[string]models.ImageVariants{}
+	if len(urls) == 0 {
+		return found
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT url, medium_url, thumbnail_url, width, height
+		FROM image_variants
+		WHERE url = ANY($1)`, pq.Array(urls))
+	if err != nil {
+		logError(ctx, "Failed to load image variants", err)
+		return found
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.ImageVariants
+		if err := rows.Scan(&v.Original, &v.Medium, &v.Thumbnail, &v.Width, &v.Height); err != nil {
+			logError(ctx, "Skipping unreadable image variants", err)
+			continue
+		}
+		found[v.Original] = v
+	}
+	return found
+}
+
+// fillUserImages sets the variant fields of each user. Images that never
+// went through the pipeline are left without variants.
+func fillUserImages(ctx context.Context, users ...*models.User) {
+	urls := []string{}
+	for _, u := range users {
+		if u.ProfilePicture != nil {
+			urls = append(urls, *u.ProfilePicture)
+		}
+		if u.Banner != nil {
+			urls = append(urls, *u.Banner)
+		}
+	}
+
+	found := loadImageVariants(ctx, urls)
+	for _, u := range users {
+		if u.ProfilePicture != nil {
+			if v, ok := found[*u.ProfilePicture]; ok {
+				u.ProfilePictureVariants = &v
+			}
+		}
+		if u.Banner != nil {
+			if v, ok := found[*u.Banner]; ok {
+				u.BannerVariants = &v
+			}
+		}
+	}
+}
+
+// fillProjectImages sets ImageVariants on each project, one entry per image,
+// its cover image and the variants of each project's developer
+func fillProjectImages(ctx context.Context, projects ...*models.Project) {
+	urls := []string{}
+	ids := []int64{}
+	developers := []*models.User{}
+	for _, p := range projects {
+		urls = append(urls, p.Images...)
+		ids = append(ids, int64(p.ID))
+		if p.Developer != nil {
+			developers = append(developers, p.Developer)
+		}
+	}
+
+	covers := map[int]string{}
+	if len(ids) > 0 {
+		rows, err := database.DB.QueryContext(ctx,
+			"SELECT id, cover_image FROM projects WHERE id = ANY($1) AND cover_image IS NOT NULL", pq.Array(ids),
+		)
+		if err == nil {
+			for rows.Next() {
+				var id int
+				var cover string
+				if rows.Scan(&id, &cover) == nil {
+					covers[id] = cover
+				}
+			}
+			rows.Close()
+		}
+	}
+
+	found := loadImageVariants(ctx, urls)
+	for _, p := range projects {
+		p.ImageVariants = make([]models.ImageVariants, len(p.Images))
+		for i, url := range p.Images {
+			if v, ok := found[url]; ok {
+				p.ImageVariants[i] = v
+			} else {
+				p.ImageVariants[i] = models.ImageVariants{Original: url}
+			}
+		}
+		p.CoverImage = coverImage(p.Images, covers[p.ID])
+	}
+
+	fillUserImages(ctx, developers...)
+}
+
+// coverImage returns the designated cover if it is still one of the
+// project's images, otherwise the first image
+func coverImage(images []string, cover string) string {
+	for _, img := range images {
+		if img == cover {
+			return cover
+		}
+	}
+	if len(images) > 0 {
+		return images[0]
+	}
+	return ""
+}
+
+// projectPointers adapts a slice of projects for fillProjectImages
+func projectPointers(projects []models.Project) []*models.Project {
+	ptrs := make([]*models.Project, len(projects))
+	for i := range projects {
+		ptrs[i] = &projects[i]
+	}
+	return ptrs
+}
@@ -0,0 +1,703 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Deal pipeline stages
+const (
+	dealInterested = "interested"
+	dealInTalks    = "in_talks"
+	dealCommitted  = "committed"
+	dealPassed     = "passed"
+)
+
+// dealTransitions lists the stages each pipeline stage can move to. Passed
+// deals can be picked up again from the start.
+var dealTransitions = map[string][]string{
+	dealInterested: {dealInTalks, dealPassed},
+	dealInTalks:    {dealInterested, dealCommitted, dealPassed},
+	dealCommitted:  {dealInTalks, dealPassed},
+	dealPassed:     {dealInterested},
+}
+
+// investmentStages are the company stages an investor can focus on
+var investmentStages = map[string]bool{
+	"idea": true, "pre_seed": true, "seed": true, "series_a": true, "series_b_plus": true,
+}
+
+const (
+	notifyInvestmentInterest = "investment_interest"
+	notifyInvestmentStage    = "investment_stage_changed"
+
+	maxInvestorThesisLength = 2000
+	maxDealMessageLength    = 2000
+	maxDealNoteLength       = 5000
+	maxInvestorSectors      = 20
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// investorColumns is shared by every query that builds a models.InvestorProfile
+const investorColumns = `
+	ip.ticket_min, ip.ticket_max, ip.currency, ip.sectors, ip.stages, ip.thesis, ip.updated_at,
+	u.id, u.username, u.first_name, u.last_name, u.profile_picture, u.company_name, u.linkedin_link`
+
+func scanInvestorProfile(rows interface{ Scan(...interface{}) error }) (models.InvestorProfile, error) {
+	var p models.InvestorProfile
+	var user models.User
+	var ticketMin, ticketMax sql.NullInt64
+
+	err := rows.Scan(
+		&ticketMin, &ticketMax, &p.Currency, pq.Array(&p.Sectors), pq.Array(&p.Stages), &p.Thesis, &p.UpdatedAt,
+		&user.ID, &user.Username, &user.FirstName, &user.LastName, &user.ProfilePicture, &user.CompanyName, &user.LinkedinLink,
+	)
+	if err != nil {
+		return p, err
+	}
+
+	if ticketMin.Valid {
+		p.TicketMin = &ticketMin.Int64
+	}
+	if ticketMax.Valid {
+		p.TicketMax = &ticketMax.Int64
+	}
+	if p.Sectors == nil {
+		p.Sectors = []string{}
+	}
+	if p.Stages == nil {
+		p.Stages = []string{}
+	}
+	p.User = &user
+	return p, nil
+}
+
+func writeInvestorProfile(w http.ResponseWriter, r *http.Request, where string, arg interface{}) {
+	row := database.DB.QueryRowContext(r.Context(), `SELECT `+investorColumns+`
+        FROM investor_profiles ip
+        JOIN users u ON u.id = ip.user_id
+        WHERE `+where, arg)
+	p, err := scanInvestorProfile(row)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Investor profile not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	fillUserImages(r.Context(), p.User)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// GetInvestorProfile returns the public investor profile of {username}
+func GetInvestorProfile(w http.ResponseWriter, r *http.Request) {
+	writeInvestorProfile(w, r, "u.username = $1", mux.Vars(r)["username"])
+}
+
+// HandleMyInvestorProfile returns (GET) or creates and updates (PUT) the
+// caller's investor profile. Only entrepreneur accounts can invest.
+func HandleMyInvestorProfile(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeInvestorProfile(w, r, "ip.user_id = $1", userID)
+
+	case http.MethodPut:
+		var userType sql.NullString
+		if err := database.DB.QueryRowContext(r.Context(), "SELECT user_type FROM users WHERE id = $1", userID).Scan(&userType); err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		if userType.String != "entrepreneur" {
+			http.Error(w, "Only entrepreneur/investor accounts can have an investor profile", http.StatusForbidden)
+			return
+		}
+
+		var req models.InvestorProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if msg := validateInvestorProfile(&req); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		_, err := database.DB.ExecContext(r.Context(), `
+            INSERT INTO investor_profiles (user_id, ticket_min, ticket_max, currency, sectors, stages, thesis, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+            ON CONFLICT (user_id) DO UPDATE SET
+                ticket_min = EXCLUDED.ticket_min, ticket_max = EXCLUDED.ticket_max, currency = EXCLUDED.currency,
+                sectors = EXCLUDED.sectors, stages = EXCLUDED.stages, thesis = EXCLUDED.thesis,
+                updated_at = EXCLUDED.updated_at
+        `, userID, req.TicketMin, req.TicketMax, req.Currency, pq.Array(req.Sectors), pq.Array(req.Stages), req.Thesis)
+		if err != nil {
+			serverError(w, r, "Failed to save investor profile", err)
+			return
+		}
+		writeInvestorProfile(w, r, "ip.user_id = $1", userID)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validateInvestorProfile normalises req in place and returns a message
+// describing the first problem, if any
+func validateInvestorProfile(req *models.InvestorProfileRequest) string {
+	if req.Currency == "" {
+		req.Currency = "USD"
+	}
+	req.Currency = strings.ToUpper(req.Currency)
+	if !currencyPattern.MatchString(req.Currency) {
+		return "currency must be a three-letter code"
+	}
+	if req.TicketMin != nil && *req.TicketMin < 0 || req.TicketMax != nil && *req.TicketMax < 0 {
+		return "Ticket sizes can't be negative"
+	}
+	if req.TicketMin != nil && req.TicketMax != nil && *req.TicketMin > *req.TicketMax {
+		return "ticket_min can't be above ticket_max"
+	}
+
+	sectors := []string{}
+	seen := map[string]bool{}
+	for _, s := range req.Sectors {
+		s = strings.TrimSpace(s)
+		if s != "" && !seen[strings.ToLower(s)] {
+			seen[strings.ToLower(s)] = true
+			sectors = append(sectors, s)
+		}
+	}
+	if len(sectors) > maxInvestorSectors {
+		return "Too many sectors"
+	}
+	req.Sectors = sectors
+
+	stages := []string{}
+	for _, s := range req.Stages {
+		if !investmentStages[s] {
+			return "Unknown stage " + s
+		}
+		stages = append(stages, s)
+	}
+	req.Stages = stages
+
+	req.Thesis = strings.TrimSpace(req.Thesis)
+	if len([]rune(req.Thesis)) > maxInvestorThesisLength {
+		return "Thesis is too long"
+	}
+	return ""
+}
+
+// dealColumns is shared by every query that builds a models.InvestmentInterest
+const dealColumns = `
+	d.id, d.stage, d.amount, d.message, d.created_at, d.updated_at,
+	p.id, p.name, p.code, p.status`
+
+// dealNotesColumn follows dealColumns on the investor's side, counting the
+// notes investor $1 wrote on the deal
+const dealNotesColumn = `(SELECT COUNT(*) FROM deal_notes n WHERE n.deal_id = d.id AND n.author_id = $1)`
+
+// dealInvestorColumns follows dealColumns on the project's side, where the
+// investor's notes stay private
+const dealInvestorColumns = `
+	u.id, u.username, u.first_name, u.last_name, u.profile_picture, u.company_name, u.linkedin_link,
+	ip.user_id IS NOT NULL, ip.ticket_min, ip.ticket_max, ip.currency, ip.sectors, ip.stages, ip.thesis`
+
+// scanDeal reads dealColumns followed by dealInvestorColumns when
+// withInvestor is set, or by dealNotesColumn otherwise
+func scanDeal(rows interface{ Scan(...interface{}) error }, withInvestor bool) (models.InvestmentInterest, error) {
+	var d models.InvestmentInterest
+	var project models.Project
+	var amount sql.NullInt64
+
+	dest := []interface{}{
+		&d.ID, &d.Stage, &amount, &d.Message, &d.CreatedAt, &d.UpdatedAt,
+		&project.ID, &project.Name, &project.Code, &project.Status,
+	}
+	var profile models.InvestorProfile
+	var hasProfile bool
+	var ticketMin, ticketMax sql.NullInt64
+	var currency, thesis sql.NullString
+	var user models.User
+	if withInvestor {
+		dest = append(dest,
+			&user.ID, &user.Username, &user.FirstName, &user.LastName, &user.ProfilePicture, &user.CompanyName, &user.LinkedinLink,
+			&hasProfile, &ticketMin, &ticketMax, &currency, pq.Array(&profile.Sectors), pq.Array(&profile.Stages), &thesis,
+		)
+	} else {
+		dest = append(dest, &d.NotesCount)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return d, err
+	}
+
+	if amount.Valid {
+		d.Amount = &amount.Int64
+	}
+	d.Project = &project
+	if withInvestor {
+		d.Investor = &user
+		if hasProfile {
+			if ticketMin.Valid {
+				profile.TicketMin = &ticketMin.Int64
+			}
+			if ticketMax.Valid {
+				profile.TicketMax = &ticketMax.Int64
+			}
+			profile.Currency = currency.String
+			profile.Thesis = thesis.String
+			if profile.Sectors == nil {
+				profile.Sectors = []string{}
+			}
+			if profile.Stages == nil {
+				profile.Stages = []string{}
+			}
+			d.InvestorProfile = &profile
+		}
+	}
+	return d, nil
+}
+
+// ExpressInterest records the caller's interest in investing in project
+// {id}, or picks a passed deal back up. The project's owner and
+// maintainers are notified.
+func ExpressInterest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	projectID, ok := projectExists(w, r)
+	if !ok {
+		return
+	}
+
+	var hasProfile bool
+	err := database.DB.QueryRowContext(r.Context(), "SELECT EXISTS(SELECT 1 FROM investor_profiles WHERE user_id = $1)", userID).Scan(&hasProfile)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if !hasProfile {
+		http.Error(w, "Create an investor profile first", http.StatusForbidden)
+		return
+	}
+
+	role, err := projectRole(r.Context(), projectID, userID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if hasRole(role, roleDeveloper) {
+		http.Error(w, "You can't invest in a project you work on", http.StatusConflict)
+		return
+	}
+
+	var req models.InterestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if len([]rune(req.Message)) > maxDealMessageLength {
+		http.Error(w, "Message is too long", http.StatusBadRequest)
+		return
+	}
+	if req.Amount != nil && *req.Amount <= 0 {
+		http.Error(w, "Amount must be positive", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var dealID int
+	var projectName string
+	err = tx.QueryRowContext(r.Context(), `
+        INSERT INTO investment_interests (project_id, investor_id, amount, message)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (project_id, investor_id) DO UPDATE SET
+            stage = 'interested', amount = EXCLUDED.amount, message = EXCLUDED.message, updated_at = CURRENT_TIMESTAMP
+        WHERE investment_interests.stage = 'passed'
+        RETURNING id, (SELECT name FROM projects WHERE id = $1)
+    `, projectID, userID, req.Amount, req.Message).Scan(&dealID, &projectName)
+	if err == sql.ErrNoRows {
+		http.Error(w, "You already expressed interest in this project", http.StatusConflict)
+		return
+	} else if err != nil {
+		serverError(w, r, "Failed to express interest", err)
+		return
+	}
+
+	managers, err := projectManagers(r.Context(), projectID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	err = notify(r.Context(), tx, managers, models.Notification{
+		Type:    notifyInvestmentInterest,
+		Actor:   &models.User{ID: userID},
+		Project: &models.Project{ID: projectID},
+		Message: "An investor is interested in " + projectName,
+	})
+	if err != nil {
+		serverError(w, r, "Failed to express interest", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to express interest", err)
+		return
+	}
+	writeDeal(w, r, dealID, userID, http.StatusCreated)
+}
+
+// WithdrawInterest removes the caller's deal on project {id} along with
+// its notes
+func WithdrawInterest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	projectID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := database.DB.ExecContext(r.Context(),
+		"DELETE FROM investment_interests WHERE project_id = $1 AND investor_id = $2", projectID, userID,
+	)
+	if err != nil {
+		serverError(w, r, "Failed to withdraw interest", err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "Interest not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPipeline lists the caller's deals, most recently updated first,
+// filtered by ?stage= if given
+func GetPipeline(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := `SELECT ` + dealColumns + `, ` + dealNotesColumn + `
+        FROM investment_interests d
+        JOIN projects p ON p.id = d.project_id
+        WHERE d.investor_id = $1`
+	args := []interface{}{userID}
+	if stage := r.URL.Query().Get("stage"); stage != "" {
+		if _, ok := dealTransitions[stage]; !ok {
+			http.Error(w, "Invalid stage", http.StatusBadRequest)
+			return
+		}
+		query += ` AND d.stage = $2`
+		args = append(args, stage)
+	}
+	query += ` ORDER BY d.updated_at DESC, d.id DESC`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	deals := []models.InvestmentInterest{}
+	for rows.Next() {
+		d, err := scanDeal(rows, false)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		deals = append(deals, d)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deals)
+}
+
+// requireDealInvestor checks that deal {id} belongs to the caller
+func requireDealInvestor(w http.ResponseWriter, r *http.Request) (dealID, userID int, ok bool) {
+	userID = getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	dealID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid deal ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	var investorID int
+	err = database.DB.QueryRowContext(r.Context(), "SELECT investor_id FROM investment_interests WHERE id = $1", dealID).Scan(&investorID)
+	if err == sql.ErrNoRows || (err == nil && investorID != userID) {
+		http.Error(w, "Deal not found", http.StatusNotFound)
+		return 0, 0, false
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return 0, 0, false
+	}
+	return dealID, userID, true
+}
+
+// UpdateDeal moves deal {id} along the pipeline and/or changes the amount.
+// The project side is told about stage changes.
+func UpdateDeal(w http.ResponseWriter, r *http.Request) {
+	dealID, userID, ok := requireDealInvestor(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.DealUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Amount != nil && *req.Amount <= 0 {
+		http.Error(w, "Amount must be positive", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var stage, projectName string
+	var projectID int
+	var amount sql.NullInt64
+	err = tx.QueryRowContext(r.Context(), `
+        SELECT d.stage, d.amount, d.project_id, p.name
+        FROM investment_interests d JOIN projects p ON p.id = d.project_id
+        WHERE d.id = $1 FOR UPDATE OF d
+    `, dealID).Scan(&stage, &amount, &projectID, &projectName)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	newStage := stage
+	if req.Stage != nil && *req.Stage != stage {
+		allowed := false
+		for _, s := range dealTransitions[stage] {
+			allowed = allowed || s == *req.Stage
+		}
+		if !allowed {
+			if _, known := dealTransitions[*req.Stage]; !known {
+				http.Error(w, "Invalid stage", http.StatusBadRequest)
+			} else {
+				http.Error(w, "Cannot move a deal from "+stage+" to "+*req.Stage, http.StatusConflict)
+			}
+			return
+		}
+		newStage = *req.Stage
+	}
+	if req.Amount != nil {
+		amount = sql.NullInt64{Int64: *req.Amount, Valid: true}
+	}
+
+	_, err = tx.ExecContext(r.Context(),
+		"UPDATE investment_interests SET stage = $2, amount = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+		dealID, newStage, amount,
+	)
+	if err != nil {
+		serverError(w, r, "Failed to update deal", err)
+		return
+	}
+
+	if newStage != stage {
+		managers, err := projectManagers(r.Context(), projectID)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		err = notify(r.Context(), tx, managers, models.Notification{
+			Type:    notifyInvestmentStage,
+			Actor:   &models.User{ID: userID},
+			Project: &models.Project{ID: projectID},
+			Message: "An investor moved " + projectName + " to " + strings.ReplaceAll(newStage, "_", " "),
+		})
+		if err != nil {
+			serverError(w, r, "Failed to update deal", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to update deal", err)
+		return
+	}
+	writeDeal(w, r, dealID, userID, http.StatusOK)
+}
+
+func writeDeal(w http.ResponseWriter, r *http.Request, dealID, userID, status int) {
+	row := database.DB.QueryRowContext(r.Context(), `SELECT `+dealColumns+`, `+dealNotesColumn+`
+        FROM investment_interests d
+        JOIN projects p ON p.id = d.project_id
+        WHERE d.id = $2`, userID, dealID)
+	d, err := scanDeal(row, false)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(d)
+}
+
+// HandleDealNotes lists (GET) or adds to (POST) the caller's private notes
+// on deal {id}. Nobody else, including the project, can read them.
+func HandleDealNotes(w http.ResponseWriter, r *http.Request) {
+	dealID, userID, ok := requireDealInvestor(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rows, err := database.DB.QueryContext(r.Context(), `
+            SELECT id, body, created_at FROM deal_notes
+            WHERE deal_id = $1 AND author_id = $2
+            ORDER BY created_at DESC, id DESC
+        `, dealID, userID)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		defer rows.Close()
+
+		notes := []models.DealNote{}
+		for rows.Next() {
+			var n models.DealNote
+			if err := rows.Scan(&n.ID, &n.Body, &n.CreatedAt); err != nil {
+				serverError(w, r, "Internal server error", err)
+				return
+			}
+			notes = append(notes, n)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(notes)
+
+	case http.MethodPost:
+		var req models.DealNoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		req.Body = strings.TrimSpace(req.Body)
+		if req.Body == "" {
+			http.Error(w, "Note can't be empty", http.StatusBadRequest)
+			return
+		}
+		if len([]rune(req.Body)) > maxDealNoteLength {
+			http.Error(w, "Note is too long", http.StatusBadRequest)
+			return
+		}
+
+		var n models.DealNote
+		err := database.DB.QueryRowContext(r.Context(), `
+            INSERT INTO deal_notes (deal_id, author_id, body) VALUES ($1, $2, $3)
+            RETURNING id, body, created_at
+        `, dealID, userID, req.Body).Scan(&n.ID, &n.Body, &n.CreatedAt)
+		if err != nil {
+			serverError(w, r, "Failed to save note", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(n)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetProjectInvestors lists the investors interested in project {id} with
+// their profiles, filtered by ?stage= if given. Maintainers and the owner
+// only; investors' notes stay private.
+func GetProjectInvestors(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	query := `SELECT ` + dealColumns + `, ` + dealInvestorColumns + `
+        FROM investment_interests d
+        JOIN projects p ON p.id = d.project_id
+        JOIN users u ON u.id = d.investor_id
+        LEFT JOIN investor_profiles ip ON ip.user_id = d.investor_id
+        WHERE d.project_id = $1`
+	args := []interface{}{projectID}
+	if stage := r.URL.Query().Get("stage"); stage != "" {
+		if _, ok := dealTransitions[stage]; !ok {
+			http.Error(w, "Invalid stage", http.StatusBadRequest)
+			return
+		}
+		query += ` AND d.stage = $2`
+		args = append(args, stage)
+	}
+	query += ` ORDER BY CASE d.stage WHEN 'committed' THEN 0 WHEN 'in_talks' THEN 1 WHEN 'interested' THEN 2 ELSE 3 END,
+               d.updated_at DESC, d.id DESC`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	deals := []models.InvestmentInterest{}
+	for rows.Next() {
+		d, err := scanDeal(rows, true)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		deals = append(deals, d)
+	}
+	rows.Close()
+
+	investors := make([]*models.User, len(deals))
+	for i := range deals {
+		investors[i] = deals[i].Investor
+	}
+	fillUserImages(r.Context(), investors...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deals)
+}
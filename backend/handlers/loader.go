@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// loaderWait is how long a loader collects keys before fetching them.
+// Resolvers of sibling list items run concurrently, so this is enough for
+// a whole list to land in one batch.
+const loaderWait = 2 * time.Millisecond
+
+// batchLoader coalesces concurrent loads into one fetch, DataLoader-style,
+// and caches the results. Loaders live for a single request.
+type batchLoader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	current *loaderBatch[K, V]
+	loaded  map[K]*loaderBatch[K, V]
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys   []K
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{fetch: fetch, loaded: map[K]*loaderBatch[K, V]{}}
+}
+
+// load returns the value for key, or V's zero value if fetch didn't find it
+func (l *batchLoader[K, V]) load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	b, ok := l.loaded[key]
+	if !ok {
+		if l.current == nil {
+			b = &loaderBatch[K, V]{done: make(chan struct{})}
+			l.current = b
+			time.AfterFunc(loaderWait, func() { l.run(ctx, b) })
+		}
+		b = l.current
+		b.keys = append(b.keys, key)
+		l.loaded[key] = b
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.values[key], b.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (l *batchLoader[K, V]) run(ctx context.Context, b *loaderBatch[K, V]) {
+	l.mu.Lock()
+	l.current = nil
+	l.mu.Unlock()
+
+	b.values, b.err = l.fetch(ctx, b.keys)
+	close(b.done)
+}
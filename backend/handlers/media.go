@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"auth-app-backend/storage"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ServeMedia streams an uploaded file from storage. Keys are content
+// addressed, so a key's bytes never change and responses can be cached
+// forever; the hash in the key doubles as the ETag.
+func ServeMedia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, storage.URLPrefix)
+	if !storage.ValidKey(key) {
+		http.NotFound(w, r)
+		return
+	}
+
+	etag := `"` + strings.TrimSuffix(path.Base(key), path.Ext(key)) + `"`
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, info, err := storage.Default.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer body.Close()
+
+	if info.ContentType != "" {
+		w.Header().Set("Content-Type", info.ContentType)
+	}
+	if info.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Uploads are only ever images; should anything else have been stored,
+	// keep it from running script on this origin
+	w.Header().Set("Content-Security-Policy", "sandbox")
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.Copy(w, body)
+}
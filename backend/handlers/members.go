@@ -0,0 +1,579 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Project member roles, from most to least privileged. Observers are
+// investors and other followers of the work who can't change anything.
+const (
+	roleOwner      = "owner"
+	roleMaintainer = "maintainer"
+	roleDeveloper  = "developer"
+	roleObserver   = "observer"
+)
+
+var roleRank = map[string]int{
+	roleOwner:      4,
+	roleMaintainer: 3,
+	roleDeveloper:  2,
+	roleObserver:   1,
+}
+
+// Invitation states
+const (
+	invitationPending   = "pending"
+	invitationAccepted  = "accepted"
+	invitationDeclined  = "declined"
+	invitationCancelled = "cancelled"
+)
+
+// hasRole reports whether role grants at least the permissions of min
+func hasRole(role, min string) bool {
+	return roleRank[role] >= roleRank[min]
+}
+
+// projectRole returns a user's role on a project, or "" if they aren't a
+// member
+func projectRole(ctx context.Context, projectID, userID int) (string, error) {
+	var role string
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT role FROM project_members WHERE project_id = $1 AND user_id = $2", projectID, userID,
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// requireProjectRole checks that the caller has at least role min on
+// project {id}, writing the error response itself when they don't
+func requireProjectRole(w http.ResponseWriter, r *http.Request, min string) (int, bool) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	projectID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return projectID, checkProjectRole(w, r, projectID, userID, min)
+}
+
+// checkProjectRole is requireProjectRole for handlers that find the
+// project some other way
+func checkProjectRole(w http.ResponseWriter, r *http.Request, projectID, userID int, min string) bool {
+	var exists bool
+	var role sql.NullString
+	err := database.DB.QueryRowContext(r.Context(), `
+        SELECT TRUE, m.role
+        FROM projects p
+        LEFT JOIN project_members m ON m.project_id = p.id AND m.user_id = $2
+        WHERE p.id = $1
+    `, projectID, userID).Scan(&exists, &role)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return false
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return false
+	}
+
+	if !hasRole(role.String, min) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// isProjectMember reports whether a user works on a project, which is what
+// it takes to be assigned its milestones. Observers don't count.
+func isProjectMember(ctx context.Context, projectID, userID int) (bool, error) {
+	role, err := projectRole(ctx, projectID, userID)
+	return hasRole(role, roleDeveloper), err
+}
+
+// loadProjectTeam lists a project's members, most privileged first
+func loadProjectTeam(ctx context.Context, projectID int) ([]models.ProjectMember, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+        SELECT u.id, u.username, u.first_name, u.last_name, u.profile_picture, m.role, m.joined_at
+        FROM project_members m
+        JOIN users u ON u.id = m.user_id
+        WHERE m.project_id = $1
+        ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'maintainer' THEN 1 WHEN 'developer' THEN 2 ELSE 3 END,
+                 m.joined_at, u.id
+    `, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	team := []models.ProjectMember{}
+	for rows.Next() {
+		var m models.ProjectMember
+		err := rows.Scan(&m.User.ID, &m.User.Username, &m.User.FirstName, &m.User.LastName, &m.User.ProfilePicture, &m.Role, &m.JoinedAt)
+		if err != nil {
+			return nil, err
+		}
+		team = append(team, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	users := make([]*models.User, len(team))
+	for i := range team {
+		users[i] = &team[i].User
+	}
+	fillUserImages(ctx, users...)
+	return team, nil
+}
+
+// GetProjectMembers lists the team of project {id}
+func GetProjectMembers(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectExists(w, r)
+	if !ok {
+		return
+	}
+
+	team, err := loadProjectTeam(r.Context(), projectID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
+
+// canManageRole reports whether a member with role actor may grant,
+// change or revoke role target. Owners manage everyone else; maintainers
+// manage developers and observers.
+func canManageRole(actor, target string) bool {
+	if target == roleOwner {
+		return false
+	}
+	if actor == roleOwner {
+		return true
+	}
+	return actor == roleMaintainer && roleRank[target] < roleRank[roleMaintainer]
+}
+
+// HandleProjectMember changes the role of (PUT) or removes (DELETE) member
+// {userId} of project {id}. Members can also remove themselves to leave.
+func HandleProjectMember(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+	memberID, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	actorRole, err := projectRole(r.Context(), projectID, userID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	memberRole, err := projectRole(r.Context(), projectID, memberID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if memberRole == "" {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var req models.MemberRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if _, ok := roleRank[req.Role]; !ok {
+			http.Error(w, "Invalid role", http.StatusBadRequest)
+			return
+		}
+		if !canManageRole(actorRole, memberRole) || !canManageRole(actorRole, req.Role) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		_, err := database.DB.ExecContext(r.Context(),
+			"UPDATE project_members SET role = $3 WHERE project_id = $1 AND user_id = $2", projectID, memberID, req.Role,
+		)
+		if err != nil {
+			serverError(w, r, "Failed to update member", err)
+			return
+		}
+		GetProjectMembers(w, r)
+
+	case http.MethodDelete:
+		leaving := memberID == userID
+		if leaving && memberRole == roleOwner {
+			http.Error(w, "The owner can't leave their own project", http.StatusConflict)
+			return
+		}
+		if !leaving && !canManageRole(actorRole, memberRole) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		tx, err := database.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			serverError(w, r, "Failed to remove member", err)
+			return
+		}
+		defer tx.Rollback()
+
+		_, err = tx.ExecContext(r.Context(),
+			"DELETE FROM project_members WHERE project_id = $1 AND user_id = $2", projectID, memberID,
+		)
+		if err != nil {
+			serverError(w, r, "Failed to remove member", err)
+			return
+		}
+		// Their open milestones go back to the pool; finished ones keep who did them
+		_, err = tx.ExecContext(r.Context(),
+			`UPDATE milestones SET assignee_id = NULL
+             WHERE project_id = $1 AND assignee_id = $2 AND state IN ($3, $4)`,
+			projectID, memberID, milestoneTodo, milestoneInProgress,
+		)
+		if err != nil {
+			serverError(w, r, "Failed to remove member", err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			serverError(w, r, "Failed to remove member", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// invitationColumns is shared by every query that builds a models.Invitation
+const invitationColumns = `
+	i.id, i.role, i.status, i.created_at, i.responded_at,
+	p.id, p.name, p.code,
+	iu.id, iu.username, iu.first_name, iu.last_name, iu.profile_picture,
+	tu.id, tu.username, tu.first_name, tu.last_name, tu.profile_picture`
+
+const invitationJoins = `
+	FROM project_invitations i
+	JOIN projects p ON p.id = i.project_id
+	LEFT JOIN users iu ON iu.id = i.inviter_id
+	JOIN users tu ON tu.id = i.invitee_id`
+
+func scanInvitation(rows interface{ Scan(...interface{}) error }) (models.Invitation, error) {
+	var inv models.Invitation
+	var project models.Project
+	var inviter, invitee models.User
+	var inviterID sql.NullInt64
+	var inviterName sql.NullString
+	var respondedAt sql.NullTime
+
+	err := rows.Scan(
+		&inv.ID, &inv.Role, &inv.Status, &inv.CreatedAt, &respondedAt,
+		&project.ID, &project.Name, &project.Code,
+		&inviterID, &inviterName, &inviter.FirstName, &inviter.LastName, &inviter.ProfilePicture,
+		&invitee.ID, &invitee.Username, &invitee.FirstName, &invitee.LastName, &invitee.ProfilePicture,
+	)
+	if err != nil {
+		return inv, err
+	}
+
+	inv.Project = &project
+	inv.Invitee = &invitee
+	if inviterID.Valid {
+		inviter.ID = int(inviterID.Int64)
+		inviter.Username = inviterName.String
+		inv.Inviter = &inviter
+	}
+	if respondedAt.Valid {
+		inv.RespondedAt = &respondedAt.Time
+	}
+	return inv, nil
+}
+
+func queryInvitations(ctx context.Context, where string, args ...interface{}) ([]models.Invitation, error) {
+	rows, err := database.DB.QueryContext(ctx, `SELECT `+invitationColumns+invitationJoins+` WHERE `+where+` ORDER BY i.created_at DESC, i.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+// GetProjectInvitations lists the pending invitations of project {id}.
+// Maintainers and the owner only.
+func GetProjectInvitations(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	invitations, err := queryInvitations(r.Context(), "i.project_id = $1 AND i.status = $2", projectID, invitationPending)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// InviteProjectMember invites a user to project {id} by username
+func InviteProjectMember(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+	userID := getUserIDFromToken(r)
+
+	var req models.InvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimPrefix(strings.TrimSpace(req.Username), "@")
+	if req.Role == "" {
+		req.Role = roleDeveloper
+	}
+	if _, ok := roleRank[req.Role]; !ok {
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return
+	}
+
+	actorRole, err := projectRole(r.Context(), projectID, userID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if !canManageRole(actorRole, req.Role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var inviteeID int
+	err = database.DB.QueryRowContext(r.Context(), "SELECT id FROM users WHERE LOWER(username) = LOWER($1)", req.Username).Scan(&inviteeID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	role, err := projectRole(r.Context(), projectID, inviteeID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if role != "" {
+		http.Error(w, "User is already a member of this project", http.StatusConflict)
+		return
+	}
+
+	// The partial unique index allows one pending invitation per user
+	var invitationID int
+	err = database.DB.QueryRowContext(r.Context(), `
+        INSERT INTO project_invitations (project_id, inviter_id, invitee_id, role)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (project_id, invitee_id) WHERE status = 'pending' DO NOTHING
+        RETURNING id
+    `, projectID, userID, inviteeID, req.Role).Scan(&invitationID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User already has a pending invitation", http.StatusConflict)
+		return
+	} else if err != nil {
+		serverError(w, r, "Failed to create invitation", err)
+		return
+	}
+
+	writeInvitation(w, r, invitationID, http.StatusCreated)
+}
+
+// GetMyInvitations lists the caller's pending invitations
+func GetMyInvitations(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	invitations, err := queryInvitations(r.Context(), "i.invitee_id = $1 AND i.status = $2", userID, invitationPending)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// RespondToInvitation accepts or declines invitation {id}, depending on
+// the {action} in the route. Only the invitee can respond.
+func RespondToInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	invitationID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+	status := invitationAccepted
+	if vars["action"] == "decline" {
+		status = invitationDeclined
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var projectID, inviteeID int
+	var role, current string
+	err = tx.QueryRowContext(r.Context(), `
+        SELECT project_id, invitee_id, role, status
+        FROM project_invitations WHERE id = $1 FOR UPDATE
+    `, invitationID).Scan(&projectID, &inviteeID, &role, &current)
+	if err == sql.ErrNoRows || (err == nil && inviteeID != userID) {
+		// Don't reveal invitations addressed to someone else
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if current != invitationPending {
+		http.Error(w, "Invitation was already "+current, http.StatusConflict)
+		return
+	}
+
+	_, err = tx.ExecContext(r.Context(),
+		"UPDATE project_invitations SET status = $2, responded_at = CURRENT_TIMESTAMP WHERE id = $1", invitationID, status,
+	)
+	if err != nil {
+		serverError(w, r, "Failed to respond to invitation", err)
+		return
+	}
+	if status == invitationAccepted {
+		_, err = tx.ExecContext(r.Context(), `
+            INSERT INTO project_members (project_id, user_id, role)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (project_id, user_id) DO NOTHING
+        `, projectID, userID, role)
+		if err != nil {
+			serverError(w, r, "Failed to respond to invitation", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to respond to invitation", err)
+		return
+	}
+	writeInvitation(w, r, invitationID, http.StatusOK)
+}
+
+// CancelInvitation withdraws pending invitation {id}. Maintainers and the
+// owner of the project only.
+func CancelInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	invitationID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	var projectID int
+	err = database.DB.QueryRowContext(r.Context(), "SELECT project_id FROM project_invitations WHERE id = $1", invitationID).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if !checkProjectRole(w, r, projectID, userID, roleMaintainer) {
+		return
+	}
+
+	result, err := database.DB.ExecContext(r.Context(), `
+        UPDATE project_invitations SET status = $2, responded_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = 'pending'
+    `, invitationID, invitationCancelled)
+	if err != nil {
+		serverError(w, r, "Failed to cancel invitation", err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "Invitation is no longer pending", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeInvitation(w http.ResponseWriter, r *http.Request, invitationID, status int) {
+	invitations, err := queryInvitations(r.Context(), "i.id = $1", invitationID)
+	if err == nil && len(invitations) == 0 {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(invitations[0])
+}
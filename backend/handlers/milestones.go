@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Milestone states
+const (
+	milestoneTodo       = "todo"
+	milestoneInProgress = "in_progress"
+	milestoneDone       = "done"
+	milestoneCancelled  = "cancelled"
+)
+
+// milestoneTransitions lists the states each milestone state can move to
+var milestoneTransitions = map[string][]string{
+	milestoneTodo:       {milestoneInProgress, milestoneDone, milestoneCancelled},
+	milestoneInProgress: {milestoneTodo, milestoneDone, milestoneCancelled},
+	milestoneDone:       {milestoneInProgress},
+	milestoneCancelled:  {milestoneTodo},
+}
+
+const (
+	maxMilestoneTitleLength       = 200
+	maxMilestoneDescriptionLength = 5000
+	dateLayout                    = "2006-01-02"
+)
+
+// milestoneColumns is shared by every query that builds a models.Milestone
+const milestoneColumns = `
+	m.id, m.project_id, m.title, m.description, m.due_date, m.state, m.completed_at, m.created_at, m.updated_at,
+	u.id, u.username, u.first_name, u.last_name, u.profile_picture`
+
+func scanMilestone(rows interface{ Scan(...interface{}) error }) (models.Milestone, error) {
+	var m models.Milestone
+	var dueDate, completedAt sql.NullTime
+	var assigneeID sql.NullInt64
+	var assigneeName sql.NullString
+	var assignee models.User
+
+	err := rows.Scan(
+		&m.ID, &m.ProjectID, &m.Title, &m.Description, &dueDate, &m.State, &completedAt, &m.CreatedAt, &m.UpdatedAt,
+		&assigneeID, &assigneeName, &assignee.FirstName, &assignee.LastName, &assignee.ProfilePicture,
+	)
+	if err != nil {
+		return m, err
+	}
+
+	if dueDate.Valid {
+		d := dueDate.Time.Format(dateLayout)
+		m.DueDate = &d
+		open := m.State == milestoneTodo || m.State == milestoneInProgress
+		m.Overdue = open && d < time.Now().Format(dateLayout)
+	}
+	if completedAt.Valid {
+		m.CompletedAt = &completedAt.Time
+	}
+	if assigneeID.Valid {
+		assignee.ID = int(assigneeID.Int64)
+		assignee.Username = assigneeName.String
+		m.Assignee = &assignee
+	}
+	return m, nil
+}
+
+// loadProjectProgress computes completion for a project without loading
+// its milestones
+func loadProjectProgress(ctx context.Context, projectID int) (*models.Progress, error) {
+	var p models.Progress
+	err := database.DB.QueryRowContext(ctx, `
+        SELECT COUNT(*) FILTER (WHERE state <> 'cancelled'), COUNT(*) FILTER (WHERE state = 'done')
+        FROM milestones WHERE project_id = $1
+    `, projectID).Scan(&p.Total, &p.Done)
+	if err != nil {
+		return nil, err
+	}
+	if p.Total > 0 {
+		p.Percent = p.Done * 100 / p.Total
+	}
+	return &p, nil
+}
+
+// GetProjectMilestones lists the milestones of project {id} by due date,
+// undated ones last, with the project's progress
+func GetProjectMilestones(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectExists(w, r)
+	if !ok {
+		return
+	}
+
+	query := `SELECT ` + milestoneColumns + `
+        FROM milestones m
+        LEFT JOIN users u ON u.id = m.assignee_id
+        WHERE m.project_id = $1`
+	args := []interface{}{projectID}
+	if state := r.URL.Query().Get("state"); state != "" {
+		if _, ok := milestoneTransitions[state]; !ok {
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		}
+		query += ` AND m.state = $2`
+		args = append(args, state)
+	}
+	query += ` ORDER BY m.due_date ASC NULLS LAST, m.id ASC`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	milestones := []models.Milestone{}
+	for rows.Next() {
+		m, err := scanMilestone(rows)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		milestones = append(milestones, m)
+	}
+
+	progress, err := loadProjectProgress(r.Context(), projectID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.MilestoneListResponse{Milestones: milestones, Progress: *progress})
+}
+
+// milestoneFields is a validated MilestoneRequest ready to write
+type milestoneFields struct {
+	title, description string
+	dueDate            sql.NullTime
+	assigneeID         sql.NullInt64
+}
+
+// validateMilestone checks req against the project and applies it on top of
+// current, writing the error response itself when it is invalid
+func validateMilestone(w http.ResponseWriter, r *http.Request, projectID int, req models.MilestoneRequest, current milestoneFields) (milestoneFields, bool) {
+	f := current
+	if req.Title != nil {
+		f.title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		f.description = strings.TrimSpace(*req.Description)
+	}
+	if f.title == "" {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return f, false
+	}
+	if len([]rune(f.title)) > maxMilestoneTitleLength {
+		http.Error(w, "Title is too long", http.StatusBadRequest)
+		return f, false
+	}
+	if len([]rune(f.description)) > maxMilestoneDescriptionLength {
+		http.Error(w, "Description is too long", http.StatusBadRequest)
+		return f, false
+	}
+
+	if req.DueDate != nil {
+		if *req.DueDate == "" {
+			f.dueDate = sql.NullTime{}
+		} else {
+			d, err := time.Parse(dateLayout, *req.DueDate)
+			if err != nil {
+				http.Error(w, "due_date must be YYYY-MM-DD", http.StatusBadRequest)
+				return f, false
+			}
+			f.dueDate = sql.NullTime{Time: d, Valid: true}
+		}
+	}
+
+	if req.AssigneeID != nil {
+		if *req.AssigneeID == 0 {
+			f.assigneeID = sql.NullInt64{}
+		} else {
+			member, err := isProjectMember(r.Context(), projectID, *req.AssigneeID)
+			if err != nil {
+				serverError(w, r, "Internal server error", err)
+				return f, false
+			}
+			if !member {
+				http.Error(w, "Assignee must be a member of the project", http.StatusBadRequest)
+				return f, false
+			}
+			f.assigneeID = sql.NullInt64{Int64: int64(*req.AssigneeID), Valid: true}
+		}
+	}
+	return f, true
+}
+
+// CreateMilestone adds a milestone to project {id}
+func CreateMilestone(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	var req models.MilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	f, ok := validateMilestone(w, r, projectID, req, milestoneFields{})
+	if !ok {
+		return
+	}
+
+	state := milestoneTodo
+	if req.State != nil {
+		state = *req.State
+	}
+	if _, ok := milestoneTransitions[state]; !ok {
+		http.Error(w, "Invalid state", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var milestoneID int
+	err = tx.QueryRowContext(r.Context(), `
+        INSERT INTO milestones (project_id, title, description, due_date, state, assignee_id, completed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $5 = 'done' THEN CURRENT_TIMESTAMP END)
+        RETURNING id
+    `, projectID, f.title, f.description, f.dueDate, state, f.assigneeID).Scan(&milestoneID)
+	if err != nil {
+		serverError(w, r, "Failed to create milestone", err)
+		return
+	}
+
+	err = recordActivity(r.Context(), tx, models.Activity{
+		ProjectID:      projectID,
+		Type:           activityMilestoneCreated,
+		Actor:          &models.User{ID: getUserIDFromToken(r)},
+		MilestoneID:    &milestoneID,
+		MilestoneTitle: f.title,
+		To:             state,
+	})
+	if err != nil {
+		serverError(w, r, "Failed to create milestone", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to create milestone", err)
+		return
+	}
+	writeMilestone(w, r, milestoneID, http.StatusCreated)
+}
+
+// requireMilestoneRole checks that the caller has at least role min on the
+// project milestone {id} belongs to
+func requireMilestoneRole(w http.ResponseWriter, r *http.Request, min string) (milestoneID, projectID int, ok bool) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	milestoneID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid milestone ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	err = database.DB.QueryRowContext(r.Context(), "SELECT project_id FROM milestones WHERE id = $1", milestoneID).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return 0, 0, false
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return 0, 0, false
+	}
+
+	if !checkProjectRole(w, r, projectID, userID, min) {
+		return 0, 0, false
+	}
+	return milestoneID, projectID, true
+}
+
+// HandleMilestoneActions updates (PUT, developers and up) or deletes
+// (DELETE, maintainers and up) milestone {id}
+func HandleMilestoneActions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		updateMilestone(w, r)
+	case http.MethodDelete:
+		deleteMilestone(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func updateMilestone(w http.ResponseWriter, r *http.Request) {
+	// Developers keep their milestones up to date themselves
+	milestoneID, projectID, ok := requireMilestoneRole(w, r, roleDeveloper)
+	if !ok {
+		return
+	}
+
+	var req models.MilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var current milestoneFields
+	var state string
+	err = tx.QueryRowContext(r.Context(), `
+        SELECT title, description, due_date, assignee_id, state
+        FROM milestones WHERE id = $1 FOR UPDATE
+    `, milestoneID).Scan(&current.title, &current.description, &current.dueDate, &current.assigneeID, &state)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	f, ok := validateMilestone(w, r, projectID, req, current)
+	if !ok {
+		return
+	}
+
+	newState := state
+	if req.State != nil && *req.State != state {
+		if _, ok := milestoneTransitions[*req.State]; !ok {
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		}
+		allowed := false
+		for _, s := range milestoneTransitions[state] {
+			allowed = allowed || s == *req.State
+		}
+		if !allowed {
+			http.Error(w, "Cannot move a milestone from "+state+" to "+*req.State, http.StatusConflict)
+			return
+		}
+		newState = *req.State
+	}
+
+	_, err = tx.ExecContext(r.Context(), `
+        UPDATE milestones
+        SET title = $2, description = $3, due_date = $4, assignee_id = $5, state = $6,
+            completed_at = CASE
+                WHEN $6 <> 'done' THEN NULL
+                WHEN state = 'done' THEN completed_at
+                ELSE CURRENT_TIMESTAMP
+            END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, milestoneID, f.title, f.description, f.dueDate, f.assigneeID, newState)
+	if err != nil {
+		serverError(w, r, "Failed to update milestone", err)
+		return
+	}
+
+	if newState != state {
+		err = recordActivity(r.Context(), tx, models.Activity{
+			ProjectID:      projectID,
+			Type:           activityMilestoneState,
+			Actor:          &models.User{ID: getUserIDFromToken(r)},
+			MilestoneID:    &milestoneID,
+			MilestoneTitle: f.title,
+			From:           state,
+			To:             newState,
+		})
+		if err != nil {
+			serverError(w, r, "Failed to update milestone", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to update milestone", err)
+		return
+	}
+	writeMilestone(w, r, milestoneID, http.StatusOK)
+}
+
+func deleteMilestone(w http.ResponseWriter, r *http.Request) {
+	milestoneID, projectID, ok := requireMilestoneRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// The timeline keeps the title; its milestone_id is cleared by the delete
+	var title string
+	err = tx.QueryRowContext(r.Context(), "DELETE FROM milestones WHERE id = $1 RETURNING title", milestoneID).Scan(&title)
+	if err != nil {
+		serverError(w, r, "Failed to delete milestone", err)
+		return
+	}
+	err = recordActivity(r.Context(), tx, models.Activity{
+		ProjectID:      projectID,
+		Type:           activityMilestoneDeleted,
+		Actor:          &models.User{ID: getUserIDFromToken(r)},
+		MilestoneTitle: title,
+	})
+	if err != nil {
+		serverError(w, r, "Failed to delete milestone", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to delete milestone", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeMilestone(w http.ResponseWriter, r *http.Request, milestoneID, status int) {
+	row := database.DB.QueryRowContext(r.Context(), `SELECT `+milestoneColumns+`
+        FROM milestones m
+        LEFT JOIN users u ON u.id = m.assignee_id
+        WHERE m.id = $1`, milestoneID)
+	m, err := scanMilestone(row)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(m)
+}
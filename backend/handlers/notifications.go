@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Notification types
+const (
+	notifyApplicationReceived  = "application_received"
+	notifyApplicationUpdated   = "application_updated"
+	notifyApplicationWithdrawn = "application_withdrawn"
+)
+
+// notify queues a notification for each of userIDs, skipping the actor
+// since nobody needs to be told about their own action
+func notify(ctx context.Context, db execer, userIDs []int, n models.Notification) error {
+	actorID := 0
+	if n.Actor != nil {
+		actorID = n.Actor.ID
+	}
+	var projectID, applicationID sql.NullInt64
+	if n.Project != nil {
+		projectID = sql.NullInt64{Int64: int64(n.Project.ID), Valid: true}
+	}
+	if n.ApplicationID != nil {
+		applicationID = sql.NullInt64{Int64: int64(*n.ApplicationID), Valid: true}
+	}
+
+	for _, userID := range userIDs {
+		if userID == actorID {
+			continue
+		}
+		_, err := db.ExecContext(ctx, `
+            INSERT INTO notifications (user_id, type, actor_id, project_id, application_id, message)
+            VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6)
+        `, userID, n.Type, actorID, projectID, applicationID, n.Message)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectManagers returns the members of a project who can act on its
+// team: the owner and maintainers
+func projectManagers(ctx context.Context, projectID int) ([]int, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT user_id FROM project_members WHERE project_id = $1 AND role IN ('owner', 'maintainer')", projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetNotifications lists the caller's notifications, newest first. Pass
+// ?unread=true for unread ones only.
+func GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := `
+        SELECT n.id, n.type, n.application_id, n.message, n.read_at IS NOT NULL, n.created_at,
+               u.id, u.username, u.first_name, u.last_name, u.profile_picture,
+               p.id, p.name, p.code
+        FROM notifications n
+        LEFT JOIN users u ON u.id = n.actor_id
+        LEFT JOIN projects p ON p.id = n.project_id
+        WHERE n.user_id = $1`
+	args := []interface{}{userID, limit + 1}
+	if r.URL.Query().Get("unread") == "true" {
+		query += ` AND n.read_at IS NULL`
+	}
+	if cursor != nil {
+		query += ` AND (n.created_at, n.id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY n.created_at DESC, n.id DESC LIMIT $2`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var applicationID, actorID, projectID sql.NullInt64
+		var actorName, projectName, projectCode sql.NullString
+		var actor models.User
+		err := rows.Scan(
+			&n.ID, &n.Type, &applicationID, &n.Message, &n.Read, &n.CreatedAt,
+			&actorID, &actorName, &actor.FirstName, &actor.LastName, &actor.ProfilePicture,
+			&projectID, &projectName, &projectCode,
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		if applicationID.Valid {
+			id := int(applicationID.Int64)
+			n.ApplicationID = &id
+		}
+		if actorID.Valid {
+			actor.ID = int(actorID.Int64)
+			actor.Username = actorName.String
+			n.Actor = &actor
+		}
+		if projectID.Valid {
+			n.Project = &models.Project{ID: int(projectID.Int64), Name: projectName.String, Code: projectCode.String}
+		}
+		notifications = append(notifications, n)
+	}
+	rows.Close()
+
+	response := models.NotificationListResponse{Notifications: notifications}
+	if len(notifications) > limit {
+		last := notifications[limit-1]
+		response.Notifications = notifications[:limit]
+		response.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	err = database.DB.QueryRowContext(r.Context(),
+		"SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL", userID,
+	).Scan(&response.UnreadCount)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// MarkNotificationsRead marks the given notifications of the caller as
+// read, or all of them when no IDs are sent
+func MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.MarkReadRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	query := "UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL"
+	args := []interface{}{userID}
+	if len(req.IDs) > 0 {
+		query += " AND id = ANY($2)"
+		args = append(args, pq.Array(req.IDs))
+	}
+	if _, err := database.DB.ExecContext(r.Context(), query, args...); err != nil {
+		serverError(w, r, "Failed to update notifications", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Cursor identifies a position in a list ordered by (created_at DESC, id DESC)
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// encodeCursor turns a position into an opaque string safe for query params
+func encodeCursor(createdAt time.Time, id int) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + ":" + strconv.Itoa(id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &Cursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
+
+// parseLimit reads the "limit" query parameter, defaulting to def and
+// capped at max
+func parseLimit(r *http.Request, def, max int) (int, error) {
+	limit := def
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid limit")
+		}
+		if n > max {
+			n = max
+		}
+		limit = n
+	}
+	return limit, nil
+}
+
+// parsePageParams reads the "cursor" and "limit" query parameters
+func parsePageParams(r *http.Request) (*Cursor, int, error) {
+	limit, err := parseLimit(r, defaultPageSize, maxPageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var cursor *Cursor
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return nil, 0, err
+		}
+		cursor = c
+	}
+
+	return cursor, limit, nil
+}
@@ -2,9 +2,10 @@ package handlers
 
 import (
 	"auth-app-backend/database"
+	"auth-app-backend/imaging"
 	"auth-app-backend/models"
 	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
 	"strings"
 )
@@ -32,7 +33,7 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		github_link, portfolio_link, linkedin_link, company_name, profile_picture, banner, bio, 
 		created_at, updated_at FROM users WHERE id = $1`
 
-	err = database.DB.QueryRow(query, userID).Scan(
+	err = database.DB.QueryRowContext(r.Context(), query, userID).Scan(
 		&currentUser.ID, &currentUser.Username, &currentUser.Email, &currentUser.FirstName,
 		&currentUser.LastName, &currentUser.Phone, &currentUser.UserType, &currentUser.GithubLink,
 		&currentUser.PortfolioLink, &currentUser.LinkedinLink, &currentUser.CompanyName,
@@ -105,29 +106,36 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		paramIndex++
 	}
 
-	// Handle file uploads (for now, we'll just store the file data as base64)
-	if profilePicture, header, err := r.FormFile("profile_picture"); err == nil {
+	// Handle file uploads: run them through the image pipeline, store the
+	// variants and keep only the URL of the original
+	if profilePicture, _, err := r.FormFile("profile_picture"); err == nil {
 		defer profilePicture.Close()
-		fileBytes, err := io.ReadAll(profilePicture)
-		if err == nil {
-			profilePictureData := "data:" + header.Header.Get("Content-Type") + ";base64," +
-				string(fileBytes) // This is a simplified approach - in production, store files properly
-			updateFields = append(updateFields, "profile_picture = $"+string(rune(paramIndex+'0')))
-			updateValues = append(updateValues, profilePictureData)
-			paramIndex++
+		variants, err := storeUpload(r.Context(), profilePicture)
+		if errors.Is(err, imaging.ErrInvalid) {
+			http.Error(w, "Profile picture: "+err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			serverError(w, r, "Failed to store profile picture", err)
+			return
 		}
+		updateFields = append(updateFields, "profile_picture = $"+string(rune(paramIndex+'0')))
+		updateValues = append(updateValues, variants.Original)
+		paramIndex++
 	}
 
-	if banner, header, err := r.FormFile("banner"); err == nil {
+	if banner, _, err := r.FormFile("banner"); err == nil {
 		defer banner.Close()
-		fileBytes, err := io.ReadAll(banner)
-		if err == nil {
-			bannerData := "data:" + header.Header.Get("Content-Type") + ";base64," +
-				string(fileBytes) // This is a simplified approach - in production, store files properly
-			updateFields = append(updateFields, "banner = $"+string(rune(paramIndex+'0')))
-			updateValues = append(updateValues, bannerData)
-			paramIndex++
+		variants, err := storeUpload(r.Context(), banner)
+		if errors.Is(err, imaging.ErrInvalid) {
+			http.Error(w, "Banner: "+err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			serverError(w, r, "Failed to store banner", err)
+			return
 		}
+		updateFields = append(updateFields, "banner = $"+string(rune(paramIndex+'0')))
+		updateValues = append(updateValues, variants.Original)
+		paramIndex++
 	}
 
 	// Add updated_at timestamp
@@ -140,9 +148,9 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		// Build the UPDATE query
 		updateQuery := "UPDATE users SET " + strings.Join(updateFields, ", ") + " WHERE id = $" + string(rune(paramIndex+'0'))
 
-		_, err = database.DB.Exec(updateQuery, updateValues...)
+		_, err = database.DB.ExecContext(r.Context(), updateQuery, updateValues...)
 		if err != nil {
-			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+			serverError(w, r, "Failed to update profile", err)
 			return
 		}
 	}
@@ -153,7 +161,7 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		github_link, portfolio_link, linkedin_link, company_name, profile_picture, banner, bio, 
 		created_at, updated_at FROM users WHERE id = $1`
 
-	err = database.DB.QueryRow(query, userID).Scan(
+	err = database.DB.QueryRowContext(r.Context(), query, userID).Scan(
 		&updatedUser.ID, &updatedUser.Username, &updatedUser.Email, &updatedUser.FirstName,
 		&updatedUser.LastName, &updatedUser.Phone, &updatedUser.UserType, &updatedUser.GithubLink,
 		&updatedUser.PortfolioLink, &updatedUser.LinkedinLink, &updatedUser.CompanyName,
@@ -162,9 +170,10 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if err != nil {
-		http.Error(w, "Failed to fetch updated user", http.StatusInternalServerError)
+		serverError(w, r, "Failed to fetch updated user", err)
 		return
 	}
+	fillUserImages(r.Context(), &updatedUser)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedUser)
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/imaging"
+	"auth-app-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+const maxProjectImages = 9
+
+// UploadProjectImages adds the images sent as multipart "images" fields to
+// the end of a project's gallery
+func UploadProjectImages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxProjectImages*imaging.MaxFileSize+(1<<20))
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, "Failed to parse form data", http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["images"]
+	if len(files) == 0 {
+		http.Error(w, "No images uploaded", http.StatusBadRequest)
+		return
+	}
+	if len(files) > maxProjectImages {
+		http.Error(w, fmt.Sprintf("Maximum %d images allowed", maxProjectImages), http.StatusBadRequest)
+		return
+	}
+
+	// Process outside the transaction so the row lock is only held for the
+	// quick check-and-append below
+	urls := []string{}
+	for _, header := range files {
+		f, err := header.Open()
+		if err != nil {
+			http.Error(w, "Failed to read upload", http.StatusBadRequest)
+			return
+		}
+		variants, err := storeUpload(r.Context(), f)
+		f.Close()
+		if errors.Is(err, imaging.ErrInvalid) {
+			http.Error(w, header.Filename+": "+err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			serverError(w, r, "Failed to store image", err)
+			return
+		}
+		urls = append(urls, variants.Original)
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// FOR UPDATE serialises concurrent uploads to the same project, so two
+	// requests can't both see room for their images and overshoot the limit
+	var images []string
+	err = tx.QueryRowContext(r.Context(), "SELECT images FROM projects WHERE id = $1 FOR UPDATE", projectID).Scan(pq.Array(&images))
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	existing := map[string]bool{}
+	for _, img := range images {
+		existing[img] = true
+	}
+	for _, url := range urls {
+		if !existing[url] {
+			existing[url] = true
+			images = append(images, url)
+		}
+	}
+
+	if len(images) > maxProjectImages {
+		http.Error(w, fmt.Sprintf("Maximum %d images allowed", maxProjectImages), http.StatusConflict)
+		return
+	}
+
+	_, err = tx.ExecContext(r.Context(), "UPDATE projects SET images = $1 WHERE id = $2", pq.Array(images), projectID)
+	if err != nil {
+		serverError(w, r, "Failed to update project images", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to update project images", err)
+		return
+	}
+
+	writeProjectImages(w, r, projectID, http.StatusCreated)
+}
+
+// DeleteProjectImage removes the image given by ?url= from a project. If it
+// was the cover, the cover falls back to the first remaining image.
+func DeleteProjectImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "Image URL is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := database.DB.ExecContext(r.Context(), `
+		UPDATE projects
+		SET images = ARRAY_REMOVE(images, $2),
+		    cover_image = CASE WHEN cover_image = $2 THEN NULL ELSE cover_image END
+		WHERE id = $1 AND $2 = ANY(images)`,
+		projectID, url,
+	)
+	if err != nil {
+		serverError(w, r, "Failed to delete image", err)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+
+	writeProjectImages(w, r, projectID, http.StatusOK)
+}
+
+// ReorderProjectImages sets the gallery order. The body must list exactly
+// the project's current images: {"images": ["/media/...", ...]}
+func ReorderProjectImages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	var req models.ImageOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var images []string
+	err = tx.QueryRowContext(r.Context(), "SELECT images FROM projects WHERE id = $1 FOR UPDATE", projectID).Scan(pq.Array(&images))
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	if !sameImages(images, req.Images) {
+		http.Error(w, "Order must list each current image exactly once", http.StatusBadRequest)
+		return
+	}
+
+	_, err = tx.ExecContext(r.Context(), "UPDATE projects SET images = $1 WHERE id = $2", pq.Array(req.Images), projectID)
+	if err != nil {
+		serverError(w, r, "Failed to reorder images", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to reorder images", err)
+		return
+	}
+
+	writeProjectImages(w, r, projectID, http.StatusOK)
+}
+
+// SetProjectCover designates one of the project's images as its cover:
+// {"url": "/media/..."}
+func SetProjectCover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	var req models.CoverImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := database.DB.ExecContext(r.Context(),
+		"UPDATE projects SET cover_image = $2 WHERE id = $1 AND $2 = ANY(images)", projectID, req.URL,
+	)
+	if err != nil {
+		serverError(w, r, "Failed to set cover image", err)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if rowsAffected == 0 {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+
+	writeProjectImages(w, r, projectID, http.StatusOK)
+}
+
+// sameImages reports whether b is a permutation of a
+func sameImages(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, img := range a {
+		counts[img]++
+	}
+	for _, img := range b {
+		counts[img]--
+		if counts[img] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func writeProjectImages(w http.ResponseWriter, r *http.Request, projectID, status int) {
+	project := models.Project{ID: projectID}
+	err := database.DB.QueryRowContext(r.Context(), "SELECT images FROM projects WHERE id = $1", projectID).Scan(pq.Array(&project.Images))
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if project.Images == nil {
+		project.Images = []string{}
+	}
+	fillProjectImages(r.Context(), &project)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ProjectImages{
+		Images:        project.Images,
+		ImageVariants: project.ImageVariants,
+		CoverImage:    project.CoverImage,
+	})
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Project statuses. The values are the labels the frontend already shows.
+const (
+	statusIdea        = "Only an Idea"
+	statusDevelopment = "Under development"
+	statusProduction  = "Ready for production"
+	statusOnHold      = "On hold"
+	statusArchived    = "Archived"
+)
+
+// statusTransitions lists the statuses each status can move to
+var statusTransitions = map[string][]string{
+	statusIdea:        {statusDevelopment, statusOnHold, statusArchived},
+	statusDevelopment: {statusProduction, statusIdea, statusOnHold, statusArchived},
+	statusProduction:  {statusDevelopment, statusOnHold, statusArchived},
+	statusOnHold:      {statusIdea, statusDevelopment, statusProduction, statusArchived},
+	statusArchived:    {statusOnHold},
+}
+
+// Activity types recorded in project_activity
+const (
+	activityStatusChanged    = "status_changed"
+	activityMilestoneCreated = "milestone_created"
+	activityMilestoneState   = "milestone_state_changed"
+	activityMilestoneDeleted = "milestone_deleted"
+)
+
+const maxStatusNoteLength = 1000
+
+func validStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// recordActivity appends an entry to a project's timeline
+func recordActivity(ctx context.Context, db execer, a models.Activity) error {
+	var actorID sql.NullInt64
+	if a.Actor != nil && a.Actor.ID != 0 {
+		actorID = sql.NullInt64{Int64: int64(a.Actor.ID), Valid: true}
+	}
+	var milestoneID sql.NullInt64
+	if a.MilestoneID != nil {
+		milestoneID = sql.NullInt64{Int64: int64(*a.MilestoneID), Valid: true}
+	}
+
+	_, err := db.ExecContext(ctx, `
+        INSERT INTO project_activity (project_id, actor_id, type, milestone_id, milestone_title, from_state, to_state, note)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8)
+    `, a.ProjectID, actorID, a.Type, milestoneID, a.MilestoneTitle, a.From, a.To, a.Note)
+	return err
+}
+
+// HandleProjectStatus returns (GET) or moves (PUT, maintainers and up) the
+// status of project {id}. Moves must follow statusTransitions.
+func HandleProjectStatus(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		projectID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid project ID", http.StatusBadRequest)
+			return
+		}
+		var status string
+		err = database.DB.QueryRowContext(r.Context(), "SELECT status FROM projects WHERE id = $1", projectID).Scan(&status)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		writeProjectStatus(w, status)
+
+	case http.MethodPut:
+		updateProjectStatus(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func updateProjectStatus(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := requireProjectRole(w, r, roleMaintainer)
+	if !ok {
+		return
+	}
+
+	var req models.StatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if !validStatus(req.Status) {
+		http.Error(w, "Unknown status", http.StatusBadRequest)
+		return
+	}
+	if len([]rune(req.Note)) > maxStatusNoteLength {
+		http.Error(w, "Note is too long", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRowContext(r.Context(), "SELECT status FROM projects WHERE id = $1 FOR UPDATE", projectID).Scan(&current)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	if current == req.Status {
+		writeProjectStatus(w, current)
+		return
+	}
+	if !canTransition(current, req.Status) {
+		http.Error(w, "Cannot move a project from "+current+" to "+req.Status, http.StatusConflict)
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), "UPDATE projects SET status = $1 WHERE id = $2", req.Status, projectID); err != nil {
+		serverError(w, r, "Failed to update status", err)
+		return
+	}
+	err = recordActivity(r.Context(), tx, models.Activity{
+		ProjectID: projectID,
+		Type:      activityStatusChanged,
+		Actor:     &models.User{ID: getUserIDFromToken(r)},
+		From:      current,
+		To:        req.Status,
+		Note:      req.Note,
+	})
+	if err != nil {
+		serverError(w, r, "Failed to update status", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to update status", err)
+		return
+	}
+	writeProjectStatus(w, req.Status)
+}
+
+func writeProjectStatus(w http.ResponseWriter, status string) {
+	allowed := statusTransitions[status]
+	if allowed == nil {
+		allowed = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ProjectStatus{Status: status, AllowedTransitions: allowed})
+}
+
+// GetProjectActivity returns the timeline of project {id}, newest first
+func GetProjectActivity(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectExists(w, r)
+	if !ok {
+		return
+	}
+
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := `
+        SELECT a.id, a.project_id, a.type, a.milestone_id, COALESCE(a.milestone_title, ''),
+               COALESCE(a.from_state, ''), COALESCE(a.to_state, ''), a.note, a.created_at,
+               u.id, u.username, u.first_name, u.last_name, u.profile_picture
+        FROM project_activity a
+        LEFT JOIN users u ON u.id = a.actor_id
+        WHERE a.project_id = $1`
+	args := []interface{}{projectID, limit + 1}
+	if cursor != nil {
+		query += ` AND (a.created_at, a.id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY a.created_at DESC, a.id DESC LIMIT $2`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	activity := []models.Activity{}
+	for rows.Next() {
+		var a models.Activity
+		var milestoneID, actorID sql.NullInt64
+		var actorName sql.NullString
+		var actor models.User
+		err := rows.Scan(
+			&a.ID, &a.ProjectID, &a.Type, &milestoneID, &a.MilestoneTitle,
+			&a.From, &a.To, &a.Note, &a.CreatedAt,
+			&actorID, &actorName, &actor.FirstName, &actor.LastName, &actor.ProfilePicture,
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		if milestoneID.Valid {
+			id := int(milestoneID.Int64)
+			a.MilestoneID = &id
+		}
+		if actorID.Valid {
+			actor.ID = int(actorID.Int64)
+			actor.Username = actorName.String
+			a.Actor = &actor
+		}
+		activity = append(activity, a)
+	}
+
+	response := models.ActivityListResponse{Activity: activity}
+	if len(activity) > limit {
+		last := activity[limit-1]
+		response.Activity = activity[:limit]
+		response.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"auth-app-backend/database"
+	"auth-app-backend/metrics"
 	"auth-app-backend/models"
 	"encoding/json"
 	"net/http"
@@ -15,15 +16,15 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := database.DB.Query(`
+	rows, err := database.DB.QueryContext(r.Context(), `
 		SELECT p.id, p.name, p.description, p.code, p.general_tags, p.programming_tags, p.likes, p.status, p.created_at, p.images,
-		       u.username, u.email
+		       u.username, u.email, `+commentsCountColumn+`
 		FROM projects p
 		JOIN users u ON p.user_id = u.id
 		ORDER BY p.created_at DESC
 	`)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 	defer rows.Close()
@@ -36,9 +37,10 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.Code, pq.Array(&genTags), pq.Array(&progTags), &p.Likes, &p.Status, &p.CreatedAt, pq.Array(&images),
-			&u.Username, &u.Email,
+			&u.Username, &u.Email, &p.CommentsCount,
 		)
 		if err != nil {
+			logError(r.Context(), "Skipping unreadable project", err)
 			continue
 		}
 		p.GeneralTags = genTags
@@ -47,6 +49,7 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 		p.Developer = &u
 		projects = append(projects, p)
 	}
+	fillProjectImages(r.Context(), projectPointers(projects)...)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(projects)
@@ -58,39 +61,106 @@ func CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req models.Project
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body models.CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	// Images only come in through UploadProjectImages, which checks and
+	// resizes them
+	req := models.Project{
+		UserID:          userID,
+		Name:            body.Name,
+		Description:     body.Description,
+		Code:            body.Code,
+		GeneralTags:     body.GeneralTags,
+		ProgrammingTags: body.ProgrammingTags,
+		Status:          body.Status,
+		Images:          []string{},
+	}
+
+	// New projects start anywhere but archived; later moves follow the state machine
+	if req.Status == "" {
+		req.Status = statusIdea
+	}
+	if !validStatus(req.Status) || req.Status == statusArchived {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
 
-	// In a real app, get user_id from context/token
-	// For now, hardcode or expect in body. Let's assume passed in body for simplicity of migration
-	if req.UserID == 0 {
-		http.Error(w, "User ID required", http.StatusBadRequest)
+	generalTags, msg := cleanTags(req.GeneralTags)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	programmingTags, msg := cleanTags(req.ProgrammingTags)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
 
-	// Validate images (max 9 images)
-	if len(req.Images) > 9 {
-		http.Error(w, "Maximum 9 images allowed", http.StatusBadRequest)
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Error creating project", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Tags are stored under their canonical names so "reactjs" and "React" match
+	if req.GeneralTags, err = normalizeTags(r.Context(), tx, tagGeneral, generalTags); err != nil {
+		serverError(w, r, "Error creating project", err)
+		return
+	}
+	if req.ProgrammingTags, err = normalizeTags(r.Context(), tx, tagProgramming, programmingTags); err != nil {
+		serverError(w, r, "Error creating project", err)
 		return
 	}
 
 	query := `
-		INSERT INTO projects (user_id, name, description, code, general_tags, programming_tags, likes, status, images)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO projects (user_id, name, description, code, general_tags, programming_tags, status, images)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '{}')
 		RETURNING id, created_at
 	`
-	err := database.DB.QueryRow(
+	err = tx.QueryRowContext(r.Context(),
 		query,
-		req.UserID, req.Name, req.Description, req.Code, pq.Array(req.GeneralTags), pq.Array(req.ProgrammingTags), req.Likes, req.Status, pq.Array(req.Images),
+		req.UserID, req.Name, req.Description, req.Code, pq.Array(req.GeneralTags), pq.Array(req.ProgrammingTags), req.Status,
 	).Scan(&req.ID, &req.CreatedAt)
 
 	if err != nil {
-		http.Error(w, "Error creating project", http.StatusInternalServerError)
+		serverError(w, r, "Error creating project", err)
+		return
+	}
+
+	_, err = tx.ExecContext(r.Context(),
+		"INSERT INTO project_members (project_id, user_id, role) VALUES ($1, $2, $3)", req.ID, req.UserID, roleOwner,
+	)
+	if err != nil {
+		serverError(w, r, "Error creating project", err)
+		return
+	}
+
+	err = recordActivity(r.Context(), tx, models.Activity{
+		ProjectID: req.ID,
+		Type:      activityStatusChanged,
+		Actor:     &models.User{ID: req.UserID},
+		To:        req.Status,
+	})
+	if err != nil {
+		serverError(w, r, "Error creating project", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Error creating project", err)
 		return
 	}
+	metrics.ProjectsCreated.Inc()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(req)
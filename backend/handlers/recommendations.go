@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"auth-app-backend/recommend"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+	// maxRecommendationCandidates bounds how many rows are scored per request
+	maxRecommendationCandidates = 500
+)
+
+// GetProjectRecommendations suggests projects for the caller to join.
+// Projects score for programming tags shared with the projects the caller
+// works on, general tags shared with those and the projects they liked or
+// saved, owners they follow, and past likes and saves of the owner's work.
+// Projects the caller is on, has saved, has an open application to or that
+// are archived are left out.
+func GetProjectRecommendations(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	limit, err := parseLimit(r, defaultRecommendationLimit, maxRecommendationLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	skills := recommend.NewTagProfile()
+	interests := recommend.NewTagProfile()
+	rows, err := database.DB.QueryContext(r.Context(), `
+        SELECT p.programming_tags, p.general_tags, TRUE
+        FROM project_members m
+        JOIN projects p ON p.id = m.project_id
+        WHERE m.user_id = $1 AND m.role <> 'observer'
+        UNION ALL
+        SELECT p.programming_tags, p.general_tags, FALSE
+        FROM projects p
+        WHERE p.id IN (
+            SELECT project_id FROM project_likes WHERE user_id = $1
+            UNION SELECT project_id FROM project_saves WHERE user_id = $1
+        )
+    `, userID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	for rows.Next() {
+		var progTags, genTags []string
+		var own bool
+		if err := rows.Scan(pq.Array(&progTags), pq.Array(&genTags), &own); err != nil {
+			rows.Close()
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		// Liked projects say what someone cares about, not what they can build
+		if own {
+			skills.Add(progTags)
+		}
+		interests.Add(genTags)
+	}
+	rows.Close()
+
+	// Past the cap, keep the candidates with the best recommend.Estimate,
+	// so a good older match isn't crowded out by newer weak ones
+	tags := append(skills.Keys(), interests.Keys()...)
+	rows, err = database.DB.QueryContext(r.Context(), `
+        SELECT p.id, p.user_id, p.name, p.description, p.code, p.general_tags, p.programming_tags,
+               p.likes, p.status, p.created_at, p.images,
+               u.username, u.first_name, u.last_name, u.profile_picture,
+               sig.followed, sig.interactions
+        FROM projects p
+        JOIN users u ON u.id = p.user_id
+        CROSS JOIN LATERAL (
+            SELECT EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = $1 AND f.following_id = p.user_id) AS followed,
+                   (SELECT COUNT(*) FROM project_likes l JOIN projects op ON op.id = l.project_id
+                    WHERE l.user_id = $1 AND op.user_id = p.user_id AND op.id <> p.id)
+                 + (SELECT COUNT(*) FROM project_saves s JOIN projects op ON op.id = s.project_id
+                    WHERE s.user_id = $1 AND op.user_id = p.user_id AND op.id <> p.id) AS interactions,
+                   (SELECT COUNT(DISTINCT LOWER(t)) FROM UNNEST(p.programming_tags) t WHERE LOWER(t) = ANY($4)) AS skill_matches,
+                   (SELECT COUNT(DISTINCT LOWER(t)) FROM UNNEST(p.general_tags) t WHERE LOWER(t) = ANY($5)) AS interest_matches
+        ) sig
+        WHERE p.status <> 'Archived'
+          AND NOT EXISTS (SELECT 1 FROM project_members m WHERE m.project_id = p.id AND m.user_id = $1)
+          AND NOT EXISTS (SELECT 1 FROM project_saves s WHERE s.project_id = p.id AND s.user_id = $1)
+          AND NOT EXISTS (
+              SELECT 1 FROM project_applications a
+              WHERE a.project_id = p.id AND a.applicant_id = $1 AND a.status IN ('pending', 'shortlisted')
+          )
+          AND (
+              EXISTS (SELECT 1 FROM UNNEST(p.programming_tags || p.general_tags) t WHERE LOWER(t) = ANY($2))
+              OR p.user_id IN (SELECT following_id FROM followers WHERE follower_id = $1)
+              OR p.user_id IN (
+                  SELECT op.user_id FROM projects op
+                  WHERE op.id IN (
+                      SELECT project_id FROM project_likes WHERE user_id = $1
+                      UNION SELECT project_id FROM project_saves WHERE user_id = $1
+                  )
+              )
+          )
+        ORDER BY $6::FLOAT8 * sig.skill_matches + $7::FLOAT8 * sig.interest_matches
+                 + $8::FLOAT8 * sig.followed::INT + $9::FLOAT8 * LEAST(sig.interactions, $10::INT) DESC,
+                 p.created_at DESC, p.id
+        LIMIT $3
+    `, userID, pq.Array(tags), maxRecommendationCandidates, pq.Array(skills.Keys()), pq.Array(interests.Keys()),
+		recommend.ProgrammingWeight, recommend.GeneralWeight, recommend.FollowWeight, recommend.InteractionWeight, recommend.MaxInteractions)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	recommendations := []models.ProjectRecommendation{}
+	for rows.Next() {
+		var p models.Project
+		var dev models.User
+		var followed bool
+		var interactions int
+		err := rows.Scan(
+			&p.ID, &p.UserID, &p.Name, &p.Description, &p.Code, pq.Array(&p.GeneralTags), pq.Array(&p.ProgrammingTags),
+			&p.Likes, &p.Status, &p.CreatedAt, pq.Array(&p.Images),
+			&dev.Username, &dev.FirstName, &dev.LastName, &dev.ProfilePicture,
+			&followed, &interactions,
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+
+		var m recommend.Match
+		m.AddTags(skills, p.ProgrammingTags, recommend.ProgrammingWeight, "matched on")
+		m.AddTags(interests, p.GeneralTags, recommend.GeneralWeight, "shares interests:")
+		if followed {
+			m.Add(recommend.FollowWeight, "you follow @"+dev.Username)
+		}
+		m.AddInteractions(interactions, fmt.Sprintf("you liked or saved %d of @%s's projects", interactions, dev.Username))
+		if m.Score == 0 {
+			continue
+		}
+
+		if p.Images == nil {
+			p.Images = []string{}
+		}
+		dev.ID = p.UserID
+		p.Developer = &dev
+		recommendations = append(recommendations, models.ProjectRecommendation{
+			Project:     p,
+			Score:       m.Rounded(),
+			Reasons:     m.Reasons,
+			MatchedTags: nonNil(m.MatchedTags),
+		})
+	}
+	rows.Close()
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Project.CreatedAt.After(b.Project.CreatedAt) ||
+			a.Project.CreatedAt.Equal(b.Project.CreatedAt) && a.Project.ID < b.Project.ID
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	projects := make([]*models.Project, len(recommendations))
+	for i := range recommendations {
+		projects[i] = &recommendations[i].Project
+	}
+	fillProjectImages(r.Context(), projects...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recommendations)
+}
+
+// GetDeveloperRecommendations suggests developers for ?project={id}.
+// Developers score for programming and general tags of the projects they
+// work on that the project shares, having liked or saved it, the caller
+// following them and them following the project's owner. Maintainers and
+// the owner only; current members are left out.
+func GetDeveloperRecommendations(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	projectID, err := strconv.Atoi(r.URL.Query().Get("project"))
+	if err != nil {
+		http.Error(w, "project is required", http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLimit(r, defaultRecommendationLimit, maxRecommendationLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !checkProjectRole(w, r, projectID, userID, roleMaintainer) {
+		return
+	}
+
+	var ownerID int
+	var ownerName string
+	var progTags, genTags []string
+	err = database.DB.QueryRowContext(r.Context(), `
+        SELECT p.user_id, u.username, p.programming_tags, p.general_tags
+        FROM projects p JOIN users u ON u.id = p.user_id
+        WHERE p.id = $1
+    `, projectID).Scan(&ownerID, &ownerName, pq.Array(&progTags), pq.Array(&genTags))
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	wanted := recommend.NewTagProfile()
+	wanted.Add(progTags)
+	wanted.Add(genTags)
+
+	// Build each candidate's profile from the projects they work on that
+	// share at least one tag with this one. When there are more than the
+	// cap, the projects sharing the most tags win, then the newest.
+	skills := map[int]*recommend.TagProfile{}
+	interests := map[int]*recommend.TagProfile{}
+	rows, err := database.DB.QueryContext(r.Context(), `
+        SELECT m.user_id, p.programming_tags, p.general_tags
+        FROM project_members m
+        JOIN projects p ON p.id = m.project_id
+        JOIN users u ON u.id = m.user_id
+        WHERE u.user_type = 'developer' AND m.role <> 'observer' AND p.id <> $1
+          AND NOT EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = $1 AND pm.user_id = m.user_id)
+          AND EXISTS (SELECT 1 FROM UNNEST(p.programming_tags || p.general_tags) t WHERE LOWER(t) = ANY($2))
+        ORDER BY (SELECT COUNT(*) FROM UNNEST(p.programming_tags || p.general_tags) t WHERE LOWER(t) = ANY($2)) DESC,
+                 p.created_at DESC, m.project_id, m.user_id
+        LIMIT $3
+    `, projectID, pq.Array(wanted.Keys()), maxRecommendationCandidates*5)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	for rows.Next() {
+		var devID int
+		var devProg, devGen []string
+		if err := rows.Scan(&devID, pq.Array(&devProg), pq.Array(&devGen)); err != nil {
+			rows.Close()
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		if skills[devID] == nil {
+			skills[devID] = recommend.NewTagProfile()
+			interests[devID] = recommend.NewTagProfile()
+		}
+		skills[devID].Add(devProg)
+		interests[devID].Add(devGen)
+	}
+	rows.Close()
+
+	candidates := make([]int64, 0, len(skills))
+	for id := range skills {
+		candidates = append(candidates, int64(id))
+	}
+
+	// Past the cap, drop the candidates with the weakest signals: tag
+	// matches outweigh follows, which outweigh likes and saves
+	rows, err = database.DB.QueryContext(r.Context(), `
+        SELECT u.id, u.username, u.first_name, u.last_name, u.profile_picture,
+               u.github_link, u.portfolio_link, u.linkedin_link, u.bio,
+               EXISTS(SELECT 1 FROM project_likes l WHERE l.user_id = u.id AND l.project_id = $1),
+               EXISTS(SELECT 1 FROM project_saves s WHERE s.user_id = u.id AND s.project_id = $1),
+               EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = $2 AND f.following_id = u.id),
+               EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = u.id AND f.following_id = $3)
+        FROM users u
+        WHERE u.user_type = 'developer' AND u.id <> $2
+          AND NOT EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = $1 AND pm.user_id = u.id)
+          AND (
+              u.id = ANY($4)
+              OR u.id IN (SELECT user_id FROM project_likes WHERE project_id = $1)
+              OR u.id IN (SELECT user_id FROM project_saves WHERE project_id = $1)
+              OR u.id IN (SELECT following_id FROM followers WHERE follower_id = $2)
+          )
+        ORDER BY u.id = ANY($4) DESC,
+                 EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = $2 AND f.following_id = u.id) DESC,
+                 EXISTS(SELECT 1 FROM project_likes l WHERE l.user_id = u.id AND l.project_id = $1)::INT
+                 + EXISTS(SELECT 1 FROM project_saves s WHERE s.user_id = u.id AND s.project_id = $1)::INT DESC,
+                 u.id
+        LIMIT $5
+    `, projectID, userID, ownerID, pq.Array(candidates), maxRecommendationCandidates)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	recommendations := []models.DeveloperRecommendation{}
+	for rows.Next() {
+		var u models.User
+		var liked, saved, followed, followsOwner bool
+		err := rows.Scan(
+			&u.ID, &u.Username, &u.FirstName, &u.LastName, &u.ProfilePicture,
+			&u.GithubLink, &u.PortfolioLink, &u.LinkedinLink, &u.Bio,
+			&liked, &saved, &followed, &followsOwner,
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+
+		var m recommend.Match
+		if skills[u.ID] != nil {
+			m.AddTags(skills[u.ID], progTags, recommend.ProgrammingWeight, "matched on")
+			m.AddTags(interests[u.ID], genTags, recommend.GeneralWeight, "has worked on")
+		}
+		switch {
+		case liked && saved:
+			m.AddInteractions(2, "liked and saved this project")
+		case liked:
+			m.AddInteractions(1, "liked this project")
+		case saved:
+			m.AddInteractions(1, "saved this project")
+		}
+		if followed {
+			m.Add(recommend.FollowWeight, "you follow @"+u.Username)
+		}
+		if followsOwner {
+			reason := "follows @" + ownerName
+			if ownerID == userID {
+				reason = "follows you"
+			}
+			m.Add(recommend.FollowWeight/2, reason)
+		}
+		if m.Score == 0 {
+			continue
+		}
+
+		recommendations = append(recommendations, models.DeveloperRecommendation{
+			User:        u,
+			Score:       m.Rounded(),
+			Reasons:     m.Reasons,
+			MatchedTags: nonNil(m.MatchedTags),
+		})
+	}
+	rows.Close()
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].User.ID < recommendations[j].User.ID
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	users := make([]*models.User, len(recommendations))
+	for i := range recommendations {
+		users[i] = &recommendations[i].User
+	}
+	fillUserImages(r.Context(), users...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recommendations)
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -10,6 +10,8 @@ import (
 	"auth-app-backend/database"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
 )
 
 // Helper function to extract user ID from JWT token
@@ -74,9 +76,9 @@ func GetSavedProjects(w http.ResponseWriter, r *http.Request) {
 		ORDER BY sp.created_at DESC
 	`
 
-	rows, err := database.DB.Query(query, userID)
+	rows, err := database.DB.QueryContext(r.Context(), query, userID)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 	defer rows.Close()
@@ -88,12 +90,12 @@ func GetSavedProjects(w http.ResponseWriter, r *http.Request) {
 			Name        string         `json:"name"`
 			Description string         `json:"description"`
 			Code        string         `json:"code"`
-			GeneralTags sql.NullString `json:"general_tags"`
-			ProgTags    sql.NullString `json:"programming_tags"`
+			GeneralTags []string       `json:"general_tags"`
+			ProgTags    []string       `json:"programming_tags"`
 			Likes       int            `json:"likes"`
 			Status      string         `json:"status"`
 			CreatedAt   string         `json:"created_at"`
-			Images      sql.NullString `json:"images"`
+			Images      []string       `json:"images"`
 			Username    string         `json:"username"`
 			FirstName   sql.NullString `json:"first_name"`
 			LastName    sql.NullString `json:"last_name"`
@@ -102,40 +104,25 @@ func GetSavedProjects(w http.ResponseWriter, r *http.Request) {
 
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.Code,
-			&p.GeneralTags, &p.ProgTags, &p.Likes, &p.Status, &p.CreatedAt, &p.Images,
+			pq.Array(&p.GeneralTags), pq.Array(&p.ProgTags), &p.Likes, &p.Status, &p.CreatedAt, pq.Array(&p.Images),
 			&p.Username, &p.FirstName, &p.LastName, &p.ProfilePic,
 		)
 		if err != nil {
+			logError(r.Context(), "Skipping unreadable saved project", err)
 			continue
 		}
 
-		// Parse tags and images
-		var generalTags []string
-		if p.GeneralTags.Valid {
-			generalTags = strings.Split(p.GeneralTags.String, ",")
-		}
-
-		var progTags []string
-		if p.ProgTags.Valid {
-			progTags = strings.Split(p.ProgTags.String, ",")
-		}
-
-		var images []string
-		if p.Images.Valid {
-			images = strings.Split(p.Images.String, ",")
-		}
-
 		project := map[string]interface{}{
 			"id":               p.ID,
 			"name":             p.Name,
 			"description":      p.Description,
 			"code":             p.Code,
-			"general_tags":     generalTags,
-			"programming_tags": progTags,
+			"general_tags":     p.GeneralTags,
+			"programming_tags": p.ProgTags,
 			"likes":            p.Likes,
 			"status":           p.Status,
 			"created_at":       p.CreatedAt,
-			"images":           images,
+			"images":           p.Images,
 			"developer": map[string]interface{}{
 				"username":        p.Username,
 				"first_name":      p.FirstName.String,
@@ -160,16 +147,7 @@ func HandleProjectActions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract project ID from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/projects/")
-	pathParts := strings.Split(path, "/")
-
-	if len(pathParts) < 2 || pathParts[1] != "save" {
-		http.Error(w, "Invalid endpoint", http.StatusNotFound)
-		return
-	}
-
-	projectID, err := strconv.Atoi(pathParts[0])
+	projectID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid project ID", http.StatusBadRequest)
 		return
@@ -191,9 +169,9 @@ func HandleProjectActions(w http.ResponseWriter, r *http.Request) {
 func saveProject(w http.ResponseWriter, r *http.Request, userID, projectID int) {
 	// Check if project exists
 	var exists bool
-	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&exists)
+	err := database.DB.QueryRowContext(r.Context(), "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&exists)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
@@ -204,10 +182,10 @@ func saveProject(w http.ResponseWriter, r *http.Request, userID, projectID int)
 
 	// Check if already saved
 	var alreadySaved bool
-	err = database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM project_saves WHERE user_id = $1 AND project_id = $2)",
+	err = database.DB.QueryRowContext(r.Context(), "SELECT EXISTS(SELECT 1 FROM project_saves WHERE user_id = $1 AND project_id = $2)",
 		userID, projectID).Scan(&alreadySaved)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
@@ -218,9 +196,9 @@ func saveProject(w http.ResponseWriter, r *http.Request, userID, projectID int)
 	}
 
 	// Save the project
-	_, err = database.DB.Exec("INSERT INTO project_saves (user_id, project_id) VALUES ($1, $2)", userID, projectID)
+	_, err = database.DB.ExecContext(r.Context(), "INSERT INTO project_saves (user_id, project_id) VALUES ($1, $2)", userID, projectID)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
@@ -231,15 +209,15 @@ func saveProject(w http.ResponseWriter, r *http.Request, userID, projectID int)
 // unsaveProject removes a project from user's saved projects
 func unsaveProject(w http.ResponseWriter, r *http.Request, userID, projectID int) {
 	// Delete from saved projects
-	result, err := database.DB.Exec("DELETE FROM project_saves WHERE user_id = $1 AND project_id = $2", userID, projectID)
+	result, err := database.DB.ExecContext(r.Context(), "DELETE FROM project_saves WHERE user_id = $1 AND project_id = $2", userID, projectID)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
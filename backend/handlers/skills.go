@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Skill proficiency levels
+const (
+	levelBeginner     = "beginner"
+	levelIntermediate = "intermediate"
+	levelAdvanced     = "advanced"
+	levelExpert       = "expert"
+)
+
+var skillLevels = map[string]bool{
+	levelBeginner: true, levelIntermediate: true, levelAdvanced: true, levelExpert: true,
+}
+
+const (
+	notifySkillEndorsed = "skill_endorsed"
+
+	maxUserSkills      = 50
+	maxSkillNameLength = 50
+	skillSearchLimit   = 20
+)
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// lookupSkills resolves skill keys against the catalogue and its aliases.
+// Keys that match nothing are left out of the result.
+func lookupSkills(ctx context.Context, db queryer, keys []string) (map[string]models.Skill, error) {
+	found := map[string]models.Skill{}
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `
+        SELECT k.key, s.id, s.name, s.category
+        FROM (
+            SELECT slug AS key, id AS skill_id FROM skills
+            UNION ALL
+            SELECT alias, skill_id FROM skill_aliases
+        ) k
+        JOIN skills s ON s.id = k.skill_id
+        WHERE k.key = ANY($1)
+    `, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var s models.Skill
+		if err := rows.Scan(&key, &s.ID, &s.Name, &s.Category); err != nil {
+			return nil, err
+		}
+		found[key] = s
+	}
+	return found, rows.Err()
+}
+
+// SearchSkills returns approved catalogue skills whose name or an alias
+// starts with ?q=, for autocomplete
+func SearchSkills(w http.ResponseWriter, r *http.Request) {
+	q := tagSlug(r.URL.Query().Get("q"))
+
+	rows, err := database.DB.QueryContext(r.Context(), `
+        SELECT s.id, s.name, s.category
+        FROM skills s
+        WHERE s.approved
+          AND (s.slug LIKE $1 || '%'
+               OR EXISTS (SELECT 1 FROM skill_aliases a WHERE a.skill_id = s.id AND a.alias LIKE $1 || '%'))
+        ORDER BY (SELECT COUNT(*) FROM user_skills us WHERE us.skill_id = s.id) DESC, s.name
+        LIMIT $2
+    `, strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q), skillSearchLimit)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	skills := []models.Skill{}
+	for rows.Next() {
+		var s models.Skill
+		if err := rows.Scan(&s.ID, &s.Name, &s.Category); err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		skills = append(skills, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(skills)
+}
+
+// loadUserSkills returns the skills a user lists, in their chosen order,
+// with endorsement counts from viewerID's point of view
+func loadUserSkills(ctx context.Context, userID, viewerID int) ([]models.UserSkill, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+        SELECT s.id, s.name, s.category, us.level,
+               (SELECT COUNT(*) FROM skill_endorsements e WHERE e.user_id = us.user_id AND e.skill_id = us.skill_id),
+               EXISTS(SELECT 1 FROM skill_endorsements e
+                      WHERE e.user_id = us.user_id AND e.skill_id = us.skill_id AND e.endorser_id = $2)
+        FROM user_skills us
+        JOIN skills s ON s.id = us.skill_id
+        WHERE us.user_id = $1
+        ORDER BY us.position, s.name
+    `, userID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skills := []models.UserSkill{}
+	for rows.Next() {
+		var s models.UserSkill
+		if err := rows.Scan(&s.ID, &s.Name, &s.Category, &s.Level, &s.Endorsements, &s.EndorsedByMe); err != nil {
+			return nil, err
+		}
+		skills = append(skills, s)
+	}
+	return skills, rows.Err()
+}
+
+// loadProjectSkills derives skills from the programming tags of the
+// projects a user works on. Tags are resolved through the catalogue so
+// "golang" and "Go" count as one; unknown tags are kept as written.
+func loadProjectSkills(ctx context.Context, userID int) ([]models.SkillAggregate, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+        SELECT p.programming_tags, p.created_at
+        FROM projects p
+        JOIN project_members m ON m.project_id = p.id
+        WHERE m.user_id = $1 AND m.role IN ('owner', 'maintainer', 'developer')
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type projectTags struct {
+		keys    map[string]string
+		created time.Time
+	}
+	var projects []projectTags
+	allKeys := map[string]bool{}
+	for rows.Next() {
+		var tags []string
+		var pt projectTags
+		if err := rows.Scan(pq.Array(&tags), &pt.created); err != nil {
+			return nil, err
+		}
+		pt.keys = map[string]string{}
+		for _, tag := range tags {
+			if key := tagSlug(tag); key != "" {
+				pt.keys[key] = strings.TrimSpace(tag)
+				allKeys[key] = true
+			}
+		}
+		projects = append(projects, pt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	keys := make([]string, 0, len(allKeys))
+	for k := range allKeys {
+		keys = append(keys, k)
+	}
+	catalogue, err := lookupSkills(ctx, database.DB, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*models.SkillAggregate{}
+	for _, pt := range projects {
+		// A project tagged both "golang" and "Go" still counts once
+		counted := map[string]bool{}
+		for key, tag := range pt.keys {
+			agg := models.SkillAggregate{Name: tag}
+			if s, ok := catalogue[key]; ok {
+				id := s.ID
+				agg.SkillID = &id
+				agg.Name = s.Name
+				key = tagSlug(s.Name)
+			}
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+
+			existing, ok := byName[key]
+			if !ok {
+				existing = &agg
+				byName[key] = existing
+			}
+			existing.Projects++
+			if pt.created.After(existing.LastUsed) {
+				existing.LastUsed = pt.created
+			}
+		}
+	}
+
+	aggregates := make([]models.SkillAggregate, 0, len(byName))
+	for _, agg := range byName {
+		aggregates = append(aggregates, *agg)
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if aggregates[i].Projects != aggregates[j].Projects {
+			return aggregates[i].Projects > aggregates[j].Projects
+		}
+		return aggregates[i].Name < aggregates[j].Name
+	})
+	return aggregates, nil
+}
+
+// GetUserSkills lists the skills {username} lists along with those derived
+// from their projects
+func GetUserSkills(w http.ResponseWriter, r *http.Request) {
+	var userID int
+	err := database.DB.QueryRowContext(r.Context(), "SELECT id FROM users WHERE username = $1", mux.Vars(r)["username"]).Scan(&userID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	writeUserSkills(w, r, userID, getUserIDFromToken(r))
+}
+
+func writeUserSkills(w http.ResponseWriter, r *http.Request, userID, viewerID int) {
+	var response models.UserSkillsResponse
+	var err error
+	if response.Skills, err = loadUserSkills(r.Context(), userID, viewerID); err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if response.ProjectSkills, err = loadProjectSkills(r.Context(), userID); err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateMySkills replaces the caller's skill list. Names are resolved
+// through the catalogue and its aliases; names it doesn't know yet are
+// added to it unapproved, so one user's typo doesn't show up in everyone's
+// autocomplete. Endorsements survive for skills that stay on the list.
+func UpdateMySkills(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromToken(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.SkillsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Skills) > maxUserSkills {
+		http.Error(w, "Too many skills", http.StatusBadRequest)
+		return
+	}
+
+	keys := make([]string, 0, len(req.Skills))
+	for i, s := range req.Skills {
+		name := strings.Join(strings.Fields(s.Name), " ")
+		if name == "" {
+			http.Error(w, "Skill name is required", http.StatusBadRequest)
+			return
+		}
+		if len([]rune(name)) > maxSkillNameLength {
+			http.Error(w, "Skill name is too long", http.StatusBadRequest)
+			return
+		}
+		if s.Level == "" {
+			s.Level = levelIntermediate
+		}
+		if !skillLevels[s.Level] {
+			http.Error(w, "Invalid level for "+name, http.StatusBadRequest)
+			return
+		}
+		req.Skills[i] = models.SkillLevelRequest{Name: name, Level: s.Level}
+		keys = append(keys, tagSlug(name))
+	}
+
+	tx, err := database.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer tx.Rollback()
+
+	catalogue, err := lookupSkills(r.Context(), tx, keys)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	ids := []int{}
+	seen := map[int]bool{}
+	for i, s := range req.Skills {
+		skill, ok := catalogue[keys[i]]
+		if !ok {
+			err := tx.QueryRowContext(r.Context(), `
+                INSERT INTO skills (name, slug, approved) VALUES ($1, $2, FALSE)
+                ON CONFLICT (slug) DO UPDATE SET name = skills.name
+                RETURNING id
+            `, s.Name, keys[i]).Scan(&skill.ID)
+			if err != nil {
+				serverError(w, r, "Failed to save skills", err)
+				return
+			}
+			catalogue[keys[i]] = skill
+		}
+		// "golang" and "Go" resolve to the same skill; the first wins
+		if seen[skill.ID] {
+			continue
+		}
+		seen[skill.ID] = true
+
+		_, err := tx.ExecContext(r.Context(), `
+            INSERT INTO user_skills (user_id, skill_id, level, position) VALUES ($1, $2, $3, $4)
+            ON CONFLICT (user_id, skill_id) DO UPDATE SET level = EXCLUDED.level, position = EXCLUDED.position
+        `, userID, skill.ID, s.Level, len(ids))
+		if err != nil {
+			serverError(w, r, "Failed to save skills", err)
+			return
+		}
+		ids = append(ids, skill.ID)
+	}
+
+	_, err = tx.ExecContext(r.Context(),
+		"DELETE FROM user_skills WHERE user_id = $1 AND NOT (skill_id = ANY($2))", userID, pq.Array(ids),
+	)
+	if err != nil {
+		serverError(w, r, "Failed to save skills", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		serverError(w, r, "Failed to save skills", err)
+		return
+	}
+	writeUserSkills(w, r, userID, userID)
+}
+
+// HandleEndorsement endorses (POST) or withdraws an endorsement of (DELETE)
+// skill {skillId} of {username}. Only skills on the user's list can be
+// endorsed, and never by the user themselves.
+func HandleEndorsement(w http.ResponseWriter, r *http.Request) {
+	endorserID := getUserIDFromToken(r)
+	if endorserID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	skillID, err := strconv.Atoi(mux.Vars(r)["skillId"])
+	if err != nil {
+		http.Error(w, "Invalid skill ID", http.StatusBadRequest)
+		return
+	}
+
+	var userID int
+	var skillName string
+	err = database.DB.QueryRowContext(r.Context(), `
+        SELECT u.id, s.name
+        FROM users u
+        JOIN user_skills us ON us.user_id = u.id
+        JOIN skills s ON s.id = us.skill_id
+        WHERE u.username = $1 AND us.skill_id = $2
+    `, mux.Vars(r)["username"], skillID).Scan(&userID, &skillName)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Skill not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	if userID == endorserID {
+		http.Error(w, "You can't endorse your own skills", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		tx, err := database.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		defer tx.Rollback()
+
+		result, err := tx.ExecContext(r.Context(), `
+            INSERT INTO skill_endorsements (user_id, skill_id, endorser_id) VALUES ($1, $2, $3)
+            ON CONFLICT DO NOTHING
+        `, userID, skillID, endorserID)
+		if err != nil {
+			serverError(w, r, "Failed to endorse skill", err)
+			return
+		}
+		// Re-endorsing is a no-op and shouldn't notify again
+		if n, _ := result.RowsAffected(); n > 0 {
+			err = notify(r.Context(), tx, []int{userID}, models.Notification{
+				Type:    notifySkillEndorsed,
+				Actor:   &models.User{ID: endorserID},
+				Message: "endorsed you for " + skillName,
+			})
+			if err != nil {
+				serverError(w, r, "Failed to endorse skill", err)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			serverError(w, r, "Failed to endorse skill", err)
+			return
+		}
+
+	case http.MethodDelete:
+		_, err := database.DB.ExecContext(r.Context(),
+			"DELETE FROM skill_endorsements WHERE user_id = $1 AND skill_id = $2 AND endorser_id = $3",
+			userID, skillID, endorserID,
+		)
+		if err != nil {
+			serverError(w, r, "Failed to remove endorsement", err)
+			return
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var count int
+	err = database.DB.QueryRowContext(r.Context(),
+		"SELECT COUNT(*) FROM skill_endorsements WHERE user_id = $1 AND skill_id = $2", userID, skillID,
+	).Scan(&count)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.EndorsementResponse{
+		Endorsements: count,
+		EndorsedByMe: r.Method == http.MethodPost,
+	})
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Tag kinds, one per project tag column
+const (
+	tagGeneral     = "general"
+	tagProgramming = "programming"
+)
+
+const (
+	maxProjectTags   = 20
+	maxTagLength     = 50
+	tagSearchLimit   = 20
+	tagSearchDefault = 10
+)
+
+// tagSlug is the lookup form of a tag or skill name: lowercased with runs
+// of whitespace collapsed, so "Node JS " and "node js" resolve alike
+func tagSlug(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// tagColumn returns the projects column holding tags of kind
+func tagColumn(kind string) string {
+	if kind == tagProgramming {
+		return "programming_tags"
+	}
+	return "general_tags"
+}
+
+// cleanTags trims tags and collapses their whitespace, dropping empty ones.
+// It returns a message describing the first problem, if any.
+func cleanTags(tags []string) ([]string, string) {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, "Tag is too long: " + tag
+		}
+		if strings.Contains(tag, "/") {
+			return nil, "Tags can't contain '/'"
+		}
+		cleaned = append(cleaned, tag)
+	}
+	if len(cleaned) > maxProjectTags {
+		return nil, "Too many tags"
+	}
+	return cleaned, ""
+}
+
+// normalizeTags maps cleaned tags onto their canonical names, adding tags
+// the taxonomy doesn't know yet. Tags that resolve to the same canonical
+// tag are kept once, in first-seen order.
+func normalizeTags(ctx context.Context, tx *sql.Tx, kind string, tags []string) ([]string, error) {
+	slugs := make([]string, len(tags))
+	for i, tag := range tags {
+		slugs[i] = tagSlug(tag)
+	}
+	known, err := lookupTags(ctx, tx, kind, slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := []string{}
+	seen := map[int]bool{}
+	for i, tag := range tags {
+		t, ok := known[slugs[i]]
+		if !ok {
+			err := tx.QueryRowContext(ctx, `
+                INSERT INTO tags (name, slug, kind) VALUES ($1, $2, $3)
+                ON CONFLICT (kind, slug) DO UPDATE SET name = tags.name
+                RETURNING id, name
+            `, tag, slugs[i], kind).Scan(&t.ID, &t.Name)
+			if err != nil {
+				return nil, err
+			}
+			known[slugs[i]] = t
+		}
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			normalized = append(normalized, t.Name)
+		}
+	}
+	return normalized, nil
+}
+
+// lookupTags resolves slugs of kind against the taxonomy and its aliases.
+// Slugs that match nothing are left out of the result.
+func lookupTags(ctx context.Context, db queryer, kind string, slugs []string) (map[string]models.Tag, error) {
+	found := map[string]models.Tag{}
+	if len(slugs) == 0 {
+		return found, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `
+        SELECT k.key, t.id, t.name, t.slug, t.kind, t.category
+        FROM (
+            SELECT slug AS key, id AS tag_id FROM tags WHERE kind = $1
+            UNION ALL
+            SELECT alias, tag_id FROM tag_aliases WHERE kind = $1
+        ) k
+        JOIN tags t ON t.id = k.tag_id
+        WHERE k.key = ANY($2)
+    `, kind, pq.Array(slugs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var t models.Tag
+		if err := rows.Scan(&key, &t.ID, &t.Name, &t.Slug, &t.Kind, &t.Category); err != nil {
+			return nil, err
+		}
+		found[key] = t
+	}
+	return found, rows.Err()
+}
+
+// canonicalTagName resolves a tag as typed in a filter to its canonical
+// name in either column, falling back to the input when it's unknown
+func canonicalTagName(ctx context.Context, tag string) (string, error) {
+	var name string
+	err := database.DB.QueryRowContext(ctx, `
+        SELECT t.name
+        FROM tags t
+        LEFT JOIN tag_aliases a ON a.tag_id = t.id AND a.alias = $1
+        WHERE t.slug = $1 OR a.alias IS NOT NULL
+        ORDER BY t.slug = $1 DESC, t.kind = 'programming' DESC
+        LIMIT 1
+    `, tagSlug(tag)).Scan(&name)
+	if err == sql.ErrNoRows {
+		return tag, nil
+	}
+	return name, err
+}
+
+// SearchTags returns tags whose name or an alias starts with ?q=, most used
+// first, for autocomplete. ?kind= limits them to general or programming
+// tags; ?limit= defaults to 10, up to 20.
+func SearchTags(w http.ResponseWriter, r *http.Request) {
+	q := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(tagSlug(r.URL.Query().Get("q")))
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != tagGeneral && kind != tagProgramming {
+		http.Error(w, "kind must be general or programming", http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLimit(r, tagSearchDefault, tagSearchLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := database.DB.QueryContext(r.Context(), `
+        SELECT t.id, t.name, t.slug, t.kind, t.category, `+tagProjectsCountColumn+`
+        FROM tags t
+        WHERE ($2 = '' OR t.kind = $2)
+          AND (t.slug LIKE $1 || '%'
+               OR EXISTS (SELECT 1 FROM tag_aliases a WHERE a.tag_id = t.id AND a.alias LIKE $1 || '%'))
+        ORDER BY 6 DESC, t.name
+        LIMIT $3
+    `, q, kind, limit)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Slug, &t.Kind, &t.Category, &t.ProjectsCount); err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		tags = append(tags, t)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// tagProjectsCountColumn counts the projects using tag t
+const tagProjectsCountColumn = `(SELECT COUNT(*) FROM projects p
+            WHERE CASE t.kind WHEN 'programming' THEN p.programming_tags ELSE p.general_tags END @> ARRAY[t.name::TEXT])`
+
+// GetTagPage returns tag {slug} with its projects, newest first and
+// paginated by cursor. Aliases resolve to their canonical tag, whose slug
+// clients should redirect to. ?kind= picks between a general and a
+// programming tag of the same name.
+func GetTagPage(w http.ResponseWriter, r *http.Request) {
+	slug := tagSlug(mux.Vars(r)["slug"])
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != tagGeneral && kind != tagProgramming {
+		http.Error(w, "kind must be general or programming", http.StatusBadRequest)
+		return
+	}
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var page models.TagPage
+	t := &page.Tag
+	err = database.DB.QueryRowContext(r.Context(), `
+        SELECT t.id, t.name, t.slug, t.kind, t.category, `+tagProjectsCountColumn+`
+        FROM tags t
+        LEFT JOIN tag_aliases a ON a.tag_id = t.id AND a.alias = $1
+        WHERE (t.slug = $1 OR a.alias IS NOT NULL) AND ($2 = '' OR t.kind = $2)
+        ORDER BY t.slug = $1 DESC, t.kind = 'programming' DESC
+        LIMIT 1
+    `, slug, kind).Scan(&t.ID, &t.Name, &t.Slug, &t.Kind, &t.Category, &t.ProjectsCount)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+
+	query := `
+        SELECT p.id, p.name, p.description, p.code, p.general_tags, p.programming_tags, p.status, p.likes, p.created_at, p.images,
+               u.id, u.username, u.first_name, u.last_name, u.profile_picture, ` + commentsCountColumn + `
+        FROM projects p
+        JOIN users u ON p.user_id = u.id
+        WHERE p.` + tagColumn(t.Kind) + ` @> ARRAY[$1::TEXT]`
+	args := []interface{}{t.Name, limit + 1}
+	if cursor != nil {
+		query += ` AND (p.created_at, p.id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY p.created_at DESC, p.id DESC LIMIT $2`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		var p models.Project
+		var dev models.User
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Description, &p.Code, pq.Array(&p.GeneralTags), pq.Array(&p.ProgrammingTags),
+			&p.Status, &p.Likes, &p.CreatedAt, pq.Array(&p.Images),
+			&dev.ID, &dev.Username, &dev.FirstName, &dev.LastName, &dev.ProfilePicture, &p.CommentsCount,
+		); err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		p.Developer = &dev
+		projects = append(projects, p)
+	}
+	rows.Close()
+
+	page.Projects = projects
+	if len(projects) > limit {
+		last := projects[limit-1]
+		page.Projects = projects[:limit]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	fillProjectImages(r.Context(), projectPointers(page.Projects)...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
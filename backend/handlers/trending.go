@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"auth-app-backend/database"
+	"auth-app-backend/models"
+	"encoding/json"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+const (
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = maxPageSize
+)
+
+// GetTrendingProjects returns projects ordered by the score computed by
+// jobs.RecomputeTrending. ?tag= restricts the ranking to projects carrying
+// that general or programming tag; ?limit= caps the number returned.
+func GetTrendingProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, err := parseLimit(r, defaultTrendingLimit, maxTrendingLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := getUserIDFromToken(r)
+
+	query := `
+		SELECT t.score,
+		       p.id, p.user_id, p.name, p.description, p.code, p.general_tags, p.programming_tags,
+		       p.likes, p.status, p.created_at, p.images,
+		       u.username, u.first_name, u.last_name, u.profile_picture,
+		       (SELECT COUNT(*) FROM project_likes l WHERE l.project_id = p.id),
+		       ` + commentsCountColumn + `,
+		       EXISTS(SELECT 1 FROM project_saves s WHERE s.project_id = p.id AND s.user_id = $1),
+		       EXISTS(SELECT 1 FROM project_stars st WHERE st.project_id = p.id AND st.user_id = $1)
+		FROM project_trending t
+		JOIN projects p ON p.id = t.project_id
+		JOIN users u ON u.id = p.user_id`
+	args := []interface{}{userID, limit}
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		name, err := canonicalTagName(r.Context(), tag)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+		query += `
+		WHERE p.general_tags @> ARRAY[$3::TEXT] OR p.programming_tags @> ARRAY[$3::TEXT]`
+		args = append(args, name)
+	}
+	query += `
+		ORDER BY t.score DESC, p.id DESC
+		LIMIT $2`
+
+	rows, err := database.DB.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	defer rows.Close()
+
+	projects := []models.TrendingProject{}
+	for rows.Next() {
+		var p models.TrendingProject
+		var dev models.User
+		var genTags, progTags, images []string
+
+		err := rows.Scan(
+			&p.Score,
+			&p.ID, &p.UserID, &p.Name, &p.Description, &p.Code, pq.Array(&genTags), pq.Array(&progTags),
+			&p.Likes, &p.Status, &p.CreatedAt, pq.Array(&images),
+			&dev.Username, &dev.FirstName, &dev.LastName, &dev.ProfilePicture,
+			&p.LikesCount, &p.CommentsCount, &p.SavedByUser, &p.StarredByUser,
+		)
+		if err != nil {
+			serverError(w, r, "Internal server error", err)
+			return
+		}
+
+		if genTags == nil {
+			genTags = []string{}
+		}
+		if progTags == nil {
+			progTags = []string{}
+		}
+		if images == nil {
+			images = []string{}
+		}
+		p.GeneralTags = genTags
+		p.ProgrammingTags = progTags
+		p.Images = images
+		p.Developer = &dev
+		p.Rank = len(projects) + 1
+		projects = append(projects, p)
+	}
+
+	ptrs := make([]*models.Project, len(projects))
+	for i := range projects {
+		ptrs[i] = &projects[i].Project
+	}
+	fillProjectImages(r.Context(), ptrs...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(projects)
+}
@@ -7,9 +7,26 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	"github.com/lib/pq"
 )
 
+// GetDeveloperProfile serves /dev/{username}
+func GetDeveloperProfile(w http.ResponseWriter, r *http.Request) {
+	GetUserProfile(w, r, mux.Vars(r)["username"])
+}
+
+// GetDeveloperProjects serves /dev/{username}/projects
+func GetDeveloperProjects(w http.ResponseWriter, r *http.Request) {
+	GetUserProjects(w, r, mux.Vars(r)["username"])
+}
+
+// GetDeveloperProject serves /dev/{username}/{project}
+func GetDeveloperProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	GetProjectDetail(w, r, vars["username"], vars["project"], getUserIDFromToken(r))
+}
+
 func GetUserProfile(w http.ResponseWriter, r *http.Request, username string) {
 	if username == "" {
 		http.Error(w, "Username is required", http.StatusBadRequest)
@@ -32,7 +49,7 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request, username string) {
     `
 
 	var user models.User
-	err := database.DB.QueryRow(userQuery, username).Scan(
+	err := database.DB.QueryRowContext(r.Context(), userQuery, username).Scan(
 		&user.ID, &user.Username, &user.Email, &user.FirstName, &user.LastName, &user.Phone,
 		&user.UserType, &user.GithubLink, &user.PortfolioLink, &user.LinkedinLink, &user.CompanyName,
 		&user.ProfilePicture, &user.Banner, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
@@ -42,22 +59,22 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request, username string) {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	} else if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 
 	// Fetch user's projects in one query
 	projectsQuery := `
     SELECT p.id, p.name, p.description, p.code, p.general_tags, p.programming_tags, p.status, p.likes, p.created_at, p.images,
-           u.id, u.username, u.first_name, u.last_name, u.profile_picture
+           u.id, u.username, u.first_name, u.last_name, u.profile_picture, ` + commentsCountColumn + `
     FROM projects p
     JOIN users u ON p.user_id = u.id
     WHERE p.user_id = $1
     ORDER BY p.created_at DESC
     `
-	rows, err := database.DB.Query(projectsQuery, user.ID)
+	rows, err := database.DB.QueryContext(r.Context(), projectsQuery, user.ID)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 	defer rows.Close()
@@ -70,8 +87,9 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request, username string) {
 		if err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.Code, pq.Array(&genTags), pq.Array(&progTags),
 			&p.Status, &p.Likes, &p.CreatedAt, pq.Array(&images),
-			&dev.ID, &dev.Username, &dev.FirstName, &dev.LastName, &dev.ProfilePicture,
+			&dev.ID, &dev.Username, &dev.FirstName, &dev.LastName, &dev.ProfilePicture, &p.CommentsCount,
 		); err != nil {
+			logError(r.Context(), "Skipping unreadable project", err)
 			continue
 		}
 		p.GeneralTags = genTags
@@ -80,17 +98,26 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request, username string) {
 		p.Developer = &dev
 		projects = append(projects, p)
 	}
+	fillUserImages(r.Context(), &user)
+	fillProjectImages(r.Context(), projectPointers(projects)...)
 
-	// Response struct
-	type UserWithProjects struct {
-		models.User
-		Projects []models.Project `json:"projects"`
+	skills, err := loadUserSkills(r.Context(), user.ID, getUserIDFromToken(r))
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	projectSkills, err := loadProjectSkills(r.Context(), user.ID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(UserWithProjects{
-		User:     user,
-		Projects: projects,
+	json.NewEncoder(w).Encode(models.UserProfile{
+		User:          user,
+		Projects:      projects,
+		Skills:        skills,
+		ProjectSkills: projectSkills,
 	})
 }
 
@@ -100,18 +127,8 @@ func GetUserProjects(w http.ResponseWriter, r *http.Request, username string) {
 		return
 	}
 
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
-	// Handle preflight request
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
 	// Only allow GET method
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -120,9 +137,9 @@ func GetUserProjects(w http.ResponseWriter, r *http.Request, username string) {
 
 	// Verify user exists
 	var exists bool
-	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)", username).Scan(&exists)
+	err := database.DB.QueryRowContext(r.Context(), "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)", username).Scan(&exists)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 	if !exists {
@@ -136,7 +153,7 @@ func GetUserProjects(w http.ResponseWriter, r *http.Request, username string) {
 		SELECT p.id, p.name, p.description, p.code, p.general_tags, p.programming_tags, 
 			p.status, p.likes, p.created_at, p.images,
 			u.username, u.email, u.first_name, u.last_name, u.profile_picture,
-			COALESCE(l.likes_count, 0) as likes_count,
+			COALESCE(l.likes_count, 0) as likes_count, ` + commentsCountColumn + ` as comments_count,
 			CASE WHEN s.user_id IS NOT NULL THEN TRUE ELSE FALSE END as saved_by_user,
 			CASE WHEN st.user_id IS NOT NULL THEN TRUE ELSE FALSE END as starred_by_user
 		FROM projects p
@@ -155,9 +172,9 @@ func GetUserProjects(w http.ResponseWriter, r *http.Request, username string) {
 		WHERE p.user_id = (SELECT id FROM users WHERE username = $1) 
 		ORDER BY p.created_at DESC`
 
-	rows, err := database.DB.Query(query, username)
+	rows, err := database.DB.QueryContext(r.Context(), query, username)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, r, "Internal server error", err)
 		return
 	}
 	defer rows.Close()
@@ -172,10 +189,10 @@ func GetUserProjects(w http.ResponseWriter, r *http.Request, username string) {
 			pq.Array(&genTags), pq.Array(&progTags), &project.Status,
 			&project.Likes, &project.CreatedAt, pq.Array(&images),
 			&dev.Username, &dev.Email, &dev.FirstName, &dev.LastName, &dev.ProfilePicture,
-			&project.LikesCount, &project.SavedByUser, &project.StarredByUser,
+			&project.LikesCount, &project.CommentsCount, &project.SavedByUser, &project.StarredByUser,
 		)
 		if err != nil {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			serverError(w, r, "Internal server error", err)
 			return
 		}
 
@@ -201,6 +218,7 @@ func GetUserProjects(w http.ResponseWriter, r *http.Request, username string) {
 	if projects == nil {
 		projects = []models.Project{}
 	}
+	fillProjectImages(r.Context(), projectPointers(projects)...)
 
 	json.NewEncoder(w).Encode(projects)
 }
@@ -209,7 +227,7 @@ func GetProjectDetail(w http.ResponseWriter, r *http.Request, username, projectN
 	query := `
         SELECT p.id, p.user_id, p.name, p.description, p.code, p.general_tags, p.programming_tags, p.status, p.likes, p.created_at, p.images,
                u.username, u.email,
-               COALESCE(l.likes_count, 0),
+               COALESCE(l.likes_count, 0), ` + commentsCountColumn + `,
                CASE WHEN s.user_id IS NOT NULL THEN TRUE ELSE FALSE END AS saved_by_user,
                CASE WHEN st.user_id IS NOT NULL THEN TRUE ELSE FALSE END AS starred_by_user
         FROM projects p
@@ -228,11 +246,11 @@ func GetProjectDetail(w http.ResponseWriter, r *http.Request, username, projectN
 	var dev models.User
 	var genTags, progTags, images []string
 
-	err := database.DB.QueryRow(query, currentUserID, projectName, username).Scan(
+	err := database.DB.QueryRowContext(r.Context(), query, currentUserID, projectName, username).Scan(
 		&project.ID, &project.UserID, &project.Name, &project.Description, &project.Code,
 		pq.Array(&genTags), pq.Array(&progTags), &project.Status, &project.Likes, &project.CreatedAt, pq.Array(&images),
 		&dev.Username, &dev.Email,
-		&project.LikesCount, &project.SavedByUser, &project.StarredByUser,
+		&project.LikesCount, &project.CommentsCount, &project.SavedByUser, &project.StarredByUser,
 	)
 	if err != nil {
 		http.Error(w, "Project not found", http.StatusNotFound)
@@ -243,19 +261,47 @@ func GetProjectDetail(w http.ResponseWriter, r *http.Request, username, projectN
 	project.ProgrammingTags = progTags
 	project.Images = images
 	project.Developer = &dev
+	fillProjectImages(r.Context(), &project)
+
+	canvas, err := loadCurrentCanvas(r.Context(), database.DB, project.ID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	project.Canvas = canvas
+
+	progress, err := loadProjectProgress(r.Context(), project.ID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	project.Progress = progress
+
+	team, err := loadProjectTeam(r.Context(), project.ID)
+	if err != nil {
+		serverError(w, r, "Internal server error", err)
+		return
+	}
+	project.Team = team
+
+	// Views feed the trending score and analytics; a failure here shouldn't
+	// fail the page
+	if err := recordProjectView(r, project.ID, currentUserID); err != nil {
+		logError(r.Context(), "Failed to record project view", err)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(project)
 }
 
 func LikeProject(w http.ResponseWriter, r *http.Request, userID, projectID int) {
-	_, err := database.DB.Exec(`
+	_, err := database.DB.ExecContext(r.Context(), `
         INSERT INTO project_likes (user_id, project_id)
         VALUES ($1, $2)
         ON CONFLICT (user_id, project_id) DO NOTHING
     `, userID, projectID)
 	if err != nil {
-		http.Error(w, "Failed to like project", http.StatusInternalServerError)
+		serverError(w, r, "Failed to like project", err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -8,45 +8,62 @@ import (
 	"github.com/gorilla/mux"
 
 	"auth-app-backend/database"
+	"auth-app-backend/metrics"
+	"auth-app-backend/models"
 )
 
-type FollowResponse struct {
-	Success   bool   `json:"success"`
-	Message   string `json:"message"`
-	Following bool   `json:"following"`
-}
-
-type FollowStatusResponse struct {
-	Success   bool `json:"success"`
-	Following bool `json:"following"`
-}
-
+// FollowUser handles /users/{username}/follow. PUT follows and DELETE
+// unfollows; both are idempotent so retries are safe. POST follows too,
+// unless the legacy toggle behaviour is requested with ?toggle=true.
 func FollowUser(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
-	// Handle preflight request
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
+	switch r.Method {
+	case http.MethodPut:
+		followAction(w, r, followOn)
+	case http.MethodDelete:
+		followAction(w, r, followOff)
+	case http.MethodPost:
+		if r.URL.Query().Get("toggle") == "true" {
+			followAction(w, r, followToggle)
+		} else {
+			followAction(w, r, followOn)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Method not allowed"})
 	}
+}
 
-	// Only allow POST method for follow/unfollow
-	if r.Method != http.MethodPost {
+// UnfollowUser handles DELETE /users/{username}/unfollow, kept for clients
+// written against the older API
+func UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Method not allowed"})
+		json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Method not allowed"})
 		return
 	}
 
+	followAction(w, r, followOff)
+}
+
+type followMode int
+
+const (
+	followOn followMode = iota
+	followOff
+	followToggle
+)
+
+func followAction(w http.ResponseWriter, r *http.Request, mode followMode) {
 	// Extract username from URL
 	vars := mux.Vars(r)
 	username := vars["username"]
 	if username == "" {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Username required"})
+		json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Username required"})
 		return
 	}
 
@@ -54,20 +71,21 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromToken(r)
 	if userID == 0 {
 		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Unauthorized"})
+		json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Unauthorized"})
 		return
 	}
 
 	// Get the user to follow's ID from username
 	var targetUserID int
-	err := database.DB.QueryRow("SELECT id FROM users WHERE username = $1", username).Scan(&targetUserID)
+	err := database.DB.QueryRowContext(r.Context(), "SELECT id FROM users WHERE username = $1", username).Scan(&targetUserID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "User not found"})
+			json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "User not found"})
 		} else {
+			logError(r.Context(), "Database error", err)
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Database error"})
+			json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Database error"})
 		}
 		return
 	}
@@ -75,53 +93,66 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 	// Check if user is trying to follow themselves
 	if userID == targetUserID {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Cannot follow yourself"})
+		json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Cannot follow yourself"})
 		return
 	}
 
-	// Check if already following
-	var isFollowing bool
-	err = database.DB.QueryRow(
-		"SELECT EXISTS(SELECT 1 FROM followers WHERE follower_id = $1 AND following_id = $2)",
-		userID, targetUserID,
-	).Scan(&isFollowing)
+	if mode == followToggle {
+		var isFollowing bool
+		err = database.DB.QueryRowContext(r.Context(),
+			"SELECT EXISTS(SELECT 1 FROM followers WHERE follower_id = $1 AND following_id = $2)",
+			userID, targetUserID,
+		).Scan(&isFollowing)
+		if err != nil {
+			logError(r.Context(), "Database error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Database error"})
+			return
+		}
 
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Database error"})
-		return
+		mode = followOn
+		if isFollowing {
+			mode = followOff
+		}
 	}
 
-	var response FollowResponse
+	var response models.FollowResponse
 
-	if isFollowing {
-		// Unfollow the user
-		_, err = database.DB.Exec(
+	if mode == followOff {
+		// Unfollow the user; deleting a missing row is not an error
+		_, err = database.DB.ExecContext(r.Context(),
 			"DELETE FROM followers WHERE follower_id = $1 AND following_id = $2",
 			userID, targetUserID,
 		)
 		if err != nil {
+			logError(r.Context(), "Failed to unfollow", err)
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Failed to unfollow"})
+			json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Failed to unfollow"})
 			return
 		}
-		response = FollowResponse{
+		response = models.FollowResponse{
 			Success:   true,
 			Message:   "Successfully unfollowed",
 			Following: false,
 		}
 	} else {
-		// Follow the user
-		_, err = database.DB.Exec(
-			"INSERT INTO followers (follower_id, following_id) VALUES ($1, $2)",
+		// Follow the user; the unique constraint makes concurrent
+		// requests collapse into a single row
+		res, err := database.DB.ExecContext(r.Context(),
+			`INSERT INTO followers (follower_id, following_id) VALUES ($1, $2)
+			ON CONFLICT (follower_id, following_id) DO NOTHING`,
 			userID, targetUserID,
 		)
 		if err != nil {
+			logError(r.Context(), "Failed to follow", err)
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(FollowResponse{Success: false, Message: "Failed to follow"})
+			json.NewEncoder(w).Encode(models.FollowResponse{Success: false, Message: "Failed to follow"})
 			return
 		}
-		response = FollowResponse{
+		if n, _ := res.RowsAffected(); n > 0 {
+			metrics.Follows.Inc()
+		}
+		response = models.FollowResponse{
 			Success:   true,
 			Message:   "Successfully followed",
 			Following: true,
@@ -133,22 +164,12 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 
 // CheckFollowStatus checks if the current user is following the target user
 func CheckFollowStatus(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
-	// Handle preflight request
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
 	// Only allow GET method
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(FollowStatusResponse{Success: false, Following: false})
+		json.NewEncoder(w).Encode(models.FollowStatusResponse{Success: false, Following: false})
 		return
 	}
 
@@ -157,7 +178,7 @@ func CheckFollowStatus(w http.ResponseWriter, r *http.Request) {
 	username := vars["username"]
 	if username == "" {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(FollowStatusResponse{Success: false, Following: false})
+		json.NewEncoder(w).Encode(models.FollowStatusResponse{Success: false, Following: false})
 		return
 	}
 
@@ -165,40 +186,41 @@ func CheckFollowStatus(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromToken(r)
 	if userID == 0 {
 		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(FollowStatusResponse{Success: false, Following: false})
+		json.NewEncoder(w).Encode(models.FollowStatusResponse{Success: false, Following: false})
 		return
 	}
 
 	// Get the target user's ID from username
 	var targetUserID int
-	err := database.DB.QueryRow("SELECT id FROM users WHERE username = $1", username).Scan(&targetUserID)
+	err := database.DB.QueryRowContext(r.Context(), "SELECT id FROM users WHERE username = $1", username).Scan(&targetUserID)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(FollowStatusResponse{Success: false, Following: false})
+		json.NewEncoder(w).Encode(models.FollowStatusResponse{Success: false, Following: false})
 		return
 	}
 
 	// Check if user is trying to check their own follow status
 	if userID == targetUserID {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(FollowStatusResponse{Success: false, Following: false})
+		json.NewEncoder(w).Encode(models.FollowStatusResponse{Success: false, Following: false})
 		return
 	}
 
 	// Check if following
 	var isFollowing bool
-	err = database.DB.QueryRow(
+	err = database.DB.QueryRowContext(r.Context(),
 		"SELECT EXISTS(SELECT 1 FROM followers WHERE follower_id = $1 AND following_id = $2)",
 		userID, targetUserID,
 	).Scan(&isFollowing)
 
 	if err != nil {
+		logError(r.Context(), "Failed to check follow status", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(FollowStatusResponse{Success: false, Following: false})
+		json.NewEncoder(w).Encode(models.FollowStatusResponse{Success: false, Following: false})
 		return
 	}
 
-	response := FollowStatusResponse{
+	response := models.FollowStatusResponse{
 		Success:   true,
 		Following: isFollowing,
 	}
@@ -0,0 +1,200 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Limits applied to every uploaded image
+const (
+	MaxFileSize  = 10 << 20 // 10MB
+	MaxDimension = 8000
+	MaxPixels    = 40_000_000
+	MinDimension = 16
+)
+
+// Longest side of each generated variant
+const (
+	OriginalSize  = 2048
+	MediumSize    = 800
+	ThumbnailSize = 200
+)
+
+const jpegQuality = 85
+
+// ErrInvalid is wrapped by every error caused by the upload itself rather
+// than by the server
+var ErrInvalid = errors.New("invalid image")
+
+var (
+	ErrTooLarge    = fmt.Errorf("%w: file is larger than %dMB", ErrInvalid, MaxFileSize>>20)
+	ErrUnsupported = fmt.Errorf("%w: only JPEG, PNG, GIF and WebP are supported", ErrInvalid)
+)
+
+// Variant is one re-encoded rendition of an upload
+type Variant struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Processed holds every rendition generated from a single upload
+type Processed struct {
+	Original  Variant
+	Medium    Variant
+	Thumbnail Variant
+}
+
+// DetectFormat identifies an image from its magic bytes, ignoring whatever
+// Content-Type the client claimed
+func DetectFormat(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg", nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png", nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif", nil
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "webp", nil
+	}
+	return "", ErrUnsupported
+}
+
+// Process validates an upload and re-encodes it into the original, medium
+// and thumbnail variants. Re-encoding from decoded pixels drops EXIF and any
+// other metadata or trailing payload the file carried, so the EXIF
+// orientation is applied to the pixels first.
+func Process(data []byte) (*Processed, error) {
+	if len(data) > MaxFileSize {
+		return nil, ErrTooLarge
+	}
+
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the header before decoding so oversized images are rejected
+	// without allocating their pixels
+	cfg, err := decodeConfig(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: corrupt %s data", ErrInvalid, format)
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds the %dpx limit", ErrInvalid, cfg.Width, cfg.Height, MaxDimension)
+	}
+	if cfg.Width < MinDimension || cfg.Height < MinDimension {
+		return nil, fmt.Errorf("%w: must be at least %dx%d pixels", ErrInvalid, MinDimension, MinDimension)
+	}
+
+	img, err := decode(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: corrupt %s data", ErrInvalid, format)
+	}
+	orientation := exifOrientation(format, data)
+
+	p := &Processed{}
+	for _, v := range []struct {
+		dst  *Variant
+		size int
+	}{
+		{&p.Original, OriginalSize},
+		{&p.Medium, MediumSize},
+		{&p.Thumbnail, ThumbnailSize},
+	} {
+		*v.dst, err = encode(orient(fit(img, v.size), orientation))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func decodeConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "jpeg":
+		return jpeg.DecodeConfig(r)
+	case "png":
+		return png.DecodeConfig(r)
+	case "gif":
+		return gif.DecodeConfig(r)
+	case "webp":
+		return webp.DecodeConfig(r)
+	}
+	return image.Config{}, ErrUnsupported
+}
+
+// decode reads the image; animated GIFs keep only their first frame
+func decode(format string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "jpeg":
+		return jpeg.Decode(r)
+	case "png":
+		return png.Decode(r)
+	case "gif":
+		return gif.Decode(r)
+	case "webp":
+		return webp.Decode(r)
+	}
+	return nil, ErrUnsupported
+}
+
+// fit scales img down so its longest side is at most size, keeping the
+// aspect ratio. Smaller images are copied as they are.
+func fit(img image.Image, size int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w > size || h > size {
+		if w >= h {
+			h = max(1, h*size/w)
+			w = size
+		} else {
+			w = max(1, w*size/h)
+			h = size
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+	}
+	return dst
+}
+
+// encode writes opaque images as JPEG and images with transparency as PNG
+func encode(img *image.NRGBA) (Variant, error) {
+	var buf bytes.Buffer
+	v := Variant{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	if img.Opaque() {
+		v.ContentType = "image/jpeg"
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return v, err
+		}
+	} else {
+		v.ContentType = "image/png"
+		if err := png.Encode(&buf, img); err != nil {
+			return v, err
+		}
+	}
+
+	v.Data = buf.Bytes()
+	return v, nil
+}
@@ -0,0 +1,339 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// solid returns a w by h image split into a red left half and a blue right
+// half, fully opaque unless alpha is lower
+func solid(w, h int, alpha uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: alpha}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: alpha}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// exifTIFF builds EXIF TIFF data holding an Orientation tag and an extra
+// ASCII tag carrying note
+func exifTIFF(order binary.ByteOrder, orientation uint16, note string) []byte {
+	var b bytes.Buffer
+	if order == binary.LittleEndian {
+		b.WriteString("II")
+	} else {
+		b.WriteString("MM")
+	}
+	binary.Write(&b, order, uint16(42))
+	binary.Write(&b, order, uint32(8))
+
+	binary.Write(&b, order, uint16(2))
+	// Orientation, SHORT
+	binary.Write(&b, order, uint16(0x0112))
+	binary.Write(&b, order, uint16(3))
+	binary.Write(&b, order, uint32(1))
+	binary.Write(&b, order, orientation)
+	binary.Write(&b, order, uint16(0))
+	// ImageDescription, ASCII, stored after the IFD
+	binary.Write(&b, order, uint16(0x010E))
+	binary.Write(&b, order, uint16(2))
+	binary.Write(&b, order, uint32(len(note)+1))
+	binary.Write(&b, order, uint32(8+2+2*12+4))
+	binary.Write(&b, order, uint32(0))
+	b.WriteString(note + "\x00")
+	return b.Bytes()
+}
+
+// withExif inserts an Exif APP1 segment right after a JPEG's SOI marker
+func withExif(jpg, tiff []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	out = append(out, payload...)
+	return append(out, jpg[2:]...)
+}
+
+// pngHeader is a PNG signature and IHDR claiming w by h pixels, with no
+// pixel data behind it
+func pngHeader(w, h uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint32(ihdr[8:], h)
+	ihdr[12], ihdr[13] = 8, 6 // 8-bit RGBA
+
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = binary.BigEndian.AppendUint32(out, 13)
+	out = append(out, ihdr...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, "jpeg"},
+		{"png", []byte("\x89PNG\r\n\x1a\nrest"), "png"},
+		{"gif87a", []byte("GIF87a..."), "gif"},
+		{"gif89a", []byte("GIF89a..."), "gif"},
+		{"webp", []byte("RIFF\x10\x00\x00\x00WEBPVP8 "), "webp"},
+		{"riff that isn't webp", []byte("RIFF\x10\x00\x00\x00WAVEfmt "), ""},
+		{"bmp", []byte("BM\x00\x00"), ""},
+		{"svg", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\">"), ""},
+		{"truncated jpeg", []byte{0xFF, 0xD8}, ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.data)
+			if got != tt.want {
+				t.Errorf("DetectFormat = %q, want %q", got, tt.want)
+			}
+			if (err != nil) != (tt.want == "") || err != nil && !errors.Is(err, ErrUnsupported) {
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"over the byte limit", make([]byte, MaxFileSize+1), ErrTooLarge},
+		{"unsupported format", []byte("BM not an image at all"), ErrUnsupported},
+		{"too wide", pngHeader(MaxDimension+1, 100), ErrInvalid},
+		{"too tall", pngHeader(100, MaxDimension+1), ErrInvalid},
+		{"too many pixels", pngHeader(7000, 7000), ErrInvalid},
+		{"too small", pngHeader(MinDimension-1, 100), ErrInvalid},
+		{"header without pixels", pngHeader(100, 100), ErrInvalid},
+		{"corrupt header", []byte("\x89PNG\r\n\x1a\ngarbage"), ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Process(tt.data)
+			if !errors.Is(err, tt.wantErr) || p != nil {
+				t.Errorf("Process = %v, %v; want %v", p, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProcessLimitsAreInclusive(t *testing.T) {
+	for _, size := range [][2]int{{MinDimension, MinDimension}, {MaxDimension, MinDimension}} {
+		if _, err := Process(encodePNG(t, image.NewGray(image.Rect(0, 0, size[0], size[1])))); err != nil {
+			t.Errorf("%dx%d: %v", size[0], size[1], err)
+		}
+	}
+}
+
+func TestProcessVariantSizes(t *testing.T) {
+	type size struct{ w, h int }
+	tests := []struct {
+		name                        string
+		w, h                        int
+		original, medium, thumbnail size
+	}{
+		{"landscape", 3000, 1500, size{2048, 1024}, size{800, 400}, size{200, 100}},
+		{"portrait", 1000, 3000, size{682, 2048}, size{266, 800}, size{66, 200}},
+		{"square between sizes", 1000, 1000, size{1000, 1000}, size{800, 800}, size{200, 200}},
+		{"small stays as it is", 100, 50, size{100, 50}, size{100, 50}, size{100, 50}},
+		{"thin keeps a pixel", 4000, 16, size{2048, 8}, size{800, 3}, size{200, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Process(encodePNG(t, solid(tt.w, tt.h, 255)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range []struct {
+				name string
+				got  Variant
+				want size
+			}{
+				{"original", p.Original, tt.original},
+				{"medium", p.Medium, tt.medium},
+				{"thumbnail", p.Thumbnail, tt.thumbnail},
+			} {
+				if v.got.Width != v.want.w || v.got.Height != v.want.h {
+					t.Errorf("%s is %dx%d, want %dx%d", v.name, v.got.Width, v.got.Height, v.want.w, v.want.h)
+				}
+				cfg, _, err := image.DecodeConfig(bytes.NewReader(v.got.Data))
+				if err != nil || cfg.Width != v.want.w || cfg.Height != v.want.h {
+					t.Errorf("%s data decodes as %dx%d (%v)", v.name, cfg.Width, cfg.Height, err)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+		want string
+	}{
+		{"opaque png becomes jpeg", func(t *testing.T) []byte { return encodePNG(t, solid(64, 64, 255)) }, "image/jpeg"},
+		{"transparent png stays png", func(t *testing.T) []byte { return encodePNG(t, solid(64, 64, 128)) }, "image/png"},
+		{"jpeg", func(t *testing.T) []byte { return encodeJPEG(t, solid(64, 64, 255)) }, "image/jpeg"},
+		{"gif", func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			if err := gif.Encode(&buf, solid(64, 64, 255), nil); err != nil {
+				t.Fatal(err)
+			}
+			return buf.Bytes()
+		}, "image/jpeg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Process(tt.data(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range []Variant{p.Original, p.Medium, p.Thumbnail} {
+				if v.ContentType != tt.want {
+					t.Errorf("content type %q, want %q", v.ContentType, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	const secret = "GPS 51.5007N 0.1246W"
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"jpeg exif", withExif(encodeJPEG(t, solid(64, 64, 255)), exifTIFF(binary.BigEndian, 1, secret))},
+		{"trailing payload", append(encodePNG(t, solid(64, 64, 255)), []byte(secret)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Contains(tt.data, []byte(secret)) {
+				t.Fatal("the upload doesn't carry the metadata")
+			}
+			p, err := Process(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range []Variant{p.Original, p.Medium, p.Thumbnail} {
+				if bytes.Contains(v.Data, []byte(secret)) || bytes.Contains(v.Data, []byte("Exif\x00")) {
+					t.Errorf("%dx%d variant kept the metadata", v.Width, v.Height)
+				}
+			}
+		})
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	jpg := encodeJPEG(t, solid(32, 16, 255))
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", jpg, 1},
+		{"big endian", withExif(jpg, exifTIFF(binary.BigEndian, 6, "")), 6},
+		{"little endian", withExif(jpg, exifTIFF(binary.LittleEndian, 8, "")), 8},
+		{"out of range", withExif(jpg, exifTIFF(binary.BigEndian, 9, "")), 1},
+		{"truncated tiff", withExif(jpg, exifTIFF(binary.BigEndian, 6, "")[:12]), 1},
+		{"not tiff", withExif(jpg, []byte("XX\x00\x2a\x00\x00\x00\x08")), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation("jpeg", tt.data); got != tt.want {
+				t.Errorf("orientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	tiff := exifTIFF(binary.LittleEndian, 3, "x")
+	webp = append(webp, "EXIF"...)
+	webp = binary.LittleEndian.AppendUint32(webp, uint32(len(tiff)))
+	webp = append(webp, tiff...)
+	if got := exifOrientation("webp", webp); got != 3 {
+		t.Errorf("webp orientation = %d, want 3", got)
+	}
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	// Stored 40x20 with red on the left and blue on the right
+	jpg := encodeJPEG(t, solid(40, 20, 255))
+
+	isRed := func(c color.Color) bool { r, _, b, _ := c.RGBA(); return r > 0xC000 && b < 0x4000 }
+	isBlue := func(c color.Color) bool { r, _, b, _ := c.RGBA(); return b > 0xC000 && r < 0x4000 }
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		// points expected red and blue once upright
+		red, blue image.Point
+	}{
+		{1, 40, 20, image.Pt(5, 10), image.Pt(35, 10)},
+		{2, 40, 20, image.Pt(35, 10), image.Pt(5, 10)},
+		{3, 40, 20, image.Pt(35, 10), image.Pt(5, 10)},
+		{4, 40, 20, image.Pt(5, 10), image.Pt(35, 10)},
+		{5, 20, 40, image.Pt(10, 5), image.Pt(10, 35)},
+		{6, 20, 40, image.Pt(10, 5), image.Pt(10, 35)},
+		{7, 20, 40, image.Pt(10, 35), image.Pt(10, 5)},
+		{8, 20, 40, image.Pt(10, 35), image.Pt(10, 5)},
+	}
+	for _, tt := range tests {
+		p, err := Process(withExif(jpg, exifTIFF(binary.BigEndian, uint16(tt.orientation), "")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Original.Width != tt.w || p.Original.Height != tt.h {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", tt.orientation, p.Original.Width, p.Original.Height, tt.w, tt.h)
+			continue
+		}
+		img, err := jpeg.Decode(bytes.NewReader(p.Original.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isRed(img.At(tt.red.X, tt.red.Y)) || !isBlue(img.At(tt.blue.X, tt.blue.Y)) {
+			t.Errorf("orientation %d: %v is %v and %v is %v, want red and blue",
+				tt.orientation, tt.red, img.At(tt.red.X, tt.red.Y), tt.blue, img.At(tt.blue.X, tt.blue.Y))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// exifOrientation reads the EXIF Orientation tag of a JPEG or WebP, 1
+// (upright) when there is none. Phone cameras store pixels as the sensor
+// saw them and record the rotation here.
+func exifOrientation(format string, data []byte) int {
+	var tiff []byte
+	switch format {
+	case "jpeg":
+		tiff = jpegExif(data)
+	case "webp":
+		tiff = webpExif(data)
+	}
+	return tiffOrientation(tiff)
+}
+
+// jpegExif returns the TIFF data of a JPEG's Exif APP1 segment
+func jpegExif(data []byte) []byte {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte
+			i++
+			continue
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD7:
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// Image data starts; metadata comes before it
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i = end
+	}
+	return nil
+}
+
+// webpExif returns the TIFF data of a WebP's EXIF chunk
+func webpExif(data []byte) []byte {
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size
+		if end > len(data) {
+			return nil
+		}
+		if string(data[i:i+4]) == "EXIF" {
+			// Some writers keep the JPEG-style prefix
+			return bytes.TrimPrefix(data[i+8:end], []byte("Exif\x00\x00"))
+		}
+		i = end + size%2
+	}
+	return nil
+}
+
+// tiffOrientation finds tag 0x0112 in the first IFD of EXIF TIFF data
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for e := ifd + 2; e+12 <= len(tiff) && count > 0; e, count = e+12, count-1 {
+		if order.Uint16(tiff[e:]) != 0x0112 {
+			continue
+		}
+		// A SHORT, stored in the first bytes of the value field
+		if o := int(order.Uint16(tiff[e+8:])); order.Uint16(tiff[e+2:]) == 3 && o >= 1 && o <= 8 {
+			return o
+		}
+		return 1
+	}
+	return 1
+}
+
+// orient turns img upright according to an EXIF orientation: 2-4 mirror
+// or turn it half way, 5-8 turn it a quarter and swap its sides
+func orient(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				sx, sy = x, h-1-y
+			case 5: // mirrored, turned left
+				sx, sy = y, x
+			case 6: // turned left, so turn right
+				sx, sy = y, h-1-x
+			case 7: // mirrored, turned right
+				sx, sy = w-1-y, h-1-x
+			case 8: // turned right, so turn left
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], img.Pix[img.PixOffset(sx, sy):img.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
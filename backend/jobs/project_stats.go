@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"auth-app-backend/database"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RecomputeProjectStats rebuilds the project_daily_stats rows of every day
+// from since onwards. Likes, saves and stars count the ones still in place
+// by the day they were given; follows gained are new followers of the
+// project's owner, credited in full to each of their projects since a
+// follow isn't tied to any one of them.
+func RecomputeProjectStats(ctx context.Context, since time.Time) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM project_daily_stats WHERE day >= $1::DATE", since); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		WITH events AS (
+			SELECT project_id, created_at::DATE AS day, 'view' AS kind, viewer_key FROM project_views
+			WHERE created_at >= $1::DATE
+			UNION ALL
+			SELECT project_id, created_at::DATE, 'like', NULL FROM project_likes
+			WHERE created_at >= $1::DATE
+			UNION ALL
+			SELECT project_id, created_at::DATE, 'save', NULL FROM project_saves
+			WHERE created_at >= $1::DATE
+			UNION ALL
+			SELECT project_id, created_at::DATE, 'star', NULL FROM project_stars
+			WHERE created_at >= $1::DATE
+			UNION ALL
+			SELECT p.id, f.created_at::DATE, 'follow', NULL FROM followers f
+			JOIN projects p ON p.user_id = f.following_id
+			WHERE f.created_at >= $1::DATE
+		)
+		INSERT INTO project_daily_stats (project_id, day, views, unique_viewers, likes, saves, stars, follows_gained)
+		SELECT project_id, day,
+		       COUNT(*) FILTER (WHERE kind = 'view'),
+		       COUNT(DISTINCT viewer_key) FILTER (WHERE kind = 'view'),
+		       COUNT(*) FILTER (WHERE kind = 'like'),
+		       COUNT(*) FILTER (WHERE kind = 'save'),
+		       COUNT(*) FILTER (WHERE kind = 'star'),
+		       COUNT(*) FILTER (WHERE kind = 'follow')
+		FROM events
+		GROUP BY project_id, day`, since)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// StartProjectStats keeps the daily project stats current. The first run
+// catches up from the last day already aggregated, or from the beginning;
+// after that each tick of interval recomputes yesterday and today. It
+// blocks, so run it in its own goroutine.
+func StartProjectStats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var since time.Time
+	var last sql.NullTime
+	err := runTraced("ReadProjectStats", func(ctx context.Context) error {
+		return database.DB.QueryRowContext(ctx, "SELECT MAX(day) FROM project_daily_stats").Scan(&last)
+	})
+	if err == nil && last.Valid {
+		since = last.Time
+	}
+
+	for {
+		err := runTraced("RecomputeProjectStats", func(ctx context.Context) error {
+			return RecomputeProjectStats(ctx, since)
+		})
+		if err == nil {
+			// Late events of the previous day are picked up on the next run
+			since = time.Now().UTC().AddDate(0, 0, -1)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("auth-app-backend/jobs")
+
+// runTraced runs one pass of a job in its own trace, so its queries are
+// traced as they are within requests, and logs its failure
+func runTraced(name string, job func(ctx context.Context) error) error {
+	ctx, span := tracer.Start(context.Background(), name)
+	defer span.End()
+
+	err := job(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "Job failed", "job", name, "err", err)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"auth-app-backend/database"
+	"context"
+	"time"
+)
+
+// Weights of each kind of interaction in the trending score
+const (
+	trendingLikeWeight    = 1.0
+	trendingSaveWeight    = 2.0
+	trendingStarWeight    = 2.0
+	trendingCommentWeight = 3.0
+	trendingViewWeight    = 0.1
+)
+
+// An interaction loses half its weight every trendingHalfLife, and anything
+// older than trendingWindow is ignored entirely
+const (
+	trendingHalfLife = 48 * time.Hour
+	trendingWindow   = 14 * 24 * time.Hour
+)
+
+// RecomputeTrending rebuilds the project_trending table. Every like, save,
+// star, comment and view inside the window contributes its weight decayed
+// exponentially by age, so recent activity outranks old popularity.
+func RecomputeTrending(ctx context.Context) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM project_trending"); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		WITH events AS (
+			SELECT project_id, created_at, $1::DOUBLE PRECISION AS weight FROM project_likes
+			WHERE created_at > NOW() - $6 * INTERVAL '1 second'
+			UNION ALL
+			SELECT project_id, created_at, $2 FROM project_saves
+			WHERE created_at > NOW() - $6 * INTERVAL '1 second'
+			UNION ALL
+			SELECT project_id, created_at, $3 FROM project_stars
+			WHERE created_at > NOW() - $6 * INTERVAL '1 second'
+			UNION ALL
+			SELECT project_id, created_at, $4 FROM project_comments
+			WHERE deleted_at IS NULL AND created_at > NOW() - $6 * INTERVAL '1 second'
+			UNION ALL
+			SELECT project_id, created_at, $5 FROM project_views
+			WHERE created_at > NOW() - $6 * INTERVAL '1 second'
+		)
+		INSERT INTO project_trending (project_id, score, computed_at)
+		SELECT project_id,
+		       SUM(weight * EXP(-LN(2) * EXTRACT(EPOCH FROM (NOW() - created_at)) / $7)),
+		       NOW()
+		FROM events
+		GROUP BY project_id`,
+		trendingLikeWeight, trendingSaveWeight, trendingStarWeight, trendingCommentWeight, trendingViewWeight,
+		trendingWindow.Seconds(), trendingHalfLife.Seconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// StartTrending recomputes the trending ranking immediately and then on
+// every tick of interval. It blocks, so run it in its own goroutine.
+func StartTrending(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runTraced("RecomputeTrending", RecomputeTrending)
+		<-ticker.C
+	}
+}
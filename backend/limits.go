@@ -0,0 +1,40 @@
+package main
+
+import (
+	"time"
+
+	"auth-app-backend/openapi"
+	"auth-app-backend/ratelimit"
+)
+
+// Rate limits of the routes open to abuse: guessing passwords, mass
+// sign-ups and follow/save spam. Versioned and unversioned routes share
+// buckets.
+var (
+	loginLimit  = ratelimit.Policy{Name: "login", Limit: 5, Period: time.Minute, Burst: 10, By: ratelimit.ByIP}
+	signupLimit = ratelimit.Policy{Name: "signup", Limit: 5, Period: time.Hour, By: ratelimit.ByIP}
+	followLimit = ratelimit.Policy{Name: "follow", Limit: 30, Period: time.Minute, By: ratelimit.ByUser}
+	saveLimit   = ratelimit.Policy{Name: "save", Limit: 60, Period: time.Minute, By: ratelimit.ByUser}
+
+	// defaultLimit applies to every other route
+	defaultLimit = ratelimit.Policy{Name: "api", Limit: 600, Period: time.Minute, Burst: 120, By: ratelimit.ByUser}
+)
+
+var rateLimits = map[string]ratelimit.Policy{
+	"POST /login":                       loginLimit,
+	"POST /signup":                      signupLimit,
+	"PUT /users/{username}/follow":      followLimit,
+	"POST /users/{username}/follow":     followLimit,
+	"DELETE /users/{username}/follow":   followLimit,
+	"DELETE /users/{username}/unfollow": followLimit,
+	"POST /projects/{id}/save":          saveLimit,
+	"DELETE /projects/{id}/save":        saveLimit,
+}
+
+// rateLimitFor returns the policy of a route
+func rateLimitFor(route openapi.Route) ratelimit.Policy {
+	if p, ok := rateLimits[route.Method+" "+route.Path]; ok {
+		return p
+	}
+	return defaultLimit
+}
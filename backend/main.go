@@ -1,164 +1,166 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"auth-app-backend/cors"
 	"auth-app-backend/database"
 	"auth-app-backend/handlers"
-	"auth-app-backend/utils"
+	"auth-app-backend/jobs"
+	"auth-app-backend/metrics"
+	"auth-app-backend/openapi"
+	"auth-app-backend/ratelimit"
+	"auth-app-backend/storage"
+	"auth-app-backend/telemetry"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	// Structured logs, and traces exported as OTEL_TRACES_EXPORTER says
+	telemetry.SetupLogging()
+	shutdownTracing, err := telemetry.SetupTracing(context.Background())
+	if err != nil {
+		slog.Error("Error setting up tracing", "err", err)
+		os.Exit(1)
+	}
+
 	// Connect to Database
 	database.Connect()
+	metrics.RegisterDB(database.DB)
 
-	// Helper function to extract user ID from JWT token
-	getUserIDFromToken := func(r *http.Request) int {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			return 0
-		}
+	// Set up file storage for uploads
+	storage.Init()
 
-		// Remove "Bearer " prefix
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			// No Bearer prefix found
-			return 0
-		}
+	// Keep the trending ranking fresh in the background
+	go jobs.StartTrending(10 * time.Minute)
 
-		// Validate token and extract user ID
-		claims, err := utils.ValidateToken(tokenString)
-		if err != nil {
-			return 0
-		}
+	// Aggregate daily project stats for the owners' analytics
+	go jobs.StartProjectStats(10 * time.Minute)
 
-		return claims.UserID
+	// Throttle abusable routes; RATE_LIMIT_STORE=redis shares the limits
+	// between instances
+	limiter, err := ratelimit.New()
+	if err != nil {
+		slog.Error("Error setting up rate limiting", "err", err)
+		os.Exit(1)
 	}
+	router := newRouter(apiRoutes(), limiter, os.Getenv("API_CONTRACT_CHECK"))
 
-	// CORS Middleware
-	corsMiddleware := func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000") // your frontend
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-			// ⚡ This handles the preflight request
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next(w, r) // normal requests go to your handler
-		}
+	// Start Server
+	port := ":8080"
+	// CORS is answered for the whole router, before routing, so
+	// preflights never need a route of their own. CORS_ALLOWED_ORIGINS
+	// lists the frontends allowed to call the API; CORS_ALLOW_CREDENTIALS
+	// lets them send cookies. Every request, even one no route matches,
+	// is logged and traced.
+	server := &http.Server{
+		Addr:    port,
+		Handler: telemetry.Middleware(cors.New(cors.ConfigFromEnv(), router)),
 	}
 
-	// Setup Routes with mux
-	router := mux.NewRouter()
-
-	// Global CORS middleware that handles all OPTIONS requests
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-
-			w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-			// Handle preflight
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	})
-
-	// Health check
-	router.HandleFunc("/health", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, "Server is running!")
-	})).Methods("GET")
-
-	// Auth routes
-	router.HandleFunc("/login", handlers.Login).Methods("POST", "OPTIONS")
-	router.HandleFunc("/signup", handlers.Signup).Methods("POST", "OPTIONS")
-
-	router.HandleFunc("/logout", corsMiddleware(handlers.Logout)).Methods("POST")
-	router.HandleFunc("/validate", corsMiddleware(handlers.ValidateToken)).Methods("GET")
-	router.HandleFunc("/profile/update", corsMiddleware(handlers.UpdateProfile)).Methods("PUT")
-
-	// Project routes
-	router.HandleFunc("/projects", corsMiddleware(handlers.GetProjects)).Methods("GET")
-	router.HandleFunc("/projects/create", corsMiddleware(handlers.CreateProject)).Methods("POST")
-	router.HandleFunc("/projects/saved", corsMiddleware(handlers.GetSavedProjects)).Methods("GET")
-	router.HandleFunc("/projects/{id}", corsMiddleware(handlers.HandleProjectActions)).Methods("POST", "DELETE")
-
-	// Follow route
-	router.HandleFunc("/users/{username}/follow", corsMiddleware(handlers.FollowUser)).Methods("POST")
-	router.HandleFunc("/users/{username}/follow/status", corsMiddleware(handlers.CheckFollowStatus)).Methods("GET")
-
-	// Developer profile routes
-	router.PathPrefix("/dev").HandlerFunc(corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Extract user ID from JWT token
-		userID := getUserIDFromToken(r)
-
-		// Trim "/dev/" prefix
-		path := strings.TrimPrefix(r.URL.Path, "/dev/")
-		path = strings.TrimSuffix(path, "/") // remove trailing slash
-		parts := strings.Split(path, "/")
-
-		username := parts[0]
+	// Prometheus metrics get a listener of their own, on METRICS_ADDR, so
+	// they stay off the public port. It only listens locally by default;
+	// set METRICS_ADDR=:9090 to let a scraper on the network reach it, or
+	// off to disable it.
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	if metricsAddr == "" {
+		metricsAddr = "localhost:9090"
+	}
+	var metricsServer *http.Server
+	if metricsAddr != "off" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler())
+		metricsServer = &http.Server{Addr: metricsAddr, Handler: metricsMux}
+	}
 
-		if username == "" {
-			http.Error(w, "Username is required", http.StatusBadRequest)
-			return
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		slog.Info("Server starting", "port", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server failed to start", "err", err)
+			os.Exit(1)
 		}
+	}()
+	if metricsServer != nil {
+		go func() {
+			slog.Info("Metrics server starting", "addr", metricsAddr)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Metrics server failed to start", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	<-stop.Done()
 
-		// /dev/:username/projects
-		if len(parts) == 2 && parts[1] == "projects" {
-			handlers.GetUserProjects(w, r, username)
-			return
+	// Finish the requests in flight and flush their spans before exiting
+	ctx, done := context.WithTimeout(context.Background(), 10*time.Second)
+	defer done()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Server shutdown failed", "err", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			slog.Error("Metrics server shutdown failed", "err", err)
 		}
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Flushing traces failed", "err", err)
+	}
+}
 
-		// /dev/:username/:project
-		if len(parts) == 2 {
-			projectName := parts[1]
-			handlers.GetProjectDetail(w, r, username, projectName, userID)
-			return
-		}
+// newRouter serves routes under apiPrefix and as deprecated unversioned
+// aliases, along with the OpenAPI document and uploaded files.
+// contractMode is one of the openapi.Check modes.
+func newRouter(routes []openapi.Route, limiter *ratelimit.Limiter, contractMode string) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(telemetry.Route)
+
+	// Versioned API, described by an OpenAPI document generated from the
+	// same route table. API_CONTRACT_CHECK=log or strict checks responses
+	// against it during development.
+	spec := openapi.Generate(openapi.Info{
+		Title:   "Auth App API",
+		Version: "1.0.0",
+	}, apiPrefix, routes)
+	router.Handle(apiPrefix+"/openapi.json", spec).Methods("GET")
+
+	api := router.PathPrefix(apiPrefix).Subrouter()
+	api.Use(spec.Contract(apiPrefix, contractMode))
+	for _, route := range routes {
+		api.HandleFunc(route.Path, limiter.Handler(rateLimitFor(route), route.Handler)).Methods(route.Method)
+	}
 
-		// /dev/:username
-		handlers.GetUserProfile(w, r, username)
-	})).Methods("GET")
+	// Business model export, where the frontend has always linked to it
+	router.HandleFunc("/api/projects/{code}/business-model.{format:pdf|pptx}", deprecated(handlers.ExportBusinessModel)).Methods("GET")
 
-	router.HandleFunc("/users/{username}/follow", corsMiddleware(handlers.FollowUser)).Methods("POST")
+	// Unversioned routes stay as deprecated aliases until clients move over
+	for _, route := range routes {
+		router.HandleFunc(route.Path, deprecated(limiter.Handler(rateLimitFor(route), route.Handler))).Methods(route.Method)
+	}
 
-	router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// Uploaded files
+	router.PathPrefix(storage.URLPrefix).HandlerFunc(handlers.ServeMedia).Methods("GET", "HEAD")
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		http.NotFound(w, r)
-	})
+	return router
+}
 
-	// Start Server
-	port := ":8080"
-	fmt.Printf("Server starting on port %s\n", port)
-	if err := http.ListenAndServe(port, router); err != nil {
-		log.Fatal("Server failed to start: ", err)
+// deprecated marks responses of unversioned routes, pointing clients at the
+// same path under apiPrefix
+func deprecated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+path+`>; rel="successor-version"`)
+		next(w, r)
 	}
 }
@@ -0,0 +1,105 @@
+// Package metrics exposes the server's Prometheus metrics: HTTP traffic,
+// the database pool, business events and the Go runtime.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric served on /metrics. It's separate from the
+// global registry so dependencies can't add metrics behind our back.
+var Registry = prometheus.NewRegistry()
+
+// HTTP traffic, labelled by route template rather than path so the number
+// of series stays bounded
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Business events
+var (
+	Signups = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "signups_total",
+		Help: "Accounts created.",
+	})
+
+	Logins = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "logins_total",
+		Help: "Successful logins.",
+	})
+
+	// LoginFailures is labelled with why the login was refused:
+	// unknown_email or wrong_password
+	LoginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "login_failures_total",
+		Help: "Logins refused for bad credentials, by reason.",
+	}, []string{"reason"})
+
+	ProjectsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "projects_created_total",
+		Help: "Projects created.",
+	})
+
+	Follows = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "follows_total",
+		Help: "Users followed, not counting repeated follows of the same user.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		Signups, Logins, LoginFailures, ProjectsCreated, Follows,
+	)
+}
+
+// RegisterDB adds the connection pool stats of db, as reported by
+// db.Stats(). Call it once the database is connected.
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// methods are the request methods kept as label values. Clients can send
+// any token as a method, so the rest are counted as OTHER.
+var methods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true,
+	http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// ObserveRequest records a served request. Requests no route matched are
+// grouped under the "unmatched" route, and non-standard methods under
+// OTHER.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	if !methods[method] {
+		method = "OTHER"
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
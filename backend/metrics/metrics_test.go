@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveRequestLabels(t *testing.T) {
+	tests := []struct {
+		method, route         string
+		wantMethod, wantRoute string
+	}{
+		{"GET", "/api/v1/projects", "GET", "/api/v1/projects"},
+		{"PATCH", "/api/v1/projects/{id}", "PATCH", "/api/v1/projects/{id}"},
+		{"GET", "", "GET", "unmatched"},
+		{"BREW", "", "OTHER", "unmatched"},
+		{"get", "/api/v1/projects", "OTHER", "/api/v1/projects"},
+		{"X-RANDOM-1234", "/api/v1/health", "OTHER", "/api/v1/health"},
+	}
+	for _, tt := range tests {
+		counter := httpRequests.WithLabelValues(tt.wantMethod, tt.wantRoute, "200")
+		before := testutil.ToFloat64(counter)
+		ObserveRequest(tt.method, tt.route, 200, time.Millisecond)
+		if got := testutil.ToFloat64(counter) - before; got != 1 {
+			t.Errorf("ObserveRequest(%q, %q) added %v to %s %s", tt.method, tt.route, got, tt.wantMethod, tt.wantRoute)
+		}
+		if tt.wantMethod != tt.method && httpRequests.DeleteLabelValues(tt.method, tt.wantRoute, "200") {
+			t.Errorf("a series was labelled with method %q", tt.method)
+		}
+	}
+}
@@ -21,10 +21,58 @@ type User struct {
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 
+	ProfilePictureVariants *ImageVariants `json:"profile_picture_variants,omitempty"`
+	BannerVariants         *ImageVariants `json:"banner_variants,omitempty"`
+
 	FollowersCount int `json:"followers"`
 	FollowingCount int `json:"following"`
 }
 
+// UserProfile is a developer's public profile page
+type UserProfile struct {
+	User
+	Projects      []Project        `json:"projects"`
+	Skills        []UserSkill      `json:"skills"`
+	ProjectSkills []SkillAggregate `json:"project_skills"`
+}
+
+// ImageVariants are the renditions generated for an uploaded image
+type ImageVariants struct {
+	Original  string `json:"original"`
+	Medium    string `json:"medium,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+}
+
+// ProjectImages is the image state of a project returned by the image
+// management endpoints
+type ProjectImages struct {
+	Images        []string        `json:"images"`
+	ImageVariants []ImageVariants `json:"image_variants"`
+	CoverImage    string          `json:"cover_image,omitempty"`
+}
+
+// ImageOrderRequest lists all of a project's image URLs in their new order
+type ImageOrderRequest struct {
+	Images []string `json:"images"`
+}
+
+type CoverImageRequest struct {
+	URL string `json:"url"`
+}
+
+// CreateProjectRequest is the body of a new project. Images are uploaded
+// once it exists, through the project's image routes.
+type CreateProjectRequest struct {
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	Code            string   `json:"code"`
+	GeneralTags     []string `json:"general_tags"`
+	ProgrammingTags []string `json:"programming_tags"`
+	Status          string   `json:"status"`
+}
+
 type Project struct {
 	ID              int       `json:"id"`
 	UserID          int       `json:"user_id"`
@@ -40,12 +88,455 @@ type Project struct {
 
 	// Support up to 9 images
 	Images []string `json:"images"`
+	// ImageVariants lines up with Images; entries for images that were not
+	// uploaded through the image pipeline only carry the original URL
+	ImageVariants []ImageVariants `json:"image_variants"`
+	// CoverImage is the designated cover, defaulting to the first image
+	CoverImage string `json:"cover_image,omitempty"`
+	// Canvas is the current business model canvas, only on the detail view
+	Canvas *ProjectCanvas `json:"canvas,omitempty"`
+	// Progress is derived from the project's milestones, only on the detail view
+	Progress *Progress `json:"progress,omitempty"`
+	// Team lists the project's members, only on the detail view
+	Team []ProjectMember `json:"team,omitempty"`
 
 	LikesCount    int  `json:"likes_count"`
+	CommentsCount int  `json:"comments_count"`
 	SavedByUser   bool `json:"saved_by_user"`
 	StarredByUser bool `json:"starred_by_user"`
 }
 
+// TrendingProject is a project ranked by its time-decayed engagement score
+type TrendingProject struct {
+	Project
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+}
+
+// BusinessModelCanvas is the structured business model of a project, one
+// list of points per block of the canvas
+type BusinessModelCanvas struct {
+	KeyPartners           []string `json:"key_partners"`
+	KeyActivities         []string `json:"key_activities"`
+	KeyResources          []string `json:"key_resources"`
+	ValuePropositions     []string `json:"value_propositions"`
+	CustomerRelationships []string `json:"customer_relationships"`
+	Channels              []string `json:"channels"`
+	CustomerSegments      []string `json:"customer_segments"`
+	CostStructure         []string `json:"cost_structure"`
+	RevenueStreams        []string `json:"revenue_streams"`
+}
+
+// ProjectCanvas is one saved version of a project's business model canvas.
+// Every edit creates a new version; the highest one is current.
+type ProjectCanvas struct {
+	BusinessModelCanvas
+	ProjectID int       `json:"project_id"`
+	Version   int       `json:"version"`
+	EditedBy  *User     `json:"edited_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Deleted marks the version that cleared the canvas
+	Deleted bool `json:"deleted"`
+	// Changes lists the blocks that differ from the previous version
+	Changes []string `json:"changes"`
+}
+
+// CanvasRequest replaces the canvas. BaseVersion, when set, must be the
+// current version so concurrent edits don't silently overwrite each other.
+type CanvasRequest struct {
+	BusinessModelCanvas
+	BaseVersion *int `json:"base_version,omitempty"`
+}
+
+type CanvasHistoryResponse struct {
+	Versions   []ProjectCanvas `json:"versions"`
+	NextBefore *int            `json:"next_before,omitempty"`
+}
+
+// Milestone is a goal on a project's roadmap
+type Milestone struct {
+	ID          int    `json:"id"`
+	ProjectID   int    `json:"project_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// DueDate is a calendar date, YYYY-MM-DD
+	DueDate     *string    `json:"due_date,omitempty"`
+	Overdue     bool       `json:"overdue"`
+	State       string     `json:"state"`
+	Assignee    *User      `json:"assignee,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// MilestoneRequest creates or updates a milestone. On update, fields left
+// out are unchanged; an empty due_date or an assignee_id of 0 clears them.
+type MilestoneRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	DueDate     *string `json:"due_date"`
+	State       *string `json:"state"`
+	AssigneeID  *int    `json:"assignee_id"`
+}
+
+// Progress summarises milestone completion. Cancelled milestones don't count.
+type Progress struct {
+	Total   int `json:"total"`
+	Done    int `json:"done"`
+	Percent int `json:"percent"`
+}
+
+type MilestoneListResponse struct {
+	Milestones []Milestone `json:"milestones"`
+	Progress   Progress    `json:"progress"`
+}
+
+// ProjectStatus is a project's current status and where it can move next
+type ProjectStatus struct {
+	Status             string   `json:"status"`
+	AllowedTransitions []string `json:"allowed_transitions"`
+}
+
+type StatusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// Activity is an entry in a project's timeline
+type Activity struct {
+	ID             int       `json:"id"`
+	ProjectID      int       `json:"project_id"`
+	Type           string    `json:"type"`
+	Actor          *User     `json:"actor,omitempty"`
+	MilestoneID    *int      `json:"milestone_id,omitempty"`
+	MilestoneTitle string    `json:"milestone_title,omitempty"`
+	From           string    `json:"from,omitempty"`
+	To             string    `json:"to,omitempty"`
+	Note           string    `json:"note,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ActivityListResponse struct {
+	Activity   []Activity `json:"activity"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// ProjectMember is a user on a project's team
+type ProjectMember struct {
+	User     User      `json:"user"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// Invitation asks a user to join a project's team with a role
+type Invitation struct {
+	ID          int        `json:"id"`
+	Project     *Project   `json:"project"`
+	Inviter     *User      `json:"inviter,omitempty"`
+	Invitee     *User      `json:"invitee"`
+	Role        string     `json:"role"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+type InvitationRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// Application is a developer's request to join a project's team
+type Application struct {
+	ID                int        `json:"id"`
+	Project           *Project   `json:"project"`
+	Applicant         *User      `json:"applicant"`
+	Message           string     `json:"message"`
+	PortfolioProjects []Project  `json:"portfolio_projects"`
+	Status            string     `json:"status"`
+	DecisionNote      string     `json:"decision_note,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	DecidedAt         *time.Time `json:"decided_at,omitempty"`
+}
+
+type ApplicationRequest struct {
+	Message             string `json:"message"`
+	PortfolioProjectIDs []int  `json:"portfolio_project_ids"`
+}
+
+type ApplicationDecisionRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type ApplicationListResponse struct {
+	Applications []Application `json:"applications"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// Notification tells a user about something that happened involving them
+type Notification struct {
+	ID            int       `json:"id"`
+	Type          string    `json:"type"`
+	Actor         *User     `json:"actor,omitempty"`
+	Project       *Project  `json:"project,omitempty"`
+	ApplicationID *int      `json:"application_id,omitempty"`
+	Message       string    `json:"message"`
+	Read          bool      `json:"read"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+	UnreadCount   int            `json:"unread_count"`
+}
+
+// MarkReadRequest lists notifications to mark read; empty means all
+type MarkReadRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// ProjectRecommendation is a project suggested to a developer, with the
+// reasons it matched
+type ProjectRecommendation struct {
+	Project     Project  `json:"project"`
+	Score       float64  `json:"score"`
+	Reasons     []string `json:"reasons"`
+	MatchedTags []string `json:"matched_tags"`
+}
+
+// DeveloperRecommendation is a developer suggested for a project, with the
+// reasons they matched
+type DeveloperRecommendation struct {
+	User        User     `json:"user"`
+	Score       float64  `json:"score"`
+	Reasons     []string `json:"reasons"`
+	MatchedTags []string `json:"matched_tags"`
+}
+
+// DailyStats are a project's numbers for one day, or totals over a range
+// when Date is empty
+type DailyStats struct {
+	Date          string `json:"date,omitempty"`
+	Views         int    `json:"views"`
+	UniqueViewers int    `json:"unique_viewers"`
+	Likes         int    `json:"likes"`
+	Saves         int    `json:"saves"`
+	Stars         int    `json:"stars"`
+	// FollowsGained counts new followers of the project's owner. People
+	// are followed, not projects, so every project of an owner reports the
+	// same number and it must not be summed across projects.
+	FollowsGained int `json:"follows_gained"`
+}
+
+type ProjectAnalytics struct {
+	From   string       `json:"from"`
+	To     string       `json:"to"`
+	Totals DailyStats   `json:"totals"`
+	Series []DailyStats `json:"series"`
+}
+
+// Tag is an entry of the tag taxonomy. Kind is "general" or
+// "programming", matching the project column the tag is used in.
+type Tag struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Slug          string `json:"slug"`
+	Kind          string `json:"kind"`
+	Category      string `json:"category"`
+	ProjectsCount int    `json:"projects_count"`
+}
+
+// TagPage is a tag with a page of the projects using it
+type TagPage struct {
+	Tag        Tag       `json:"tag"`
+	Projects   []Project `json:"projects"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// Skill is an entry of the skills catalogue
+type Skill struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// UserSkill is a skill someone lists on their profile
+type UserSkill struct {
+	Skill
+	Level        string `json:"level"`
+	Endorsements int    `json:"endorsements"`
+	EndorsedByMe bool   `json:"endorsed_by_me"`
+}
+
+// SkillAggregate is a skill derived from the programming tags of the
+// projects someone works on. SkillID is nil for tags not in the catalogue.
+type SkillAggregate struct {
+	SkillID  *int      `json:"skill_id"`
+	Name     string    `json:"name"`
+	Projects int       `json:"projects"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+type UserSkillsResponse struct {
+	Skills        []UserSkill      `json:"skills"`
+	ProjectSkills []SkillAggregate `json:"project_skills"`
+}
+
+type SkillLevelRequest struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+type SkillsRequest struct {
+	Skills []SkillLevelRequest `json:"skills"`
+}
+
+type EndorsementResponse struct {
+	Endorsements int  `json:"endorsements"`
+	EndorsedByMe bool `json:"endorsed_by_me"`
+}
+
+// InvestorProfile describes what an investor looks for. Ticket sizes are
+// whole units of Currency.
+type InvestorProfile struct {
+	User      *User     `json:"user,omitempty"`
+	TicketMin *int64    `json:"ticket_min"`
+	TicketMax *int64    `json:"ticket_max"`
+	Currency  string    `json:"currency"`
+	Sectors   []string  `json:"sectors"`
+	Stages    []string  `json:"stages"`
+	Thesis    string    `json:"thesis"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+type InvestorProfileRequest struct {
+	TicketMin *int64   `json:"ticket_min"`
+	TicketMax *int64   `json:"ticket_max"`
+	Currency  string   `json:"currency"`
+	Sectors   []string `json:"sectors"`
+	Stages    []string `json:"stages"`
+	Thesis    string   `json:"thesis"`
+}
+
+// InvestmentInterest is an investor's deal on a project as it moves through
+// their pipeline. NotesCount only counts the caller's own notes, so it's
+// left at zero when the project's team lists its investors.
+type InvestmentInterest struct {
+	ID              int              `json:"id"`
+	Project         *Project         `json:"project"`
+	Investor        *User            `json:"investor,omitempty"`
+	InvestorProfile *InvestorProfile `json:"investor_profile,omitempty"`
+	Stage           string           `json:"stage"`
+	Amount          *int64           `json:"amount"`
+	Message         string           `json:"message"`
+	NotesCount      int              `json:"notes_count"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+type InterestRequest struct {
+	Amount  *int64 `json:"amount"`
+	Message string `json:"message"`
+}
+
+type DealUpdateRequest struct {
+	Stage  *string `json:"stage"`
+	Amount *int64  `json:"amount"`
+}
+
+// DealNote is a private note an investor keeps on a deal
+type DealNote struct {
+	ID        int       `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DealNoteRequest struct {
+	Body string `json:"body"`
+}
+
+// Comment is feedback left on a project. Top-level comments carry their
+// replies; replies never nest further. Deleted comments keep their place in
+// the thread but lose their body and author.
+type Comment struct {
+	ID         int       `json:"id"`
+	ProjectID  int       `json:"project_id"`
+	ParentID   *int      `json:"parent_id,omitempty"`
+	Author     *User     `json:"author,omitempty"`
+	Body       string    `json:"body"`
+	Mentions   []string  `json:"mentions"`
+	Deleted    bool      `json:"deleted"`
+	Edited     bool      `json:"edited"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ReplyCount int       `json:"reply_count"`
+	Replies    []Comment `json:"replies,omitempty"`
+}
+
+type CommentRequest struct {
+	Body     string `json:"body"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type CommentListResponse struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// FeedItem is a single entry in the following feed: a project posted, liked
+// or starred by someone the current user follows
+type FeedItem struct {
+	Type      string    `json:"type"`
+	Actor     User      `json:"actor"`
+	Project   Project   `json:"project"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedResponse struct {
+	Items      []FeedItem `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type FollowResponse struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Following bool   `json:"following"`
+}
+
+type FollowStatusResponse struct {
+	Success   bool `json:"success"`
+	Following bool `json:"following"`
+}
+
+// FollowEntry is a user in a followers/following list, annotated with the
+// relationship between that user and the caller
+type FollowEntry struct {
+	User        User      `json:"user"`
+	FollowedAt  time.Time `json:"followed_at"`
+	IsFollowing bool      `json:"is_following"`
+	FollowsYou  bool      `json:"follows_you"`
+	Mutual      bool      `json:"mutual"`
+}
+
+type FollowListResponse struct {
+	Users      []FollowEntry `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// SuggestedUser is a person the caller may want to follow, ranked by how many
+// of the caller's followings already follow them
+type SuggestedUser struct {
+	User        User     `json:"user"`
+	MutualCount int      `json:"mutual_count"`
+	FollowedBy  []string `json:"followed_by"`
+}
+
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -69,3 +560,10 @@ type AuthResponse struct {
 	Token string `json:"token"`
 	User  User   `json:"user"`
 }
+
+// GraphQLRequest is the body of a POST to /graphql
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
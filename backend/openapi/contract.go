@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Contract check modes, picked with the API_CONTRACT_CHECK environment
+// variable
+const (
+	// CheckOff serves responses untouched
+	CheckOff = ""
+	// CheckLog logs responses that diverge from the document
+	CheckLog = "log"
+	// CheckStrict replaces diverging responses with a 500 describing the
+	// mismatch, so they can't go unnoticed in development
+	CheckStrict = "strict"
+)
+
+// Contract returns middleware checking every response served under prefix
+// against the document. Responses are buffered while checking, so keep it
+// off in production.
+func (d *Document) Contract(prefix, mode string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if mode == CheckOff {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || !strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// net/http sniffs the type of responses that don't set one;
+			// check what clients will actually receive
+			contentType := rec.header.Get("Content-Type")
+			if contentType == "" && rec.body.Len() > 0 {
+				contentType = http.DetectContentType(rec.body.Bytes())
+			}
+
+			path := strings.TrimPrefix(r.URL.Path, prefix)
+			err := d.Validate(r.Method, path, rec.status, contentType, rec.body.Bytes())
+			if err != nil {
+				slog.ErrorContext(r.Context(), "API contract violation", "err", err)
+				if mode == CheckStrict {
+					http.Error(w, "API contract violation: "+err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+
+			for k, v := range rec.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// recorder buffers a response so it can be checked before it's sent
+type recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
@@ -0,0 +1,343 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document. The
+// document is generated from the same route table main registers, with
+// schemas reflected from the Go types handlers decode and encode, so the
+// two can't drift apart. Validate checks real responses against it.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version documents are written in
+const Version = "3.0.3"
+
+// Route is one operation of the API. Path is a gorilla/mux template;
+// variables with a pattern such as {format:pdf|pptx} are documented as
+// enums.
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+
+	Summary string
+	Tag     string
+	// Auth marks operations that need a bearer token
+	Auth  bool
+	Query []Param
+	// Body is a zero value of the JSON request body, if any
+	Body interface{}
+	// Form lists the fields of a multipart/form-data body, if any
+	Form      []Param
+	Responses []Response
+}
+
+// Param is a query parameter or form field
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// Response is one documented outcome of an operation. Body is a zero value
+// of the JSON body; ContentTypes replace JSON for other bodies.
+type Response struct {
+	Status       int
+	Body         interface{}
+	ContentTypes []string
+}
+
+// JSON documents a JSON response of body's type
+func JSON(status int, body interface{}) Response {
+	return Response{Status: status, Body: body}
+}
+
+// NoContent documents an empty response
+func NoContent() Response {
+	return Response{Status: http.StatusNoContent}
+}
+
+// Raw documents a non-JSON response in one of contentTypes
+func Raw(status int, contentTypes ...string) Response {
+	return Response{Status: status, ContentTypes: contentTypes}
+}
+
+// Query parameter helpers shared by many routes
+var (
+	CursorParam = Param{Name: "cursor", Type: "string", Description: "Opaque cursor from a previous page's next_cursor"}
+	LimitParam  = Param{Name: "limit", Type: "integer", Description: "Page size"}
+)
+
+// Document is an OpenAPI document. Only the parts this API uses are
+// modelled.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	matchers []pathMatcher
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lowercase HTTP methods to operations
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*RespSpec  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type RespSpec struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Schema is the subset of JSON Schema used by the generated document
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+const bearerScheme = "bearerAuth"
+
+// Generate builds the document for routes served under server
+func Generate(info Info, server string, routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Servers: []Server{{URL: server}},
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	gen := &generator{schemas: doc.Components.Schemas}
+
+	for _, route := range routes {
+		path, pathParams := parseTemplate(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		op := &Operation{
+			OperationID: operationID(route.Method, path),
+			Summary:     route.Summary,
+			Responses:   map[string]*RespSpec{},
+		}
+		if route.Tag != "" {
+			op.Tags = []string{route.Tag}
+		}
+		if route.Auth {
+			op.Security = []map[string][]string{{bearerScheme: {}}}
+		}
+		op.Parameters = append(op.Parameters, pathParams...)
+		for _, p := range route.Query {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: p.Name, In: "query", Description: p.Description, Required: p.Required, Schema: p.schema(),
+			})
+		}
+
+		if route.Body != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+				"application/json": {Schema: gen.schemaOf(route.Body)},
+			}}
+		} else if len(route.Form) > 0 {
+			form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			for _, p := range route.Form {
+				form.Properties[p.Name] = p.schema()
+				if p.Required {
+					form.Required = append(form.Required, p.Name)
+				}
+			}
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+				"multipart/form-data": {Schema: form},
+			}}
+		}
+
+		for _, resp := range route.Responses {
+			spec := &RespSpec{Description: http.StatusText(resp.Status)}
+			switch {
+			case resp.Body != nil:
+				spec.Content = map[string]*MediaType{"application/json": {Schema: gen.schemaOf(resp.Body)}}
+			case len(resp.ContentTypes) > 0:
+				spec.Content = map[string]*MediaType{}
+				for _, ct := range resp.ContentTypes {
+					spec.Content[ct] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+				}
+			}
+			op.Responses[strconv.Itoa(resp.Status)] = spec
+		}
+
+		(*item)[strings.ToLower(route.Method)] = op
+	}
+
+	doc.compile()
+	return doc
+}
+
+func (p Param) schema() *Schema {
+	s := &Schema{Type: p.Type, Enum: p.Enum}
+	if s.Type == "" {
+		s.Type = "string"
+	}
+	if s.Type == "file" {
+		s.Type, s.Format = "string", "binary"
+	}
+	return s
+}
+
+var (
+	templateVar = regexp.MustCompile(`\{([^{}:]+)(?::([^{}]+))?\}`)
+	enumPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\|[A-Za-z0-9_-]+)*$`)
+)
+
+// parseTemplate turns a mux path template into an OpenAPI path and its
+// path parameters
+func parseTemplate(template string) (string, []Parameter) {
+	var params []Parameter
+	path := templateVar.ReplaceAllStringFunc(template, func(v string) string {
+		m := templateVar.FindStringSubmatch(v)
+		schema := &Schema{Type: "string"}
+		if m[2] != "" && enumPattern.MatchString(m[2]) {
+			schema.Enum = strings.Split(m[2], "|")
+		}
+		params = append(params, Parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+		return "{" + m[1] + "}"
+	})
+	return path, params
+}
+
+// operationID derives a stable identifier such as getProjectsIdCanvas
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// pathMatcher matches concrete request paths against a documented path
+type pathMatcher struct {
+	path    string
+	pattern *regexp.Regexp
+}
+
+// compile prepares the path matchers used by Validate. Literal paths are
+// tried before templated ones so /projects/saved isn't taken for
+// /projects/{id}.
+func (d *Document) compile() {
+	d.matchers = d.matchers[:0]
+	for path := range d.Paths {
+		pattern := "^" + templateVar.ReplaceAllString(quoteLiterals(path), `[^/]+`) + "$"
+		d.matchers = append(d.matchers, pathMatcher{path: path, pattern: regexp.MustCompile(pattern)})
+	}
+	sort.Slice(d.matchers, func(i, j int) bool {
+		vi, vj := strings.Count(d.matchers[i].path, "{"), strings.Count(d.matchers[j].path, "{")
+		if vi != vj {
+			return vi < vj
+		}
+		return d.matchers[i].path < d.matchers[j].path
+	})
+}
+
+// quoteLiterals escapes regexp metacharacters outside of {variables}
+func quoteLiterals(path string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range templateVar.FindAllStringIndex(path, -1) {
+		b.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		b.WriteString(path[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(path[last:]))
+	return b.String()
+}
+
+// Find returns the documented path and operation serving method and path,
+// where path is relative to the server URL
+func (d *Document) Find(method, path string) (string, *Operation) {
+	if d.matchers == nil {
+		d.compile()
+	}
+	for _, m := range d.matchers {
+		if !m.pattern.MatchString(path) {
+			continue
+		}
+		if op := (*d.Paths[m.path])[strings.ToLower(method)]; op != nil {
+			return m.path, op
+		}
+	}
+	return "", nil
+}
+
+// ServeHTTP serves the document as JSON
+func (d *Document) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
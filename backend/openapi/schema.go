@@ -0,0 +1,163 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// generator reflects Go types into schemas, registering named structs as
+// components so they are described once and referenced everywhere
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func (g *generator) schemaOf(v interface{}) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// schema describes how encoding/json writes values of t. Nil slices, maps
+// and pointers encode as null, so those are nullable.
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Kind() != reflect.Ptr && (t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType)):
+		// Custom encodings can't be described by reflection
+		return &Schema{}
+	case t.Kind() != reflect.Ptr && (t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		inner := g.schema(t.Elem())
+		if inner.Ref != "" {
+			return &Schema{AllOf: []*Schema{inner}, Nullable: true}
+		}
+		inner.Nullable = true
+		return inner
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: true}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem()), Nullable: true}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.ref(t)
+	default:
+		// interface{} and anything else encoding/json accepts
+		return &Schema{}
+	}
+}
+
+// ref registers a named struct as a component and returns a reference to
+// it. Types of the same name in different packages get qualified names.
+func (g *generator) ref(t reflect.Type) *Schema {
+	if g.names == nil {
+		g.names = map[reflect.Type]string{}
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.schemas[name]; taken {
+			pkg := t.PkgPath()
+			pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		g.names[t] = name
+		// Reserve the name first so recursive types terminate
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema lists the fields encoding/json writes. Fields without
+// omitempty are always present, so they're required; nothing else is
+// allowed.
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	g.addFields(s, t, false)
+	return s
+}
+
+// addFields adds t's fields to s. Like encoding/json, fields of the outer
+// struct win over promoted ones of the same name.
+func (g *generator) addFields(s *Schema, t reflect.Type, promoted bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a name of their own are flattened
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft, true)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if _, exists := s.Properties[name]; exists {
+			if promoted {
+				continue
+			}
+			for j, r := range s.Required {
+				if r == name {
+					s.Required = append(s.Required[:j], s.Required[j+1:]...)
+					break
+				}
+			}
+		}
+
+		prop := g.schema(f.Type)
+		if strings.Contains(opts, "string") {
+			prop = &Schema{Type: "string"}
+		}
+		s.Properties[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks a response against the document. path is relative to
+// the server URL. Error responses that aren't documented are skipped since
+// they're plain text from http.Error; undocumented successes, unexpected
+// content types and JSON bodies that don't match their schema are
+// reported.
+func (d *Document) Validate(method, path string, status int, contentType string, body []byte) error {
+	docPath, op := d.Find(method, path)
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+
+	spec, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if status >= 400 {
+			return nil
+		}
+		return fmt.Errorf("%s %s: status %d is not documented", method, docPath, status)
+	}
+
+	if len(spec.Content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%s %s: %d should have no body", method, docPath, status)
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := spec.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("%s %s: content type %q is not documented for %d", method, docPath, contentType, status)
+	}
+	if mediaType != "application/json" || media.Schema == nil {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON: %v", method, docPath, err)
+	}
+	if err := d.check(media.Schema, value, "$"); err != nil {
+		return fmt.Errorf("%s %s: %v", method, docPath, err)
+	}
+	return nil
+}
+
+// check validates value against s, naming the failing location in errors
+func (d *Document) check(s *Schema, value interface{}, at string) error {
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, name)
+		}
+		return d.check(target, value, at)
+	}
+	if value == nil {
+		if s.Nullable || (s.Type == "" && len(s.AllOf) == 0) {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", at)
+	}
+	for _, sub := range s.AllOf {
+		if err := d.check(sub, value, at); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %s", at, kindOf(value))
+		}
+
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected %s, got %s", at, s.Type, kindOf(value))
+		}
+		if s.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				return fmt.Errorf("%s: expected integer, got %s", at, n)
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %s", at, kindOf(value))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, str)
+			}
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			return fmt.Errorf("%s: %q is not one of %s", at, str, strings.Join(s.Enum, ", "))
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %s", at, kindOf(value))
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := d.check(s.Items, item, at+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %s", at, kindOf(value))
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing property %q", at, name)
+			}
+		}
+
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				switch extra := s.AdditionalProperties.(type) {
+				case bool:
+					if !extra {
+						return fmt.Errorf("%s: unexpected property %q", at, name)
+					}
+					continue
+				case *Schema:
+					prop = extra
+				case map[string]interface{}:
+					// Documents read back from JSON hold plain maps
+					prop = schemaFromMap(extra)
+				default:
+					continue
+				}
+			}
+			if err := d.check(prop, obj[name], at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func schemaFromMap(m map[string]interface{}) *Schema {
+	raw, _ := json.Marshal(m)
+	var s Schema
+	json.Unmarshal(raw, &s)
+	return &s
+}
+
+func kindOf(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
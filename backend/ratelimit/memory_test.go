@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock stands in for time.Now so buckets refill on demand
+type fakeClock struct {
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// takeStep advances the clock by wait, takes a token and expects want
+type takeStep struct {
+	name string
+	wait time.Duration
+	want Result
+}
+
+// bucketSteps runs a bucket of 3 refilling at one token per second
+// through being emptied, refilling partly and refilling past full
+var bucketSteps = []takeStep{
+	{"first take", 0, Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+	{"second take", 0, Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+	{"last token", 0, Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+	{"empty", 0, Result{Remaining: 0, Reset: 3 * time.Second, RetryAfter: time.Second}},
+	{"half refilled", 500 * time.Millisecond, Result{Remaining: 0, Reset: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+	{"refilled one", 500 * time.Millisecond, Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+	{"refill stops at burst", time.Hour, Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+}
+
+// checkSteps runs steps against store on key, allowing for the rounding
+// of durations
+func checkSteps(t *testing.T, store Store, clock *fakeClock, key string, steps []takeStep) {
+	t.Helper()
+	near := func(a, b time.Duration) bool {
+		d := a - b
+		return d > -time.Millisecond && d < time.Millisecond
+	}
+	for _, step := range steps {
+		clock.advance(step.wait)
+		got, err := store.Take(context.Background(), key, 1, 3)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		want := step.want
+		if got.Allowed != want.Allowed || got.Remaining != want.Remaining ||
+			!near(got.Reset, want.Reset) || !near(got.RetryAfter, want.RetryAfter) {
+			t.Errorf("%s: got %+v, want %+v", step.name, got, want)
+		}
+	}
+}
+
+func TestMemoryTake(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemory()
+	m.now = clock.now
+
+	checkSteps(t, m, clock, "a", bucketSteps)
+}
+
+func TestMemoryKeysAreSeparate(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemory()
+	m.now = clock.now
+
+	for i := 0; i < 3; i++ {
+		m.Take(context.Background(), "a", 1, 3)
+	}
+	if res, _ := m.Take(context.Background(), "a", 1, 3); res.Allowed {
+		t.Fatal("emptied bucket allowed a take")
+	}
+	if res, _ := m.Take(context.Background(), "b", 1, 3); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("other key got %+v, want a full bucket", res)
+	}
+}
+
+func TestMemorySlowRate(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemory()
+	m.now = clock.now
+
+	// 10 a minute with a burst of 1: a token every six seconds
+	rate := 10 / time.Minute.Seconds()
+	m.Take(context.Background(), "a", rate, 1)
+	res, _ := m.Take(context.Background(), "a", rate, 1)
+	if res.Allowed || res.RetryAfter != 6*time.Second || res.Reset != 6*time.Second {
+		t.Errorf("got %+v, want a 6s wait", res)
+	}
+	if got := ceilSeconds(res.RetryAfter - time.Millisecond); got != 6 {
+		t.Errorf("ceilSeconds rounds a partial second to %d, want 6", got)
+	}
+
+	clock.advance(6 * time.Second)
+	if res, _ := m.Take(context.Background(), "a", rate, 1); !res.Allowed {
+		t.Errorf("got %+v after the wait, want allowed", res)
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMemory()
+	m.now = clock.now
+	m.lastSweep = clock.now()
+
+	m.Take(context.Background(), "full", 1, 1)
+	clock.advance(time.Second)
+	m.Take(context.Background(), "busy", 0.001, 2)
+	clock.advance(sweepInterval + time.Second)
+	m.Take(context.Background(), "new", 1, 1)
+
+	if _, ok := m.buckets["full"]; ok {
+		t.Error("refilled bucket was kept")
+	}
+	if _, ok := m.buckets["busy"]; !ok {
+		t.Error("bucket still refilling was dropped")
+	}
+}
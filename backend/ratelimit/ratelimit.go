@@ -0,0 +1,166 @@
+// Package ratelimit throttles requests with token buckets. Each Policy
+// gives a client a bucket of Burst tokens refilled at Limit per Period;
+// every request takes one. Buckets live in a Store, in memory for a single
+// instance or in Redis when several instances share the limits.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"auth-app-backend/utils"
+)
+
+// Store keeps the token buckets
+type Store interface {
+	// Take removes a token from the bucket at key, which refills at rate
+	// tokens per second up to burst
+	Take(ctx context.Context, key string, rate float64, burst int) (Result, error)
+}
+
+// Result is the state of a bucket after a Take
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next token, when not allowed
+	RetryAfter time.Duration
+}
+
+// result derives a Result from the tokens left in a bucket
+func result(allowed bool, tokens, rate float64, burst int) Result {
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(burst) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Key picks what a bucket is per
+type Key int
+
+const (
+	// ByIP gives every client address its own bucket
+	ByIP Key = iota
+	// ByUser gives every signed in user a bucket; anonymous requests
+	// share one per address
+	ByUser
+)
+
+// Policy is the limit of a group of routes
+type Policy struct {
+	// Name namespaces the buckets; routes with the same name share them
+	Name   string
+	Limit  int
+	Period time.Duration
+	// Burst is the bucket size, Limit when zero
+	Burst int
+	By    Key
+}
+
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+func (p Policy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// Limiter applies policies to handlers
+type Limiter struct {
+	Store Store
+	// TrustProxy takes the client address from X-Forwarded-For. Only
+	// enable it behind a proxy that sets the header, or clients can pick
+	// their own bucket.
+	TrustProxy bool
+}
+
+// New returns a limiter configured from the environment.
+// RATE_LIMIT_STORE selects "memory" (the default) or "redis" (see
+// NewRedisFromEnv); RATE_LIMIT_TRUST_PROXY=true trusts X-Forwarded-For.
+func New() (*Limiter, error) {
+	l := &Limiter{TrustProxy: utils.TrustProxy()}
+
+	switch store := os.Getenv("RATE_LIMIT_STORE"); store {
+	case "", "memory":
+		l.Store = NewMemory()
+	case "redis":
+		s, err := NewRedisFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		l.Store = s
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", store)
+	}
+	return l, nil
+}
+
+// Handler limits next with p. Responses carry RateLimit-* headers; once
+// the bucket is empty requests get a 429 with Retry-After. If the store
+// fails, requests are let through rather than taking the API down.
+func (l *Limiter) Handler(p Policy, next http.HandlerFunc) http.HandlerFunc {
+	rate, burst := p.rate(), p.burst()
+	policy := fmt.Sprintf("%d;w=%d", burst, int(p.Period.Seconds()))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		key := "rl:" + p.Name + ":" + l.clientKey(r, p.By)
+		res, err := l.Store.Take(r.Context(), key, rate, burst)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Rate limiter unavailable", "err", err)
+			next(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", policy)
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientKey identifies who a bucket belongs to
+func (l *Limiter) clientKey(r *http.Request, by Key) string {
+	if by == ByUser {
+		if id := utils.UserIDFromRequest(r); id != 0 {
+			return "u:" + strconv.Itoa(id)
+		}
+	}
+	return "ip:" + utils.ClientIP(r, l.TrustProxy)
+}
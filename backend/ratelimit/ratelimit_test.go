@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auth-app-backend/utils"
+)
+
+func newTestLimiter() (*Limiter, *fakeClock) {
+	clock := newFakeClock()
+	m := NewMemory()
+	m.now = clock.now
+	return &Limiter{Store: m}, clock
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func serve(h http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h(rec, r)
+	return rec
+}
+
+func TestHandlerHeaders(t *testing.T) {
+	l, clock := newTestLimiter()
+	// 2 a minute: a token every 30s
+	h := l.Handler(Policy{Name: "test", Limit: 2, Period: time.Minute}, ok)
+
+	tests := []struct {
+		name           string
+		wait           time.Duration
+		wantStatus     int
+		wantRemaining  string
+		wantReset      string
+		wantRetryAfter string
+	}{
+		{"first", 0, http.StatusOK, "1", "30", ""},
+		{"second", 0, http.StatusOK, "0", "60", ""},
+		{"limited", 0, http.StatusTooManyRequests, "0", "60", "30"},
+		{"partial refill rounds up", 20 * time.Second, http.StatusTooManyRequests, "0", "40", "10"},
+		{"refilled", 10 * time.Second, http.StatusOK, "0", "60", ""},
+	}
+	for _, tt := range tests {
+		clock.advance(tt.wait)
+		rec := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		h := rec.Header()
+		if h.Get("RateLimit-Limit") != "2" || h.Get("RateLimit-Policy") != "2;w=60" {
+			t.Errorf("%s: limit %q and policy %q", tt.name, h.Get("RateLimit-Limit"), h.Get("RateLimit-Policy"))
+		}
+		if got := h.Get("RateLimit-Remaining"); got != tt.wantRemaining {
+			t.Errorf("%s: RateLimit-Remaining = %q, want %q", tt.name, got, tt.wantRemaining)
+		}
+		if got := h.Get("RateLimit-Reset"); got != tt.wantReset {
+			t.Errorf("%s: RateLimit-Reset = %q, want %q", tt.name, got, tt.wantReset)
+		}
+		if got := h.Get("Retry-After"); got != tt.wantRetryAfter {
+			t.Errorf("%s: Retry-After = %q, want %q", tt.name, got, tt.wantRetryAfter)
+		}
+	}
+}
+
+func TestHandlerKeys(t *testing.T) {
+	l, _ := newTestLimiter()
+	policy := Policy{Name: "test", Limit: 1, Period: time.Minute, By: ByUser}
+	h := l.Handler(policy, ok)
+
+	token, err := utils.GenerateToken(7, "alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(addr, forwarded, token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = addr
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-For", forwarded)
+		}
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r
+	}
+
+	serve(h, request("192.0.2.1:1000", "", ""))
+	if rec := serve(h, request("192.0.2.1:2000", "", "")); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("same address got %d, want 429", rec.Code)
+	}
+	if rec := serve(h, request("192.0.2.2:1000", "", "")); rec.Code != http.StatusOK {
+		t.Errorf("another address got %d, want 200", rec.Code)
+	}
+	if rec := serve(h, request("192.0.2.1:1000", "", token)); rec.Code != http.StatusOK {
+		t.Errorf("signed in user got %d, want their own bucket", rec.Code)
+	}
+	if rec := serve(h, request("192.0.2.9:1000", "", token)); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("user from another address got %d, want 429", rec.Code)
+	}
+	if rec := serve(h, request("192.0.2.1:1000", "198.51.100.1", "")); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("X-Forwarded-For picked a bucket without TrustProxy: %d", rec.Code)
+	}
+
+	l.TrustProxy = true
+	if rec := serve(h, request("192.0.2.1:1000", "198.51.100.1", "")); rec.Code != http.StatusOK {
+		t.Errorf("forwarded client got %d, want its own bucket", rec.Code)
+	}
+}
+
+func TestHandlerSkipsPreflight(t *testing.T) {
+	l, _ := newTestLimiter()
+	h := l.Handler(Policy{Name: "test", Limit: 1, Period: time.Minute}, ok)
+
+	for i := 0; i < 3; i++ {
+		rec := serve(h, httptest.NewRequest(http.MethodOptions, "/", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("preflight %d got %d with headers %v", i, rec.Code, rec.Header())
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, float64, int) (Result, error) {
+	return Result{}, errors.New("down")
+}
+
+func TestHandlerFailsOpen(t *testing.T) {
+	l := &Limiter{Store: failingStore{}}
+	h := l.Handler(Policy{Name: "test", Limit: 1, Period: time.Minute}, ok)
+
+	for i := 0; i < 3; i++ {
+		if rec := serve(h, httptest.NewRequest(http.MethodGet, "/", nil)); rec.Code != http.StatusOK {
+			t.Fatalf("request %d got %d with the store down", i, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes from a bucket atomically. The bucket is a
+// hash of its tokens and the time they were counted, expiring once it
+// would be full again. The caller's clock is used rather than TIME so the
+// script runs on any Redis-compatible server.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) / 1000 * rate)
+	ts = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis keeps buckets in Redis, or anything speaking its protocol with Lua
+// scripting (Valkey, KeyDB, miniredis in tests), so every instance shares
+// the same limits
+type Redis struct {
+	client redis.UniversalClient
+	now    func() time.Time
+}
+
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client, now: time.Now}
+}
+
+// NewRedisFromEnv connects to REDIS_URL, such as redis://localhost:6379/0
+func NewRedisFromEnv() (*Redis, error) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		url = "redis://localhost:6379/0"
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	return NewRedis(redis.NewClient(opts)), nil
+}
+
+func (s *Redis) Take(ctx context.Context, key string, rate float64, burst int) (Result, error) {
+	now := s.now().UnixMilli()
+	reply, err := takeScript.Run(ctx, s.client, []string{key}, rate, burst, now).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	left, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+	return result(allowed == 1, tokens, rate, burst), nil
+}
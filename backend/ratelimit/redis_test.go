@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *Redis, *fakeClock) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	clock := newFakeClock()
+	s := NewRedis(client)
+	s.now = clock.now
+	return mr, s, clock
+}
+
+func TestRedisTake(t *testing.T) {
+	_, s, clock := newTestRedis(t)
+
+	checkSteps(t, s, clock, "rl:test:a", bucketSteps)
+}
+
+func TestRedisKeysAreSeparate(t *testing.T) {
+	_, s, _ := newTestRedis(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.Take(ctx, "a", 1, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res, _ := s.Take(ctx, "a", 1, 3); res.Allowed {
+		t.Fatal("emptied bucket allowed a take")
+	}
+	if res, _ := s.Take(ctx, "b", 1, 3); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("other key got %+v, want a full bucket", res)
+	}
+}
+
+func TestRedisBucketExpires(t *testing.T) {
+	mr, s, _ := newTestRedis(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		s.Take(ctx, "a", 1, 3)
+	}
+	// Empty, the bucket takes 3s to refill, plus a second's margin
+	if ttl := mr.TTL("a"); ttl != 4*time.Second {
+		t.Errorf("TTL = %v, want 4s", ttl)
+	}
+
+	mr.FastForward(4 * time.Second)
+	if mr.Exists("a") {
+		t.Fatal("full bucket was kept")
+	}
+	if res, _ := s.Take(ctx, "a", 1, 3); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("expired bucket got %+v, want a full one", res)
+	}
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	mr, s, _ := newTestRedis(t)
+	mr.Close()
+
+	if _, err := s.Take(context.Background(), "a", 1, 3); err == nil {
+		t.Error("Take succeeded without a server")
+	}
+}
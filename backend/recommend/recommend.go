@@ -0,0 +1,124 @@
+// Package recommend scores developer–project matches. Scores are built up
+// from individual signals, each of which records a human-readable reason,
+// so every recommendation can explain itself.
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Weights of each signal. Programming tags carry the most weight since
+// they describe what a developer can actually build.
+const (
+	ProgrammingWeight = 3.0
+	GeneralWeight     = 1.0
+	FollowWeight      = 2.0
+	InteractionWeight = 1.0
+	// MaxInteractions caps how many likes and saves count towards a match
+	MaxInteractions = 3
+)
+
+// TagProfile counts how often each tag appears in someone's history. Keys
+// are lowercased; Display keeps the spelling first seen for reasons.
+type TagProfile struct {
+	Counts  map[string]int
+	Display map[string]string
+}
+
+func NewTagProfile() *TagProfile {
+	return &TagProfile{Counts: map[string]int{}, Display: map[string]string{}}
+}
+
+// Add counts each distinct tag once
+func (p *TagProfile) Add(tags []string) {
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		key := strings.ToLower(strings.TrimSpace(tag))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		p.Counts[key]++
+		if _, ok := p.Display[key]; !ok {
+			p.Display[key] = strings.TrimSpace(tag)
+		}
+	}
+}
+
+// Keys returns the profile's lowercased tags
+func (p *TagProfile) Keys() []string {
+	keys := make([]string, 0, len(p.Counts))
+	for k := range p.Counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Match is a score and the reasons behind it
+type Match struct {
+	Score       float64
+	Reasons     []string
+	MatchedTags []string
+}
+
+// Add adds points for a signal described by reason
+func (m *Match) Add(points float64, reason string) {
+	if points <= 0 {
+		return
+	}
+	m.Score += points
+	m.Reasons = append(m.Reasons, reason)
+}
+
+// AddTags scores the overlap between a profile and tags. Each shared tag
+// is worth weight, growing logarithmically with how often it appears in the
+// profile so depth counts without drowning out breadth. The reason reads
+// "<label> Go, React".
+func (m *Match) AddTags(p *TagProfile, tags []string, weight float64, label string) {
+	points := 0.0
+	matched := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		key := strings.ToLower(strings.TrimSpace(tag))
+		count := p.Counts[key]
+		if count == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		points += weight * (1 + math.Log(float64(count)))
+		matched = append(matched, strings.TrimSpace(tag))
+	}
+	if len(matched) == 0 {
+		return
+	}
+	m.MatchedTags = append(m.MatchedTags, matched...)
+	m.Add(points, label+" "+strings.Join(matched, ", "))
+}
+
+// AddInteractions scores past likes and saves, capped at MaxInteractions
+func (m *Match) AddInteractions(count int, reason string) {
+	if count > MaxInteractions {
+		count = MaxInteractions
+	}
+	m.Add(float64(count)*InteractionWeight, reason)
+}
+
+// Estimate is the score of a match counting each shared tag once, without
+// the extra weight of tags that recur in a profile. It never exceeds the
+// full score, and is cheap enough for SQL to rank candidates by before
+// they are scored; the project candidate query computes the same sum.
+func Estimate(skillMatches, interestMatches int, followed bool, interactions int) float64 {
+	score := float64(skillMatches)*ProgrammingWeight + float64(interestMatches)*GeneralWeight
+	if followed {
+		score += FollowWeight
+	}
+	return score + float64(min(interactions, MaxInteractions))*InteractionWeight
+}
+
+// Rounded returns the score rounded to two decimals for display
+func (m *Match) Rounded() float64 {
+	return math.Round(m.Score*100) / 100
+}
@@ -0,0 +1,246 @@
+package recommend
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTagProfileAdd(t *testing.T) {
+	tests := []struct {
+		name        string
+		adds        [][]string
+		wantCounts  map[string]int
+		wantDisplay map[string]string
+	}{
+		{
+			name:        "empty",
+			adds:        nil,
+			wantCounts:  map[string]int{},
+			wantDisplay: map[string]string{},
+		},
+		{
+			name:        "case and space folded",
+			adds:        [][]string{{"Go", " go ", "GO"}},
+			wantCounts:  map[string]int{"go": 1},
+			wantDisplay: map[string]string{"go": "Go"},
+		},
+		{
+			name:        "blank tags skipped",
+			adds:        [][]string{{"", "  ", "React"}},
+			wantCounts:  map[string]int{"react": 1},
+			wantDisplay: map[string]string{"react": "React"},
+		},
+		{
+			name:        "counted once per add",
+			adds:        [][]string{{"Go", "React"}, {"go"}, {"GO", "Rust"}},
+			wantCounts:  map[string]int{"go": 3, "react": 1, "rust": 1},
+			wantDisplay: map[string]string{"go": "Go", "react": "React", "rust": "Rust"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewTagProfile()
+			for _, tags := range tt.adds {
+				p.Add(tags)
+			}
+			if !reflect.DeepEqual(p.Counts, tt.wantCounts) {
+				t.Errorf("Counts = %v, want %v", p.Counts, tt.wantCounts)
+			}
+			if !reflect.DeepEqual(p.Display, tt.wantDisplay) {
+				t.Errorf("Display = %v, want %v", p.Display, tt.wantDisplay)
+			}
+		})
+	}
+}
+
+func TestTagProfileKeys(t *testing.T) {
+	p := NewTagProfile()
+	p.Add([]string{"Rust", "go", "React"})
+	if got, want := p.Keys(), []string{"go", "react", "rust"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if got := NewTagProfile().Keys(); len(got) != 0 {
+		t.Errorf("Keys() of an empty profile = %v", got)
+	}
+}
+
+func TestMatchAddTags(t *testing.T) {
+	profile := NewTagProfile()
+	profile.Add([]string{"Go", "React"})
+	profile.Add([]string{"go"})
+
+	tests := []struct {
+		name        string
+		tags        []string
+		weight      float64
+		wantScore   float64
+		wantReasons []string
+		wantMatched []string
+	}{
+		{
+			name:   "no overlap",
+			tags:   []string{"Rust"},
+			weight: ProgrammingWeight,
+		},
+		{
+			name:        "single tag",
+			tags:        []string{"React"},
+			weight:      ProgrammingWeight,
+			wantScore:   ProgrammingWeight,
+			wantReasons: []string{"matched on React"},
+			wantMatched: []string{"React"},
+		},
+		{
+			name:        "depth grows logarithmically",
+			tags:        []string{"Go"},
+			weight:      ProgrammingWeight,
+			wantScore:   ProgrammingWeight * (1 + math.Log(2)),
+			wantReasons: []string{"matched on Go"},
+			wantMatched: []string{"Go"},
+		},
+		{
+			name:        "duplicates count once and keep the project's spelling",
+			tags:        []string{" react ", "REACT", "Rust", "Go"},
+			weight:      GeneralWeight,
+			wantScore:   GeneralWeight + GeneralWeight*(1+math.Log(2)),
+			wantReasons: []string{"matched on react, Go"},
+			wantMatched: []string{"react", "Go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Match
+			m.AddTags(profile, tt.tags, tt.weight, "matched on")
+			if math.Abs(m.Score-tt.wantScore) > 1e-9 {
+				t.Errorf("Score = %v, want %v", m.Score, tt.wantScore)
+			}
+			if !reflect.DeepEqual(m.Reasons, tt.wantReasons) {
+				t.Errorf("Reasons = %q, want %q", m.Reasons, tt.wantReasons)
+			}
+			if !reflect.DeepEqual(m.MatchedTags, tt.wantMatched) {
+				t.Errorf("MatchedTags = %q, want %q", m.MatchedTags, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func TestMatchAdd(t *testing.T) {
+	tests := []struct {
+		name        string
+		points      []float64
+		wantScore   float64
+		wantReasons int
+	}{
+		{"positive", []float64{2, 1.5}, 3.5, 2},
+		{"zero ignored", []float64{0}, 0, 0},
+		{"negative ignored", []float64{-1, 2}, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Match
+			for _, p := range tt.points {
+				m.Add(p, "reason")
+			}
+			if m.Score != tt.wantScore || len(m.Reasons) != tt.wantReasons {
+				t.Errorf("got score %v with %d reasons, want %v with %d", m.Score, len(m.Reasons), tt.wantScore, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestMatchAddInteractions(t *testing.T) {
+	tests := []struct {
+		count     int
+		wantScore float64
+	}{
+		{0, 0},
+		{1, InteractionWeight},
+		{MaxInteractions, MaxInteractions * InteractionWeight},
+		{MaxInteractions + 5, MaxInteractions * InteractionWeight},
+	}
+	for _, tt := range tests {
+		var m Match
+		m.AddInteractions(tt.count, "liked this project")
+		if m.Score != tt.wantScore {
+			t.Errorf("AddInteractions(%d) score = %v, want %v", tt.count, m.Score, tt.wantScore)
+		}
+		if (tt.wantScore > 0) != (len(m.Reasons) == 1) {
+			t.Errorf("AddInteractions(%d) reasons = %q", tt.count, m.Reasons)
+		}
+	}
+}
+
+func TestMatchRounded(t *testing.T) {
+	tests := []struct {
+		score, want float64
+	}{
+		{0, 0},
+		{3, 3},
+		{5.0794415, 5.08},
+		{1.004, 1},
+		{2.346, 2.35},
+	}
+	for _, tt := range tests {
+		m := Match{Score: tt.score}
+		if got := m.Rounded(); got != tt.want {
+			t.Errorf("Rounded(%v) = %v, want %v", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	profile := NewTagProfile()
+	profile.Add([]string{"Go", "React"})
+	profile.Add([]string{"go"})
+
+	tests := []struct {
+		name            string
+		skillMatches    int
+		interestMatches int
+		followed        bool
+		interactions    int
+		want            float64
+		// tags scored against profile for the full score
+		tags []string
+	}{
+		{"nothing", 0, 0, false, 0, 0, nil},
+		{"one shared tag", 1, 0, false, 0, ProgrammingWeight, []string{"React"}},
+		{"recurring tag counts once", 1, 0, false, 0, ProgrammingWeight, []string{"Go"}},
+		{"two shared tags", 2, 0, false, 0, 2 * ProgrammingWeight, []string{"Go", "React"}},
+		{"interest", 0, 1, false, 0, GeneralWeight, nil},
+		{"follow", 0, 0, true, 0, FollowWeight, nil},
+		{"interactions capped", 0, 0, false, MaxInteractions + 4, MaxInteractions * InteractionWeight, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Estimate(tt.skillMatches, tt.interestMatches, tt.followed, tt.interactions)
+			if got != tt.want {
+				t.Errorf("Estimate = %v, want %v", got, tt.want)
+			}
+			if tt.tags != nil {
+				var m Match
+				m.AddTags(profile, tt.tags, ProgrammingWeight, "matched on")
+				if got > m.Score+1e-9 {
+					t.Errorf("Estimate %v exceeds the full score %v", got, m.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateRanksTagsOverRecency(t *testing.T) {
+	// An old project sharing two programming tags must survive the
+	// candidate cap ahead of new projects only sharing an owner follow or
+	// past likes
+	strong := Estimate(2, 0, false, 0)
+	for _, weak := range []float64{
+		Estimate(0, 0, true, 0),
+		Estimate(0, 0, false, MaxInteractions),
+		Estimate(0, 2, false, 0),
+	} {
+		if strong <= weak {
+			t.Errorf("two shared programming tags (%v) don't outrank %v", strong, weak)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"auth-app-backend/export"
+	"auth-app-backend/handlers"
+	"auth-app-backend/models"
+	"auth-app-backend/openapi"
+)
+
+// apiPrefix is where the current version of the API is served
+const apiPrefix = "/api/v1"
+
+func health(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "Server is running!")
+}
+
+// Shared bodies and parameters
+var (
+	message   = map[string]string{}
+	pageQuery = []openapi.Param{openapi.CursorParam, openapi.LimitParam}
+	kindParam = openapi.Param{Name: "kind", Enum: []string{"general", "programming"}}
+	stageEnum = []string{"interested", "in_talks", "committed", "passed"}
+)
+
+// apiRoutes is the API served under apiPrefix. The OpenAPI document is
+// generated from it, so every route documents its body and responses
+// here. Routes are matched in order.
+func apiRoutes() []openapi.Route {
+	return []openapi.Route{
+		{Method: "GET", Path: "/health", Handler: health, Summary: "Health check", Tag: "meta",
+			Responses: []openapi.Response{openapi.Raw(200, "text/plain")}},
+
+		// Auth
+		{Method: "POST", Path: "/login", Handler: handlers.Login, Summary: "Log in", Tag: "auth",
+			Body: models.LoginRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.AuthResponse{})}},
+		{Method: "POST", Path: "/signup", Handler: handlers.Signup, Summary: "Create an account", Tag: "auth",
+			Body: models.SignupRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.AuthResponse{})}},
+		{Method: "POST", Path: "/logout", Handler: handlers.Logout, Summary: "Log out", Tag: "auth", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, message)}},
+		{Method: "POST", Path: "/refresh", Handler: handlers.RefreshToken, Summary: "Trade a valid token for a fresh one", Tag: "auth", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.AuthResponse{})}},
+		{Method: "GET", Path: "/validate", Handler: handlers.ValidateToken, Summary: "Check a token", Tag: "auth", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, message)}},
+		{Method: "PUT", Path: "/profile/update", Handler: handlers.UpdateProfile, Summary: "Update the caller's profile", Tag: "users", Auth: true,
+			Form: []openapi.Param{
+				{Name: "first_name"}, {Name: "last_name"}, {Name: "email"}, {Name: "phone"}, {Name: "bio"},
+				{Name: "github_link"}, {Name: "portfolio_link"}, {Name: "linkedin_link"}, {Name: "company_name"},
+				{Name: "profile_picture", Type: "file"}, {Name: "banner", Type: "file"},
+			},
+			Responses: []openapi.Response{openapi.JSON(200, models.User{})}},
+
+		// Projects
+		{Method: "GET", Path: "/projects", Handler: handlers.GetProjects, Summary: "List projects", Tag: "projects",
+			Responses: []openapi.Response{openapi.JSON(200, []models.Project{})}},
+		{Method: "POST", Path: "/projects/create", Handler: handlers.CreateProject, Summary: "Create a project", Tag: "projects", Auth: true,
+			Body: models.CreateProjectRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.Project{})}},
+		{Method: "GET", Path: "/projects/saved", Handler: handlers.GetSavedProjects, Summary: "List the caller's saved projects", Tag: "projects", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, []map[string]interface{}{})}},
+		{Method: "GET", Path: "/projects/trending", Handler: handlers.GetTrendingProjects, Summary: "List trending projects", Tag: "projects",
+			Query:     []openapi.Param{openapi.LimitParam, {Name: "tag", Description: "Only projects with this tag"}},
+			Responses: []openapi.Response{openapi.JSON(200, []models.TrendingProject{})}},
+		{Method: "POST", Path: "/projects/{id}/save", Handler: handlers.HandleProjectActions, Summary: "Save a project", Tag: "projects", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, message), openapi.JSON(201, message)}},
+		{Method: "DELETE", Path: "/projects/{id}/save", Handler: handlers.HandleProjectActions, Summary: "Unsave a project", Tag: "projects", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, message)}},
+		{Method: "GET", Path: "/projects/{id}/analytics", Handler: handlers.GetProjectAnalytics, Summary: "Daily stats of a project", Tag: "projects", Auth: true,
+			Query: []openapi.Param{
+				{Name: "from", Description: "First day, YYYY-MM-DD"},
+				{Name: "to", Description: "Last day, YYYY-MM-DD"},
+			},
+			Responses: []openapi.Response{openapi.JSON(200, models.ProjectAnalytics{})}},
+
+		// Project images
+		{Method: "POST", Path: "/projects/{id}/images", Handler: handlers.UploadProjectImages, Summary: "Upload project images", Tag: "projects", Auth: true,
+			Form:      []openapi.Param{{Name: "images", Type: "file", Required: true}},
+			Responses: []openapi.Response{openapi.JSON(201, models.ProjectImages{})}},
+		{Method: "DELETE", Path: "/projects/{id}/images", Handler: handlers.DeleteProjectImage, Summary: "Delete a project image", Tag: "projects", Auth: true,
+			Query:     []openapi.Param{{Name: "url", Required: true}},
+			Responses: []openapi.Response{openapi.JSON(200, models.ProjectImages{})}},
+		{Method: "PUT", Path: "/projects/{id}/images/order", Handler: handlers.ReorderProjectImages, Summary: "Reorder project images", Tag: "projects", Auth: true,
+			Body: models.ImageOrderRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.ProjectImages{})}},
+		{Method: "PUT", Path: "/projects/{id}/images/cover", Handler: handlers.SetProjectCover, Summary: "Set the cover image", Tag: "projects", Auth: true,
+			Body: models.CoverImageRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.ProjectImages{})}},
+
+		// Business model
+		{Method: "GET", Path: "/projects/{code}/business-model.{format:pdf|pptx}", Handler: handlers.ExportBusinessModel, Summary: "Export the business model", Tag: "business model",
+			Responses: []openapi.Response{openapi.Raw(200, export.PDF.ContentType(), export.PPTX.ContentType())}},
+		{Method: "GET", Path: "/projects/{code}/business-model/template", Handler: handlers.HandleExportTemplate, Summary: "Get the export template", Tag: "business model",
+			Responses: []openapi.Response{openapi.JSON(200, export.Template{})}},
+		{Method: "PUT", Path: "/projects/{code}/business-model/template", Handler: handlers.HandleExportTemplate, Summary: "Customise the export template", Tag: "business model", Auth: true,
+			Body: export.Template{}, Responses: []openapi.Response{openapi.JSON(200, export.Template{})}},
+		{Method: "GET", Path: "/projects/{id}/canvas", Handler: handlers.HandleProjectCanvas, Summary: "Get the current canvas", Tag: "business model",
+			Responses: []openapi.Response{openapi.JSON(200, models.ProjectCanvas{})}},
+		{Method: "PUT", Path: "/projects/{id}/canvas", Handler: handlers.HandleProjectCanvas, Summary: "Save a new canvas version", Tag: "business model", Auth: true,
+			Body: models.CanvasRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.ProjectCanvas{}), openapi.JSON(201, models.ProjectCanvas{})}},
+		{Method: "DELETE", Path: "/projects/{id}/canvas", Handler: handlers.HandleProjectCanvas, Summary: "Delete the canvas, keeping its history", Tag: "business model", Auth: true,
+			Responses: []openapi.Response{openapi.NoContent()}},
+		{Method: "GET", Path: "/projects/{id}/canvas/history", Handler: handlers.GetCanvasHistory, Summary: "List canvas versions", Tag: "business model",
+			Query:     []openapi.Param{openapi.LimitParam, {Name: "before", Type: "integer", Description: "Only versions older than this one"}},
+			Responses: []openapi.Response{openapi.JSON(200, models.CanvasHistoryResponse{})}},
+		{Method: "GET", Path: "/projects/{id}/canvas/versions/{version}", Handler: handlers.GetCanvasVersion, Summary: "Get a canvas version", Tag: "business model",
+			Responses: []openapi.Response{openapi.JSON(200, models.ProjectCanvas{})}},
+		{Method: "POST", Path: "/projects/{id}/canvas/versions/{version}/restore", Handler: handlers.RestoreCanvasVersion, Summary: "Restore a canvas version", Tag: "business model", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.ProjectCanvas{}), openapi.JSON(201, models.ProjectCanvas{})}},
+
+		// Progress tracking
+		{Method: "GET", Path: "/projects/{id}/status", Handler: handlers.HandleProjectStatus, Summary: "Get the project status", Tag: "progress",
+			Responses: []openapi.Response{openapi.JSON(200, models.ProjectStatus{})}},
+		{Method: "PUT", Path: "/projects/{id}/status", Handler: handlers.HandleProjectStatus, Summary: "Move the project to a new status", Tag: "progress", Auth: true,
+			Body: models.StatusRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.ProjectStatus{})}},
+		{Method: "GET", Path: "/projects/{id}/activity", Handler: handlers.GetProjectActivity, Summary: "List project activity", Tag: "progress",
+			Query: pageQuery, Responses: []openapi.Response{openapi.JSON(200, models.ActivityListResponse{})}},
+		{Method: "GET", Path: "/projects/{id}/milestones", Handler: handlers.GetProjectMilestones, Summary: "List milestones", Tag: "progress",
+			Query:     []openapi.Param{{Name: "state", Enum: []string{"todo", "in_progress", "done", "cancelled"}}},
+			Responses: []openapi.Response{openapi.JSON(200, models.MilestoneListResponse{})}},
+		{Method: "POST", Path: "/projects/{id}/milestones", Handler: handlers.CreateMilestone, Summary: "Add a milestone", Tag: "progress", Auth: true,
+			Body: models.MilestoneRequest{}, Responses: []openapi.Response{openapi.JSON(201, models.Milestone{})}},
+		{Method: "PUT", Path: "/milestones/{id}", Handler: handlers.HandleMilestoneActions, Summary: "Update a milestone", Tag: "progress", Auth: true,
+			Body: models.MilestoneRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.Milestone{})}},
+		{Method: "DELETE", Path: "/milestones/{id}", Handler: handlers.HandleMilestoneActions, Summary: "Delete a milestone", Tag: "progress", Auth: true,
+			Responses: []openapi.Response{openapi.NoContent()}},
+
+		// Teams
+		{Method: "GET", Path: "/projects/{id}/members", Handler: handlers.GetProjectMembers, Summary: "List the team", Tag: "teams",
+			Responses: []openapi.Response{openapi.JSON(200, []models.ProjectMember{})}},
+		{Method: "PUT", Path: "/projects/{id}/members/{userId}", Handler: handlers.HandleProjectMember, Summary: "Change a member's role", Tag: "teams", Auth: true,
+			Body: models.MemberRoleRequest{}, Responses: []openapi.Response{openapi.JSON(200, []models.ProjectMember{})}},
+		{Method: "DELETE", Path: "/projects/{id}/members/{userId}", Handler: handlers.HandleProjectMember, Summary: "Remove a member or leave", Tag: "teams", Auth: true,
+			Responses: []openapi.Response{openapi.NoContent()}},
+		{Method: "GET", Path: "/projects/{id}/invitations", Handler: handlers.GetProjectInvitations, Summary: "List pending invitations", Tag: "teams", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, []models.Invitation{})}},
+		{Method: "POST", Path: "/projects/{id}/invitations", Handler: handlers.InviteProjectMember, Summary: "Invite someone", Tag: "teams", Auth: true,
+			Body: models.InvitationRequest{}, Responses: []openapi.Response{openapi.JSON(201, models.Invitation{})}},
+		{Method: "GET", Path: "/invitations", Handler: handlers.GetMyInvitations, Summary: "List the caller's invitations", Tag: "teams", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, []models.Invitation{})}},
+		{Method: "DELETE", Path: "/invitations/{id}", Handler: handlers.CancelInvitation, Summary: "Cancel an invitation", Tag: "teams", Auth: true,
+			Responses: []openapi.Response{openapi.NoContent()}},
+		{Method: "POST", Path: "/invitations/{id}/{action:accept|decline}", Handler: handlers.RespondToInvitation, Summary: "Accept or decline an invitation", Tag: "teams", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.Invitation{})}},
+
+		// Applications
+		{Method: "GET", Path: "/projects/{id}/applications", Handler: handlers.GetProjectApplications, Summary: "List applications to a project", Tag: "applications", Auth: true,
+			Query:     append([]openapi.Param{{Name: "status"}}, pageQuery...),
+			Responses: []openapi.Response{openapi.JSON(200, models.ApplicationListResponse{})}},
+		{Method: "POST", Path: "/projects/{id}/applications", Handler: handlers.ApplyToProject, Summary: "Apply to a project", Tag: "applications", Auth: true,
+			Body: models.ApplicationRequest{}, Responses: []openapi.Response{openapi.JSON(201, models.Application{})}},
+		{Method: "GET", Path: "/applications", Handler: handlers.GetMyApplications, Summary: "List the caller's applications", Tag: "applications", Auth: true,
+			Query:     append([]openapi.Param{{Name: "status"}}, pageQuery...),
+			Responses: []openapi.Response{openapi.JSON(200, models.ApplicationListResponse{})}},
+		{Method: "GET", Path: "/applications/{id}", Handler: handlers.GetApplication, Summary: "Get an application", Tag: "applications", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.Application{})}},
+		{Method: "PUT", Path: "/applications/{id}/status", Handler: handlers.UpdateApplicationStatus, Summary: "Decide on or withdraw an application", Tag: "applications", Auth: true,
+			Body: models.ApplicationDecisionRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.Application{})}},
+
+		// Notifications
+		{Method: "GET", Path: "/notifications", Handler: handlers.GetNotifications, Summary: "List notifications", Tag: "notifications", Auth: true,
+			Query:     append([]openapi.Param{{Name: "unread", Type: "boolean"}}, pageQuery...),
+			Responses: []openapi.Response{openapi.JSON(200, models.NotificationListResponse{})}},
+		{Method: "POST", Path: "/notifications/read", Handler: handlers.MarkNotificationsRead, Summary: "Mark notifications read", Tag: "notifications", Auth: true,
+			Body: models.MarkReadRequest{}, Responses: []openapi.Response{openapi.NoContent()}},
+
+		// Recommendations
+		{Method: "GET", Path: "/recommendations/projects", Handler: handlers.GetProjectRecommendations, Summary: "Projects to join", Tag: "recommendations", Auth: true,
+			Query: []openapi.Param{openapi.LimitParam}, Responses: []openapi.Response{openapi.JSON(200, []models.ProjectRecommendation{})}},
+		{Method: "GET", Path: "/recommendations/developers", Handler: handlers.GetDeveloperRecommendations, Summary: "Developers for a project", Tag: "recommendations", Auth: true,
+			Query:     []openapi.Param{{Name: "project", Type: "integer", Required: true}, openapi.LimitParam},
+			Responses: []openapi.Response{openapi.JSON(200, []models.DeveloperRecommendation{})}},
+
+		// Tags and skills
+		{Method: "GET", Path: "/tags", Handler: handlers.SearchTags, Summary: "Autocomplete tags", Tag: "tags",
+			Query: []openapi.Param{{Name: "q"}, kindParam, openapi.LimitParam}, Responses: []openapi.Response{openapi.JSON(200, []models.Tag{})}},
+		{Method: "GET", Path: "/tags/{slug}", Handler: handlers.GetTagPage, Summary: "A tag and its projects", Tag: "tags",
+			Query: append([]openapi.Param{kindParam}, pageQuery...), Responses: []openapi.Response{openapi.JSON(200, models.TagPage{})}},
+		{Method: "GET", Path: "/skills", Handler: handlers.SearchSkills, Summary: "Autocomplete skills", Tag: "skills",
+			Query: []openapi.Param{{Name: "q"}}, Responses: []openapi.Response{openapi.JSON(200, []models.Skill{})}},
+		{Method: "PUT", Path: "/profile/skills", Handler: handlers.UpdateMySkills, Summary: "Replace the caller's skills", Tag: "skills", Auth: true,
+			Body: models.SkillsRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.UserSkillsResponse{})}},
+		{Method: "GET", Path: "/users/{username}/skills", Handler: handlers.GetUserSkills, Summary: "A user's skills", Tag: "skills",
+			Responses: []openapi.Response{openapi.JSON(200, models.UserSkillsResponse{})}},
+		{Method: "POST", Path: "/users/{username}/skills/{skillId}/endorse", Handler: handlers.HandleEndorsement, Summary: "Endorse a skill", Tag: "skills", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.EndorsementResponse{})}},
+		{Method: "DELETE", Path: "/users/{username}/skills/{skillId}/endorse", Handler: handlers.HandleEndorsement, Summary: "Withdraw an endorsement", Tag: "skills", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.EndorsementResponse{})}},
+
+		// Investors
+		{Method: "GET", Path: "/investors/me", Handler: handlers.HandleMyInvestorProfile, Summary: "The caller's investor profile", Tag: "investors", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.InvestorProfile{})}},
+		{Method: "PUT", Path: "/investors/me", Handler: handlers.HandleMyInvestorProfile, Summary: "Save the caller's investor profile", Tag: "investors", Auth: true,
+			Body: models.InvestorProfileRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.InvestorProfile{})}},
+		{Method: "GET", Path: "/investors/{username}", Handler: handlers.GetInvestorProfile, Summary: "An investor profile", Tag: "investors",
+			Responses: []openapi.Response{openapi.JSON(200, models.InvestorProfile{})}},
+		{Method: "POST", Path: "/projects/{id}/interest", Handler: handlers.ExpressInterest, Summary: "Express investment interest", Tag: "investors", Auth: true,
+			Body: models.InterestRequest{}, Responses: []openapi.Response{openapi.JSON(201, models.InvestmentInterest{})}},
+		{Method: "DELETE", Path: "/projects/{id}/interest", Handler: handlers.WithdrawInterest, Summary: "Withdraw investment interest", Tag: "investors", Auth: true,
+			Responses: []openapi.Response{openapi.NoContent()}},
+		{Method: "GET", Path: "/projects/{id}/investors", Handler: handlers.GetProjectInvestors, Summary: "Investors interested in a project", Tag: "investors", Auth: true,
+			Query:     []openapi.Param{{Name: "stage", Enum: stageEnum}},
+			Responses: []openapi.Response{openapi.JSON(200, []models.InvestmentInterest{})}},
+		{Method: "GET", Path: "/pipeline", Handler: handlers.GetPipeline, Summary: "The caller's deal pipeline", Tag: "investors", Auth: true,
+			Query:     []openapi.Param{{Name: "stage", Enum: stageEnum}},
+			Responses: []openapi.Response{openapi.JSON(200, []models.InvestmentInterest{})}},
+		{Method: "PUT", Path: "/pipeline/{id}", Handler: handlers.UpdateDeal, Summary: "Move a deal", Tag: "investors", Auth: true,
+			Body: models.DealUpdateRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.InvestmentInterest{})}},
+		{Method: "GET", Path: "/pipeline/{id}/notes", Handler: handlers.HandleDealNotes, Summary: "Private notes on a deal", Tag: "investors", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, []models.DealNote{})}},
+		{Method: "POST", Path: "/pipeline/{id}/notes", Handler: handlers.HandleDealNotes, Summary: "Add a private note", Tag: "investors", Auth: true,
+			Body: models.DealNoteRequest{}, Responses: []openapi.Response{openapi.JSON(201, models.DealNote{})}},
+
+		// Comments
+		{Method: "GET", Path: "/projects/{id}/comments", Handler: handlers.GetProjectComments, Summary: "List comments", Tag: "comments",
+			Query:     append([]openapi.Param{{Name: "sort", Enum: []string{"newest", "oldest"}}}, pageQuery...),
+			Responses: []openapi.Response{openapi.JSON(200, models.CommentListResponse{})}},
+		{Method: "POST", Path: "/projects/{id}/comments", Handler: handlers.CreateComment, Summary: "Comment on a project", Tag: "comments", Auth: true,
+			Body: models.CommentRequest{}, Responses: []openapi.Response{openapi.JSON(201, models.Comment{})}},
+		{Method: "PUT", Path: "/comments/{id}", Handler: handlers.HandleCommentActions, Summary: "Edit a comment", Tag: "comments", Auth: true,
+			Body: models.CommentRequest{}, Responses: []openapi.Response{openapi.JSON(200, models.Comment{})}},
+		{Method: "DELETE", Path: "/comments/{id}", Handler: handlers.HandleCommentActions, Summary: "Delete a comment", Tag: "comments", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, message)}},
+
+		// Social
+		{Method: "GET", Path: "/feed", Handler: handlers.GetFeed, Summary: "Activity of followed users", Tag: "social", Auth: true,
+			Query:     append([]openapi.Param{{Name: "include", Description: "Comma-separated extra activity: likes, stars"}}, pageQuery...),
+			Responses: []openapi.Response{openapi.JSON(200, models.FeedResponse{})}},
+		{Method: "PUT", Path: "/users/{username}/follow", Handler: handlers.FollowUser, Summary: "Follow a user", Tag: "social", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.FollowResponse{})}},
+		{Method: "POST", Path: "/users/{username}/follow", Handler: handlers.FollowUser, Summary: "Follow a user, or toggle with ?toggle=true", Tag: "social", Auth: true,
+			Query:     []openapi.Param{{Name: "toggle", Type: "boolean"}},
+			Responses: []openapi.Response{openapi.JSON(200, models.FollowResponse{})}},
+		{Method: "DELETE", Path: "/users/{username}/follow", Handler: handlers.FollowUser, Summary: "Unfollow a user", Tag: "social", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.FollowResponse{})}},
+		{Method: "DELETE", Path: "/users/{username}/unfollow", Handler: handlers.UnfollowUser, Summary: "Unfollow a user (older clients)", Tag: "social", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.FollowResponse{})}},
+		{Method: "GET", Path: "/users/{username}/follow/status", Handler: handlers.CheckFollowStatus, Summary: "Whether the caller follows a user", Tag: "social", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, models.FollowStatusResponse{})}},
+		{Method: "GET", Path: "/users/suggested", Handler: handlers.GetSuggestedUsers, Summary: "People to follow", Tag: "social", Auth: true,
+			Responses: []openapi.Response{openapi.JSON(200, []models.SuggestedUser{})}},
+		{Method: "GET", Path: "/users/{username}/followers", Handler: handlers.GetFollowers, Summary: "A user's followers", Tag: "social",
+			Query: pageQuery, Responses: []openapi.Response{openapi.JSON(200, models.FollowListResponse{})}},
+		{Method: "GET", Path: "/users/{username}/following", Handler: handlers.GetFollowing, Summary: "Who a user follows", Tag: "social",
+			Query: pageQuery, Responses: []openapi.Response{openapi.JSON(200, models.FollowListResponse{})}},
+
+		// GraphQL
+		{Method: "POST", Path: "/graphql", Handler: handlers.GraphQL, Summary: "Run a GraphQL query over users and projects", Tag: "graphql",
+			Body: models.GraphQLRequest{}, Responses: []openapi.Response{openapi.JSON(200, map[string]interface{}{})}},
+		{Method: "GET", Path: "/graphql", Handler: handlers.GraphQL, Summary: "Run a GraphQL query given as ?query=", Tag: "graphql",
+			Query: []openapi.Param{
+				{Name: "query", Required: true},
+				{Name: "operationName"},
+				{Name: "variables", Description: "JSON object of variables"},
+			},
+			Responses: []openapi.Response{openapi.JSON(200, map[string]interface{}{})}},
+
+		// Developer profiles; "projects" is matched before project names
+		{Method: "GET", Path: "/dev/{username}", Handler: handlers.GetDeveloperProfile, Summary: "A developer's profile", Tag: "users",
+			Responses: []openapi.Response{openapi.JSON(200, models.UserProfile{})}},
+		{Method: "GET", Path: "/dev/{username}/projects", Handler: handlers.GetDeveloperProjects, Summary: "A developer's projects", Tag: "users",
+			Responses: []openapi.Response{openapi.JSON(200, []models.Project{})}},
+		{Method: "GET", Path: "/dev/{username}/{project}", Handler: handlers.GetDeveloperProject, Summary: "A project's detail page", Tag: "projects",
+			Responses: []openapi.Response{openapi.JSON(200, models.Project{})}},
+	}
+}
@@ -31,3 +31,592 @@ CREATE TABLE IF NOT EXISTS projects (
     images TEXT[],
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
+
+CREATE TABLE IF NOT EXISTS followers (
+    id SERIAL PRIMARY KEY,
+    follower_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    following_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT followers_follower_following_key UNIQUE (follower_id, following_id)
+);
+
+CREATE TABLE IF NOT EXISTS project_likes (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, project_id)
+);
+
+CREATE TABLE IF NOT EXISTS project_saves (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, project_id)
+);
+
+CREATE TABLE IF NOT EXISTS project_stars (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, project_id)
+);
+
+-- Indexes backing the following feed (fan-out on read)
+CREATE INDEX IF NOT EXISTS idx_followers_follower ON followers (follower_id, following_id);
+CREATE INDEX IF NOT EXISTS idx_followers_following ON followers (following_id, follower_id);
+CREATE INDEX IF NOT EXISTS idx_projects_user_created ON projects (user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_project_likes_user_created ON project_likes (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_project_stars_user_created ON project_stars (user_id, created_at DESC);
+
+-- Older databases created followers without a unique pair; drop duplicate
+-- rows (keeping the oldest) and add the constraint follow inserts rely on
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'followers_follower_following_key'
+    ) THEN
+        DELETE FROM followers a
+        USING followers b
+        WHERE a.follower_id = b.follower_id
+          AND a.following_id = b.following_id
+          AND a.id > b.id;
+
+        ALTER TABLE followers
+            ADD CONSTRAINT followers_follower_following_key UNIQUE (follower_id, following_id);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS project_comments (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES project_comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_comments_project ON project_comments (project_id, parent_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_project_comments_parent ON project_comments (parent_id, created_at);
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id INTEGER REFERENCES project_comments(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS project_views (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_views_project_created ON project_views (project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_project_views_created ON project_views (created_at);
+
+-- Recomputed periodically by jobs.RecomputeTrending
+CREATE TABLE IF NOT EXISTS project_trending (
+    project_id INTEGER PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_trending_score ON project_trending (score DESC);
+
+-- Renditions generated for each processed upload, keyed by the URL stored
+-- on the owning row (users.profile_picture, projects.images, ...)
+CREATE TABLE IF NOT EXISTS image_variants (
+    url VARCHAR(255) PRIMARY KEY,
+    medium_url VARCHAR(255) NOT NULL,
+    thumbnail_url VARCHAR(255) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS cover_image TEXT;
+
+-- Customised business model export layout (see export.Template); projects
+-- without a row use the default template
+CREATE TABLE IF NOT EXISTS business_model_templates (
+    project_id INTEGER PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    template JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Versioned business model canvas; each edit inserts a new row and the
+-- highest version of a project is its current canvas
+CREATE TABLE IF NOT EXISTS business_model_canvases (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    key_partners TEXT[] NOT NULL DEFAULT '{}',
+    key_activities TEXT[] NOT NULL DEFAULT '{}',
+    key_resources TEXT[] NOT NULL DEFAULT '{}',
+    value_propositions TEXT[] NOT NULL DEFAULT '{}',
+    customer_relationships TEXT[] NOT NULL DEFAULT '{}',
+    channels TEXT[] NOT NULL DEFAULT '{}',
+    customer_segments TEXT[] NOT NULL DEFAULT '{}',
+    cost_structure TEXT[] NOT NULL DEFAULT '{}',
+    revenue_streams TEXT[] NOT NULL DEFAULT '{}',
+    edited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, version)
+);
+
+-- Project status is a state machine (see handlers/project_status.go). Map
+-- the free-form values older clients wrote onto the known states before
+-- constraining the column.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'projects_status_check'
+    ) THEN
+        UPDATE projects SET status = CASE
+            WHEN status IN ('Only an Idea', 'Under development', 'Ready for production', 'On hold', 'Archived') THEN status
+            WHEN LOWER(status) LIKE '%develop%' THEN 'Under development'
+            WHEN LOWER(status) LIKE '%production%' OR LOWER(status) LIKE '%ready%' THEN 'Ready for production'
+            ELSE 'Only an Idea'
+        END;
+
+        ALTER TABLE projects ALTER COLUMN status SET DEFAULT 'Only an Idea';
+        ALTER TABLE projects ALTER COLUMN status SET NOT NULL;
+        ALTER TABLE projects ADD CONSTRAINT projects_status_check
+            CHECK (status IN ('Only an Idea', 'Under development', 'Ready for production', 'On hold', 'Archived'));
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS milestones (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    due_date DATE,
+    state VARCHAR(20) NOT NULL DEFAULT 'todo'
+        CHECK (state IN ('todo', 'in_progress', 'done', 'cancelled')),
+    assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_milestones_project ON milestones (project_id, due_date);
+
+-- Timeline of project status transitions and milestone state changes
+CREATE TABLE IF NOT EXISTS project_activity (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    type VARCHAR(30) NOT NULL,
+    milestone_id INTEGER REFERENCES milestones(id) ON DELETE SET NULL,
+    milestone_title VARCHAR(200),
+    from_state VARCHAR(50),
+    to_state VARCHAR(50),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_activity_project_created ON project_activity (project_id, created_at DESC, id DESC);
+
+-- Project teams. projects.user_id stays the owner and always has an
+-- 'owner' row here.
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL
+        CHECK (role IN ('owner', 'maintainer', 'developer', 'observer')),
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_members_user ON project_members (user_id);
+
+-- Projects created before teams existed
+INSERT INTO project_members (project_id, user_id, role, joined_at)
+SELECT id, user_id, 'owner', created_at FROM projects WHERE user_id IS NOT NULL
+ON CONFLICT (project_id, user_id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS project_invitations (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    inviter_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    invitee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL
+        CHECK (role IN ('maintainer', 'developer', 'observer')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one open invitation per user and project
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_invitations_pending
+    ON project_invitations (project_id, invitee_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_project_invitations_invitee ON project_invitations (invitee_id, status);
+
+CREATE TABLE IF NOT EXISTS project_applications (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    applicant_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'shortlisted', 'accepted', 'rejected', 'withdrawn')),
+    decision_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one open application per developer and project
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_applications_open
+    ON project_applications (project_id, applicant_id) WHERE status IN ('pending', 'shortlisted');
+CREATE INDEX IF NOT EXISTS idx_project_applications_project ON project_applications (project_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_project_applications_applicant ON project_applications (applicant_id, created_at DESC, id DESC);
+
+-- Projects an applicant points to as examples of their work
+CREATE TABLE IF NOT EXISTS application_portfolio (
+    application_id INTEGER REFERENCES project_applications(id) ON DELETE CASCADE,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (application_id, project_id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    application_id INTEGER REFERENCES project_applications(id) ON DELETE CASCADE,
+    message TEXT NOT NULL DEFAULT '',
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications (user_id, created_at DESC, id DESC);
+
+-- What an investor looks for; only entrepreneur/investor accounts have one
+CREATE TABLE IF NOT EXISTS investor_profiles (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    ticket_min BIGINT CHECK (ticket_min >= 0),
+    ticket_max BIGINT CHECK (ticket_max >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    sectors TEXT[] NOT NULL DEFAULT '{}',
+    stages TEXT[] NOT NULL DEFAULT '{}',
+    thesis TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- An investor's deal on a project
+CREATE TABLE IF NOT EXISTS investment_interests (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    investor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stage VARCHAR(20) NOT NULL DEFAULT 'interested'
+        CHECK (stage IN ('interested', 'in_talks', 'committed', 'passed')),
+    amount BIGINT CHECK (amount > 0),
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, investor_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_investment_interests_investor ON investment_interests (investor_id, updated_at DESC);
+
+-- Notes are only ever shown to their author
+CREATE TABLE IF NOT EXISTS deal_notes (
+    id SERIAL PRIMARY KEY,
+    deal_id INTEGER NOT NULL REFERENCES investment_interests(id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_deal_notes_deal ON deal_notes (deal_id, author_id, created_at DESC);
+
+-- Skills catalogue. slug is the lowercased name used for lookups; aliases
+-- map other spellings onto a skill.
+CREATE TABLE IF NOT EXISTS skills (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    slug VARCHAR(50) NOT NULL UNIQUE,
+    category VARCHAR(20) NOT NULL DEFAULT 'other'
+        CHECK (category IN ('language', 'framework', 'database', 'tool', 'platform', 'other'))
+);
+
+-- Names users list that the catalogue doesn't know are added unapproved:
+-- they show on those users' profiles but stay out of autocomplete and
+-- programming tags until someone sets approved
+ALTER TABLE skills ADD COLUMN IF NOT EXISTS approved BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS skill_aliases (
+    alias VARCHAR(50) PRIMARY KEY,
+    skill_id INTEGER NOT NULL REFERENCES skills(id) ON DELETE CASCADE
+);
+
+INSERT INTO skills (name, slug, category, approved)
+SELECT name, slug, category, TRUE FROM (VALUES
+    ('Go', 'go', 'language'),
+    ('JavaScript', 'javascript', 'language'),
+    ('TypeScript', 'typescript', 'language'),
+    ('Python', 'python', 'language'),
+    ('Java', 'java', 'language'),
+    ('Kotlin', 'kotlin', 'language'),
+    ('Swift', 'swift', 'language'),
+    ('Rust', 'rust', 'language'),
+    ('C', 'c', 'language'),
+    ('C++', 'c++', 'language'),
+    ('C#', 'c#', 'language'),
+    ('Ruby', 'ruby', 'language'),
+    ('PHP', 'php', 'language'),
+    ('Dart', 'dart', 'language'),
+    ('SQL', 'sql', 'language'),
+    ('React', 'react', 'framework'),
+    ('React Native', 'react native', 'framework'),
+    ('Vue', 'vue', 'framework'),
+    ('Angular', 'angular', 'framework'),
+    ('Next.js', 'next.js', 'framework'),
+    ('Node.js', 'node.js', 'platform'),
+    ('Django', 'django', 'framework'),
+    ('Flask', 'flask', 'framework'),
+    ('Spring', 'spring', 'framework'),
+    ('Ruby on Rails', 'ruby on rails', 'framework'),
+    ('Flutter', 'flutter', 'framework'),
+    ('PostgreSQL', 'postgresql', 'database'),
+    ('MySQL', 'mysql', 'database'),
+    ('MongoDB', 'mongodb', 'database'),
+    ('Redis', 'redis', 'database'),
+    ('Docker', 'docker', 'tool'),
+    ('Kubernetes', 'kubernetes', 'platform'),
+    ('AWS', 'aws', 'platform'),
+    ('Google Cloud', 'google cloud', 'platform'),
+    ('Azure', 'azure', 'platform'),
+    ('Git', 'git', 'tool'),
+    ('GraphQL', 'graphql', 'tool'),
+    ('Machine Learning', 'machine learning', 'other')
+) AS s(name, slug, category)
+ON CONFLICT (slug) DO UPDATE SET approved = TRUE;
+
+INSERT INTO skill_aliases (alias, skill_id)
+SELECT a.alias, s.id
+FROM (VALUES
+    ('golang', 'go'),
+    ('js', 'javascript'),
+    ('ecmascript', 'javascript'),
+    ('ts', 'typescript'),
+    ('py', 'python'),
+    ('python3', 'python'),
+    ('cpp', 'c++'),
+    ('csharp', 'c#'),
+    ('c sharp', 'c#'),
+    ('rb', 'ruby'),
+    ('reactjs', 'react'),
+    ('react.js', 'react'),
+    ('vuejs', 'vue'),
+    ('vue.js', 'vue'),
+    ('angularjs', 'angular'),
+    ('nextjs', 'next.js'),
+    ('node', 'node.js'),
+    ('nodejs', 'node.js'),
+    ('spring boot', 'spring'),
+    ('rails', 'ruby on rails'),
+    ('ror', 'ruby on rails'),
+    ('postgres', 'postgresql'),
+    ('psql', 'postgresql'),
+    ('mongo', 'mongodb'),
+    ('k8s', 'kubernetes'),
+    ('amazon web services', 'aws'),
+    ('gcp', 'google cloud'),
+    ('ml', 'machine learning')
+) AS a(alias, slug)
+JOIN skills s ON s.slug = a.slug
+ON CONFLICT (alias) DO NOTHING;
+
+-- Skills a user lists on their profile, in their chosen order
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    skill_id INTEGER REFERENCES skills(id) ON DELETE CASCADE,
+    level VARCHAR(20) NOT NULL DEFAULT 'intermediate'
+        CHECK (level IN ('beginner', 'intermediate', 'advanced', 'expert')),
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, skill_id)
+);
+
+-- Endorsements go when the skill leaves the user's list
+CREATE TABLE IF NOT EXISTS skill_endorsements (
+    user_id INTEGER,
+    skill_id INTEGER,
+    endorser_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, skill_id, endorser_id),
+    FOREIGN KEY (user_id, skill_id) REFERENCES user_skills(user_id, skill_id) ON DELETE CASCADE,
+    CHECK (endorser_id <> user_id)
+);
+
+-- Tag taxonomy. Each tag belongs to one project tag column (kind); slug is
+-- the lowercased name used for lookups and aliases map other spellings
+-- onto a tag.
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    slug VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('general', 'programming')),
+    category VARCHAR(20) NOT NULL DEFAULT 'other',
+    UNIQUE (kind, slug)
+);
+
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    kind VARCHAR(20) NOT NULL,
+    alias VARCHAR(50) NOT NULL,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (kind, alias)
+);
+
+-- Programming tags start from the skills catalogue
+INSERT INTO tags (name, slug, kind, category)
+SELECT name, slug, 'programming', category FROM skills WHERE approved
+ON CONFLICT (kind, slug) DO NOTHING;
+
+INSERT INTO tag_aliases (kind, alias, tag_id)
+SELECT 'programming', a.alias, t.id
+FROM skill_aliases a
+JOIN skills s ON s.id = a.skill_id AND s.approved
+JOIN tags t ON t.kind = 'programming' AND t.slug = s.slug
+ON CONFLICT (kind, alias) DO NOTHING;
+
+INSERT INTO tags (name, slug, kind, category) VALUES
+    ('AI', 'ai', 'general', 'technology'),
+    ('Blockchain', 'blockchain', 'general', 'technology'),
+    ('IoT', 'iot', 'general', 'technology'),
+    ('Mobile', 'mobile', 'general', 'platform'),
+    ('Web', 'web', 'general', 'platform'),
+    ('Open Source', 'open source', 'general', 'other'),
+    ('SaaS', 'saas', 'general', 'business'),
+    ('E-commerce', 'e-commerce', 'general', 'industry'),
+    ('FinTech', 'fintech', 'general', 'industry'),
+    ('HealthTech', 'healthtech', 'general', 'industry'),
+    ('EdTech', 'edtech', 'general', 'industry'),
+    ('Gaming', 'gaming', 'general', 'industry'),
+    ('Social', 'social', 'general', 'industry'),
+    ('Sustainability', 'sustainability', 'general', 'industry')
+ON CONFLICT (kind, slug) DO NOTHING;
+
+INSERT INTO tag_aliases (kind, alias, tag_id)
+SELECT 'general', a.alias, t.id
+FROM (VALUES
+    ('artificial intelligence', 'ai'),
+    ('machine learning', 'ai'),
+    ('crypto', 'blockchain'),
+    ('web3', 'blockchain'),
+    ('internet of things', 'iot'),
+    ('opensource', 'open source'),
+    ('oss', 'open source'),
+    ('ecommerce', 'e-commerce'),
+    ('e commerce', 'e-commerce'),
+    ('finance', 'fintech'),
+    ('health', 'healthtech'),
+    ('healthcare', 'healthtech'),
+    ('education', 'edtech'),
+    ('games', 'gaming'),
+    ('social media', 'social'),
+    ('climate', 'sustainability'),
+    ('green', 'sustainability')
+) AS a(alias, slug)
+JOIN tags t ON t.kind = 'general' AND t.slug = a.slug
+ON CONFLICT (kind, alias) DO NOTHING;
+
+-- clean_tag applies the rules the API enforces on new tags to an old value:
+-- whitespace collapsed, '/' (which /tags/{slug} can't route) replaced by
+-- '-', and cut to the 50 characters a tag can hold
+CREATE OR REPLACE FUNCTION clean_tag(raw TEXT) RETURNS TEXT AS $$
+    SELECT btrim(left(btrim(regexp_replace(replace(raw, '/', '-'), '\s+', ' ', 'g')), 50))
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- canonical_tags maps tag values onto their canonical names, dropping empty
+-- values and duplicates but keeping the original order
+CREATE OR REPLACE FUNCTION canonical_tags(raw_tags TEXT[], tag_kind TEXT) RETURNS TEXT[] AS $$
+    SELECT COALESCE(array_agg(c.name ORDER BY c.first_pos), '{}')
+    FROM (
+        SELECT t.name, MIN(u.pos) AS first_pos
+        FROM unnest(raw_tags) WITH ORDINALITY AS u(raw, pos)
+        JOIN tags t ON t.kind = tag_kind AND t.id = COALESCE(
+            (SELECT a.tag_id FROM tag_aliases a
+             WHERE a.kind = tag_kind AND a.alias = lower(clean_tag(u.raw))),
+            (SELECT s.id FROM tags s
+             WHERE s.kind = tag_kind AND s.slug = lower(clean_tag(u.raw)))
+        )
+        GROUP BY t.id, t.name
+    ) c
+$$ LANGUAGE SQL STABLE;
+
+-- Clean up tags written before the taxonomy: register values it doesn't
+-- know yet under their first spelling, then rewrite every project's tags
+-- to canonical names. Values the API wouldn't accept today are cleaned
+-- rather than dropped, and reported.
+DO $$
+DECLARE
+    changed INTEGER;
+BEGIN
+    SELECT COUNT(*) INTO changed
+    FROM projects p, unnest(p.general_tags || p.programming_tags) AS tag
+    WHERE clean_tag(tag) <> btrim(regexp_replace(tag, '\s+', ' ', 'g'));
+    IF changed > 0 THEN
+        RAISE NOTICE 'shortening or replacing ''/'' in % project tags', changed;
+    END IF;
+END $$;
+
+INSERT INTO tags (name, slug, kind)
+SELECT DISTINCT ON (v.kind, lower(v.name)) v.name, lower(v.name), v.kind
+FROM (
+    SELECT clean_tag(tag) AS name, 'general' AS kind, p.created_at
+    FROM projects p, unnest(p.general_tags) AS tag
+    UNION ALL
+    SELECT clean_tag(tag), 'programming', p.created_at
+    FROM projects p, unnest(p.programming_tags) AS tag
+) v
+WHERE v.name <> ''
+  AND NOT EXISTS (SELECT 1 FROM tag_aliases a WHERE a.kind = v.kind AND a.alias = lower(v.name))
+ORDER BY v.kind, lower(v.name), v.created_at
+ON CONFLICT (kind, slug) DO NOTHING;
+
+UPDATE projects
+SET general_tags = canonical_tags(general_tags, 'general'),
+    programming_tags = canonical_tags(programming_tags, 'programming')
+WHERE general_tags IS DISTINCT FROM canonical_tags(general_tags, 'general')
+   OR programming_tags IS DISTINCT FROM canonical_tags(programming_tags, 'programming');
+
+CREATE INDEX IF NOT EXISTS idx_projects_general_tags ON projects USING GIN (general_tags);
+CREATE INDEX IF NOT EXISTS idx_projects_programming_tags ON projects USING GIN (programming_tags);
+
+-- Views are de-duplicated per viewer: "u:<id>" for signed-in users, a hash
+-- of address and user agent for anonymous ones. Older rows count as
+-- distinct viewers.
+ALTER TABLE project_views ADD COLUMN IF NOT EXISTS viewer_key VARCHAR(64);
+UPDATE project_views
+SET viewer_key = CASE WHEN user_id IS NOT NULL THEN 'u:' || user_id ELSE 'legacy:' || id END
+WHERE viewer_key IS NULL;
+ALTER TABLE project_views ALTER COLUMN viewer_key SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_project_views_viewer ON project_views (project_id, viewer_key, created_at DESC);
+
+-- Recomputed periodically by jobs.RecomputeProjectStats
+CREATE TABLE IF NOT EXISTS project_daily_stats (
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    unique_viewers INTEGER NOT NULL DEFAULT 0,
+    likes INTEGER NOT NULL DEFAULT 0,
+    saves INTEGER NOT NULL DEFAULT 0,
+    stars INTEGER NOT NULL DEFAULT 0,
+    follows_gained INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_daily_stats_day ON project_daily_stats (day);
+
+-- Deleting a canvas adds an empty version with deleted set instead of
+-- dropping its history
+ALTER TABLE business_model_canvases ADD COLUMN IF NOT EXISTS deleted BOOLEAN NOT NULL DEFAULT FALSE;
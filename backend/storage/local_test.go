@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "ab/cd/abcd.png"
+	if ok, err := store.Exists(ctx, key); err != nil || ok {
+		t.Fatalf("Exists before Put = %v, %v; want false, nil", ok, err)
+	}
+	if err := store.Put(ctx, key, []byte("first"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, key, []byte("second"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Exists(ctx, key); err != nil || !ok {
+		t.Fatalf("Exists after Put = %v, %v; want true, nil", ok, err)
+	}
+
+	body, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Get = %q, want the last Put", data)
+	}
+	if info.ContentType != "image/png" || info.Size != int64(len("second")) {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestLocalMissingAndInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocal(filepath.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := store.Get(ctx, "no/such/key.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get missing = %v, want ErrNotFound", err)
+	}
+	for _, key := range []string{"../secret", "/etc/passwd", "a/../../secret", `a\b`, ""} {
+		if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want ErrNotFound", key, err)
+		}
+		if err := store.Put(ctx, key, []byte("x"), "image/png"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+	}
+}
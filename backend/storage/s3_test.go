@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-west-1"
+	testBucket    = "uploads"
+)
+
+// fakeS3 is a stand-in for an S3 service: it checks every request's SigV4
+// signature independently of the client and keeps objects in memory
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := f.verify(r, body); err != "" {
+		f.t.Errorf("%s %s: %s", r.Method, r.URL.Path, err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+testBucket+"/")
+	if !ok {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = fakeObject{data: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the request's signature, returning what's wrong with it
+func (f *fakeS3) verify(r *http.Request, body []byte) string {
+	auth := r.Header.Get("Authorization")
+	date := r.Header.Get("X-Amz-Date")
+	if len(date) != len("20060102T150405Z") {
+		return "missing X-Amz-Date"
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	if r.Header.Get("X-Amz-Content-Sha256") != payloadHash {
+		return "payload hash doesn't match the body"
+	}
+
+	canonical := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		"host:" + r.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + date + "\n",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+	canonicalSum := sha256.Sum256([]byte(canonical))
+	scope := date[:8] + "/" + testRegion + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{date[:8], testRegion, "s3", "aws4_request"} {
+		key = mac(key, part)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=" + testAccessKey + "/" + scope +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + hex.EncodeToString(mac(key, toSign))
+	if auth != want {
+		return "Authorization = " + auth + ", want " + want
+	}
+	return ""
+}
+
+func mac(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func newTestS3(t *testing.T) *S3 {
+	server := httptest.NewServer(&fakeS3{t: t, objects: map[string]fakeObject{}})
+	t.Cleanup(server.Close)
+
+	store, err := NewS3(server.URL, testBucket, testRegion, testAccessKey, testSecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestS3RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newTestS3(t)
+
+	key := "ab/cd/abcd.png"
+	if ok, err := store.Exists(ctx, key); err != nil || ok {
+		t.Fatalf("Exists before Put = %v, %v; want false, nil", ok, err)
+	}
+	if err := store.Put(ctx, key, []byte("image bytes"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Exists(ctx, key); err != nil || !ok {
+		t.Fatalf("Exists after Put = %v, %v; want true, nil", ok, err)
+	}
+
+	body, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	if string(data) != "image bytes" {
+		t.Errorf("Get = %q", data)
+	}
+	if info.ContentType != "image/png" || info.Size != int64(len(data)) {
+		t.Errorf("info = %+v", info)
+	}
+
+	if _, _, err := store.Get(ctx, "no/such/key.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get missing = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3ReportsServiceErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store, err := NewS3(server.URL, testBucket, testRegion, testAccessKey, testSecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(context.Background(), "a.png", []byte("x"), "image/png")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put = %v, want the service's error", err)
+	}
+	if _, err := store.Exists(context.Background(), "a.png"); err == nil {
+		t.Error("Exists succeeded on a 403")
+	}
+}
+
+func TestNewS3Validates(t *testing.T) {
+	if _, err := NewS3("not a url", testBucket, "", "", ""); err == nil {
+		t.Error("accepted an invalid endpoint")
+	}
+	if _, err := NewS3("http://localhost:9000", "", "", "", ""); err == nil {
+		t.Error("accepted an empty bucket")
+	}
+}
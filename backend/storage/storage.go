@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// URLPrefix is the route uploaded files are served from
+const URLPrefix = "/media/"
+
+var ErrNotFound = errors.New("object not found")
+
+// ErrUnsupportedType is returned for uploads that aren't one of the image
+// types served back to browsers
+var ErrUnsupportedType = errors.New("unsupported file type")
+
+// servedTypes are the types Save accepts. Anything else, HTML or SVG in
+// particular, could run script when opened from the media route.
+var servedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Storage is a blob store for uploaded files
+type Storage interface {
+	// Put writes data under key, replacing anything already there
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the object stored under key. Callers must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Exists reports whether an object is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+type ObjectInfo struct {
+	ContentType string
+	Size        int64
+}
+
+// Default is the store used by the handlers, set up by Init
+var Default Storage
+
+// Init configures Default from the environment. STORAGE_BACKEND selects
+// "local" (the default, rooted at STORAGE_DIR) or "s3" (see NewS3FromEnv).
+func Init() {
+	var err error
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		Default, err = NewLocal(dir)
+	case "s3":
+		Default, err = NewS3FromEnv()
+	default:
+		err = fmt.Errorf("unknown storage backend %q", backend)
+	}
+
+	if err != nil {
+		slog.Error("Error setting up file storage", "err", err)
+		os.Exit(1)
+	}
+}
+
+// ContentKey derives a key from the file contents so identical uploads share
+// one object and a key never points at different bytes over time
+func ContentKey(data []byte, contentType string) string {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	ext := ""
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		ext = preferredExtension(contentType, exts)
+	}
+
+	// Shard by hash prefix so no single directory grows too large
+	return hash[:2] + "/" + hash[2:4] + "/" + hash + ext
+}
+
+func preferredExtension(contentType string, exts []string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	}
+	return exts[0]
+}
+
+// Save stores data in Default under its content key and returns the URL it
+// is served from. The type is sniffed from data rather than taken from the
+// uploader, and only images are accepted.
+func Save(ctx context.Context, data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !servedTypes[contentType] {
+		return "", ErrUnsupportedType
+	}
+	key := ContentKey(data, contentType)
+
+	exists, err := Default.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := Default.Put(ctx, key, data, contentType); err != nil {
+			return "", err
+		}
+	}
+
+	return URLPrefix + key, nil
+}
+
+// ValidKey rejects keys that could escape the storage root
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	return path.Clean(key) == key && !strings.HasPrefix(key, "..")
+}
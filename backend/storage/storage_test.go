@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestSaveSniffsType(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := Default
+	Default = store
+	defer func() { Default = old }()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	url, err := Save(ctx, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(url, URLPrefix) || !strings.HasSuffix(url, ".png") {
+		t.Errorf("Save = %q, want a .png under %s", url, URLPrefix)
+	}
+	again, err := Save(ctx, buf.Bytes())
+	if err != nil || again != url {
+		t.Errorf("saving the same bytes again = %q, %v; want %q", again, err, url)
+	}
+
+	for _, data := range []string{
+		"<html><script>alert(1)</script></html>",
+		`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`,
+		"plain text",
+	} {
+		if _, err := Save(ctx, []byte(data)); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("Save(%.20q) = %v, want ErrUnsupportedType", data, err)
+		}
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"ab/cd/abcd.png", true},
+		{"file.jpg", true},
+		{"", false},
+		{"/abs", false},
+		{"../up", false},
+		{"a/../b", false},
+		{"a//b", false},
+		{`a\b`, false},
+	}
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
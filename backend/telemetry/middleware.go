@@ -0,0 +1,163 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth-app-backend/metrics"
+	"auth-app-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries a request's ID. An ID sent by the caller, such as
+// a proxy, is kept so both sides log the same one.
+const RequestIDHeader = "X-Request-ID"
+
+var tracer = otel.Tracer(ServiceName)
+
+type requestKey struct{}
+
+// request is what the middleware learns about a request while it's served
+type request struct {
+	id    string
+	route string
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside
+// of one
+func RequestID(ctx context.Context) string {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		return req.id
+	}
+	return ""
+}
+
+// Middleware gives every request an ID, a server span and an access log
+// line with its status, latency and caller, and counts it in the HTTP
+// metrics. Wrap the whole server with it so even requests no route
+// matches are logged.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		req := &request{id: r.Header.Get(RequestIDHeader)}
+		if !validRequestID(req.id) {
+			req.id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, req.id)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx = context.WithValue(ctx, requestKey{}, req)
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		elapsed := time.Since(start)
+		metrics.ObserveRequest(r.Method, req.route, rec.status, elapsed)
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if req.route != "" {
+			span.SetName(r.Method + " " + req.route)
+			span.SetAttributes(semconv.HTTPRoute(req.route))
+		}
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+		}
+		if req.route != "" {
+			attrs = append(attrs, slog.String("route", req.route))
+		}
+		if id := utils.UserIDFromRequest(r); id != 0 {
+			attrs = append(attrs, slog.Int("user_id", id))
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "request", attrs...)
+	})
+}
+
+// Route is router middleware recording the template of the matched route,
+// which names the request's span and groups its log lines
+func Route(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if req, ok := r.Context().Value(requestKey{}).(*request); ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				req.route, _ = route.GetPathTemplate()
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID keeps IDs from callers short and safe to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder notes the status and size of a response as it's written
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
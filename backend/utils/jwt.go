@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,22 +12,69 @@ import (
 
 var jwtSecret = []byte("your-secret-key-change-this-in-production")
 
+const (
+	// tokenLifetime is how long a token is valid for
+	tokenLifetime = 24 * time.Hour
+	// MaxSessionAge is how long after signing in tokens can be refreshed;
+	// past it the user has to log in again
+	MaxSessionAge = 30 * 24 * time.Hour
+)
+
+// ErrSessionExpired is returned when refreshing a token of a session
+// older than MaxSessionAge
+var ErrSessionExpired = errors.New("session expired")
+
 type Claims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	// AuthTime is when the user signed in, carried over by refreshes
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// SessionStart is when the user signed in. Tokens issued before auth_time
+// was recorded fall back to their own issue time.
+func (c *Claims) SessionStart() time.Time {
+	if c.AuthTime != nil {
+		return c.AuthTime.Time
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
 func GenerateToken(userID int, username, email string) (string, error) {
-	// Create token with claims
+	return signToken(userID, username, email, time.Now())
+}
+
+// RefreshToken issues a new token for the session claims belong to,
+// keeping when it started. Sessions older than MaxSessionAge can't be
+// refreshed, and new tokens never outlive that age.
+func RefreshToken(claims *Claims, username, email string) (string, error) {
+	start := claims.SessionStart()
+	if time.Since(start) >= MaxSessionAge {
+		return "", ErrSessionExpired
+	}
+	return signToken(claims.UserID, username, email, start)
+}
+
+func signToken(userID int, username, email string, authTime time.Time) (string, error) {
+	now := time.Now()
+	expires := now.Add(tokenLifetime)
+	if end := authTime.Add(MaxSessionAge); end.Before(expires) {
+		expires = end
+	}
+
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
 		Email:    email,
+		AuthTime: jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // Token expires in 24 hours
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expires),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "auth-app",
 		},
 	}
@@ -56,3 +106,21 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, fmt.Errorf("invalid token")
 }
+
+// ClaimsFromRequest validates the bearer token of a request
+func ClaimsFromRequest(r *http.Request) (*Claims, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return nil, fmt.Errorf("no bearer token")
+	}
+	return ValidateToken(token)
+}
+
+// UserIDFromRequest reads the caller from a bearer token, 0 when there's none
+func UserIDFromRequest(r *http.Request) int {
+	claims, err := ClaimsFromRequest(r)
+	if err != nil {
+		return 0
+	}
+	return claims.UserID
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRefreshTokenKeepsSessionStart(t *testing.T) {
+	signedIn := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	claims := &Claims{UserID: 7, AuthTime: jwt.NewNumericDate(signedIn)}
+
+	token, err := RefreshToken(claims, "alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := ValidateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.UserID != 7 || refreshed.Username != "alice" {
+		t.Errorf("refreshed claims = %+v", refreshed)
+	}
+	if !refreshed.SessionStart().Equal(signedIn) {
+		t.Errorf("session start = %v, want %v", refreshed.SessionStart(), signedIn)
+	}
+	if !refreshed.IssuedAt.After(signedIn) {
+		t.Errorf("iat = %v should be the refresh time", refreshed.IssuedAt)
+	}
+
+	// Refreshing again still counts from the original sign-in
+	again, err := RefreshToken(refreshed, "alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := ValidateToken(again); !c.SessionStart().Equal(signedIn) {
+		t.Errorf("second refresh moved the session start to %v", c.SessionStart())
+	}
+}
+
+func TestRefreshTokenMaxSessionAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   Claims
+		wantErr  error
+		maxUntil time.Duration
+	}{
+		{
+			name:     "fresh session gets a full token",
+			claims:   Claims{AuthTime: jwt.NewNumericDate(time.Now().Add(-time.Hour))},
+			maxUntil: tokenLifetime,
+		},
+		{
+			name:     "token is cut at the session's end",
+			claims:   Claims{AuthTime: jwt.NewNumericDate(time.Now().Add(-MaxSessionAge + time.Hour))},
+			maxUntil: time.Hour,
+		},
+		{
+			name:    "expired session",
+			claims:  Claims{AuthTime: jwt.NewNumericDate(time.Now().Add(-MaxSessionAge - time.Minute))},
+			wantErr: ErrSessionExpired,
+		},
+		{
+			name: "legacy token falls back to iat",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{
+				IssuedAt: jwt.NewNumericDate(time.Now().Add(-MaxSessionAge - time.Minute)),
+			}},
+			wantErr: ErrSessionExpired,
+		},
+		{
+			name:    "no session start",
+			claims:  Claims{},
+			wantErr: ErrSessionExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims.UserID = 1
+			token, err := RefreshToken(&tt.claims, "alice", "alice@example.com")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			c, err := ValidateToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if until := time.Until(c.ExpiresAt.Time); until > tt.maxUntil+time.Second {
+				t.Errorf("token expires in %v, want at most %v", until, tt.maxUntil)
+			}
+		})
+	}
+}
+
+func TestGenerateTokenStartsSession(t *testing.T) {
+	token, err := GenerateToken(1, "alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ValidateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AuthTime == nil || time.Since(c.AuthTime.Time) > time.Minute {
+		t.Errorf("auth_time = %v, want now", c.AuthTime)
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TrustProxy reports whether RATE_LIMIT_TRUST_PROXY=true, i.e. the server
+// sits behind a proxy that sets X-Forwarded-For
+func TrustProxy() bool {
+	return os.Getenv("RATE_LIMIT_TRUST_PROXY") == "true"
+}
+
+// ClientIP returns the address a request came from. The first hop of
+// X-Forwarded-For is only used when trustProxy is set; otherwise any client
+// could claim to be someone else.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			return strings.TrimSpace(strings.Split(fwd, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,4 +1,4 @@
-const BASE_URL = 'http://localhost:8080';
+const BASE_URL = 'http://localhost:8080/api/v1';
 
 // Get token from localStorage
 const getToken = () => {
@@ -116,7 +116,7 @@ export const api = {
 
   followUser: async (username) => {
     const response = await fetch(`${BASE_URL}/users/${username}/follow`, {
-      method: 'POST',
+      method: 'PUT',
       headers: getHeaders(),
     });
     if (!response.ok) {
@@ -127,7 +127,7 @@ export const api = {
   },
 
   unfollowUser: async (username) => {
-    const response = await fetch(`${BASE_URL}/users/${username}/unfollow`, {
+    const response = await fetch(`${BASE_URL}/users/${username}/follow`, {
       method: 'DELETE',
       headers: getHeaders(),
     });
@@ -194,6 +194,53 @@ export const api = {
     return response.json();
   },
 
+  getFeed: async (cursor) => {
+    const query = cursor ? `?cursor=${encodeURIComponent(cursor)}` : '';
+    const response = await fetch(`${BASE_URL}/feed${query}`, {
+      headers: getHeaders(),
+    });
+    if (!response.ok) {
+      throw new Error('Failed to fetch feed');
+    }
+    return response.json();
+  },
+
+  getTrendingProjects: async (tag) => {
+    const query = tag ? `?tag=${encodeURIComponent(tag)}` : '';
+    const response = await fetch(`${BASE_URL}/projects/trending${query}`, {
+      headers: getHeaders(),
+    });
+    if (!response.ok) {
+      throw new Error('Failed to fetch trending projects');
+    }
+    return response.json();
+  },
+
+  getProjectCanvas: async (projectId) => {
+    const response = await fetch(`${BASE_URL}/projects/${projectId}/canvas`, {
+      headers: getHeaders(),
+    });
+    if (response.status === 404) {
+      return null;
+    }
+    if (!response.ok) {
+      throw new Error('Failed to fetch business model canvas');
+    }
+    return response.json();
+  },
+
+  saveProjectCanvas: async (projectId, canvas, baseVersion) => {
+    const response = await fetch(`${BASE_URL}/projects/${projectId}/canvas`, {
+      method: 'PUT',
+      headers: getHeaders(),
+      body: JSON.stringify({ ...canvas, base_version: baseVersion }),
+    });
+    if (!response.ok) {
+      throw new Error(await response.text() || 'Failed to save business model canvas');
+    }
+    return response.json();
+  },
+
   getAllProjects: async () => {
     const response = await fetch(`${BASE_URL}/projects`, {
       headers: getHeaders(),
@@ -231,6 +278,25 @@ export const api = {
     }
   },
 
+  // Adds image files to a project's gallery; the server checks and resizes them
+  uploadProjectImages: async (projectId, files) => {
+    const formData = new FormData();
+    files.forEach(file => formData.append('images', file));
+
+    const response = await fetch(`${BASE_URL}/projects/${projectId}/images`, {
+      method: 'POST',
+      headers: {
+        'Authorization': `Bearer ${getToken()}`
+      },
+      body: formData
+    });
+    if (!response.ok) {
+      const errorText = await response.text();
+      throw new Error(errorText || 'Failed to upload images');
+    }
+    return response.json();
+  },
+
   // Saved Projects
   getSavedProjects: async () => {
     const response = await fetch(`${BASE_URL}/projects/saved`, {
@@ -263,4 +329,40 @@ export const api = {
     }
     return response.json();
   },
+
+  graphql: async (query, variables = {}) => {
+    const response = await fetch(`${BASE_URL}/graphql`, {
+      method: 'POST',
+      headers: getHeaders(),
+      body: JSON.stringify({ query, variables }),
+    });
+    if (!response.ok) {
+      const error = await response.text();
+      throw new Error(error || 'GraphQL request failed');
+    }
+    const result = await response.json();
+    if (result.errors && result.errors.length > 0) {
+      throw new Error(result.errors.map((e) => e.message).join('; '));
+    }
+    return result.data;
+  },
+
+  // Profile, projects and follow status of a developer in one round-trip
+  getDeveloperPage: async (username) => {
+    const data = await api.graphql(
+      `query DeveloperPage($username: String!) {
+        user(username: $username) {
+          id username firstName lastName userType bio
+          profilePicture banner githubLink portfolioLink linkedinLink companyName
+          createdAt followersCount followingCount viewerFollows
+          projects(first: 50) {
+            id name description code status generalTags programmingTags
+            images coverImage createdAt likesCount commentsCount viewerSaved viewerStarred
+          }
+        }
+      }`,
+      { username }
+    );
+    return data.user;
+  },
 };
@@ -90,31 +90,21 @@ const CreateProject = () => {
       return;
     }
 
-    // Convert files to Base64 strings
-    const readFiles = files.map(file => {
-      return new Promise((resolve, reject) => {
-        const reader = new FileReader();
-        reader.onload = () => resolve(reader.result); // Base64 string
-        reader.onerror = reject;
-        reader.readAsDataURL(file);
-      });
-    });
-
-    Promise.all(readFiles)
-      .then(base64Files => {
-        setFormData(prev => ({
-          ...prev,
-          images: [...prev.images, ...base64Files]
-        }));
-      })
-      .catch(err => {
-        console.error('Failed to read files', err);
-        setError('Failed to read images');
-      });
+    // Keep the files to upload once the project exists, with a local preview
+    const images = files.map(file => ({
+      file,
+      name: file.name,
+      preview: URL.createObjectURL(file)
+    }));
+    setFormData(prev => ({
+      ...prev,
+      images: [...prev.images, ...images]
+    }));
   };
 
 
   const handleRemoveImage = (indexToRemove) => {
+    URL.revokeObjectURL(formData.images[indexToRemove].preview);
     setFormData(prev => ({
       ...prev,
       images: prev.images.filter((_, index) => index !== indexToRemove)
@@ -140,15 +130,19 @@ const CreateProject = () => {
     setError('');
 
     try {
-      const projectData = {
-        ...formData,
-        user_id: user?.id,
-        // Only send Base64 strings
-        images: formData.images
-      };
+      const { images, ...projectData } = formData;
 
       const response = await api.createProject(projectData);
 
+      // Images are uploaded to the new project separately
+      if (response && response.id && images.length > 0) {
+        try {
+          await api.uploadProjectImages(response.id, images.map(image => image.file));
+        } catch (uploadErr) {
+          throw new Error(`Project created, but its images failed to upload: ${uploadErr.message}`);
+        }
+      }
+
       if (response && response.code) {
         navigate(`/dev/${user?.username || 'username'}/${response.code}`);
       } else {
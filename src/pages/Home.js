@@ -14,8 +14,14 @@ const Home = () => {
   useEffect(() => {
     const fetchProjects = async () => {
       try {
-        const data = await api.getProjects();
-        setProjects(data);
+        // Prefer projects from followed users, fall back to everything
+        const feed = await api.getFeed().catch(() => null);
+        if (feed && feed.items.length > 0) {
+          setProjects(feed.items.map((item) => item.project));
+        } else {
+          const data = await api.getProjects();
+          setProjects(data);
+        }
         setLoading(false);
       } catch (err) {
         setError('Failed to load projects');
@@ -38,11 +38,11 @@ const ProjectDetail = () => {
 
   const handleDownload = (format) => {
     // In a real app, this would call an API endpoint to generate/download the file
-    // Example: window.open(`/api/projects/${project}/business-model.${format}`, '_blank');
+    // Example: window.open(`/api/v1/projects/${project}/business-model.${format}`, '_blank');
     
     // For now, create a simple download link
     const fileName = `${projectData.name.replace(/\s+/g, '_')}_Business_Model.${format}`;
-    const url = `/api/projects/${projectData.code}/business-model.${format}`;
+    const url = `/api/v1/projects/${projectData.code}/business-model.${format}`;
     
     // Create a temporary anchor element to trigger download
     const link = document.createElement('a');
@@ -53,7 +53,7 @@ const ProjectDetail = () => {
     document.body.removeChild(link);
     
     // In a production environment, you would:
-    // 1. Call your backend API endpoint (e.g., fetch(`/api/projects/${projectData.code}/business-model/${format}`))
+    // 1. Call your backend API endpoint (e.g., fetch(`/api/v1/projects/${projectData.code}/business-model/${format}`))
     // 2. The backend would generate the PDF/PPTX file using libraries like pdfkit, puppeteer (for PDF) or pptxgenjs (for PPTX)
     // 3. Return the file as a blob response
     // 4. Create a download link with the blob URL: